@@ -0,0 +1,118 @@
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件实现了函数参数和返回值在栈上的大小的计算, 和编译器记录在函数信息中的 args 一致,
+// 用于校验按名称 mock 的函数声明的签名。
+package mocker
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// ptrSize 指针的大小
+const ptrSize = unsafe.Sizeof(uintptr(0))
+
+// argsSize 计算函数参数和返回值在栈上的大小, 寄存器传参(ABIInternal)时包括寄存器参数的溢出区
+func argsSize(funcTyp reflect.Type) int32 {
+	intRegs, floatRegs := argRegs()
+	if intRegs == 0 && floatRegs == 0 {
+		return abi0ArgsSize(funcTyp)
+	}
+	var (
+		stack, spill uintptr
+		regs         = &regAssigner{intRegs: intRegs, floatRegs: floatRegs}
+	)
+	for i := 0; i < funcTyp.NumIn(); i++ {
+		if typ := funcTyp.In(i); regs.assign(typ) {
+			spill = alignUp(spill, uintptr(typ.Align())) + typ.Size()
+		} else {
+			stack = alignUp(stack, uintptr(typ.Align())) + typ.Size()
+		}
+	}
+	stack = alignUp(stack, ptrSize)
+	// 返回值重新从第一个寄存器开始分配, 寄存器中的返回值没有溢出区
+	regs = &regAssigner{intRegs: intRegs, floatRegs: floatRegs}
+	for i := 0; i < funcTyp.NumOut(); i++ {
+		if typ := funcTyp.Out(i); !regs.assign(typ) {
+			stack = alignUp(stack, uintptr(typ.Align())) + typ.Size()
+		}
+	}
+	return int32(alignUp(stack, ptrSize) + alignUp(spill, ptrSize))
+}
+
+// abi0ArgsSize 按照 ABI0 的栈布局计算函数参数和返回值的大小
+func abi0ArgsSize(funcTyp reflect.Type) int32 {
+	var size uintptr
+	for i := 0; i < funcTyp.NumIn(); i++ {
+		size = alignUp(size, uintptr(funcTyp.In(i).Align())) + funcTyp.In(i).Size()
+	}
+	size = alignUp(size, ptrSize)
+	for i := 0; i < funcTyp.NumOut(); i++ {
+		size = alignUp(size, uintptr(funcTyp.Out(i).Align())) + funcTyp.Out(i).Size()
+	}
+	return int32(alignUp(size, ptrSize))
+}
+
+// regAssigner 按照 ABIInternal 的规则为参数分配寄存器
+type regAssigner struct {
+	intRegs, floatRegs int
+	ints, floats       int
+}
+
+// assign 为参数分配寄存器, 剩余的寄存器不够时返回 false, 参数通过栈传递
+func (a *regAssigner) assign(typ reflect.Type) bool {
+	ints, floats, ok := regsOf(typ)
+	if !ok || a.ints+ints > a.intRegs || a.floats+floats > a.floatRegs {
+		return false
+	}
+	a.ints += ints
+	a.floats += floats
+	return true
+}
+
+// regsOf 计算参数需要的整数和浮点数寄存器的数量, 长度大于 1 的数组不能通过寄存器传递
+func regsOf(typ reflect.Type) (int, int, bool) {
+	switch typ.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return 0, 1, true
+	case reflect.Complex64, reflect.Complex128:
+		return 0, 2, true
+	case reflect.Int64, reflect.Uint64:
+		if ptrSize == 4 {
+			return 2, 0, true
+		}
+		return 1, 0, true
+	case reflect.String, reflect.Interface:
+		return 2, 0, true
+	case reflect.Slice:
+		return 3, 0, true
+	case reflect.Array:
+		switch typ.Len() {
+		case 0:
+			return 0, 0, true
+		case 1:
+			return regsOf(typ.Elem())
+		default:
+			return 0, 0, false
+		}
+	case reflect.Struct:
+		var ints, floats int
+		for i := 0; i < typ.NumField(); i++ {
+			fieldInts, fieldFloats, ok := regsOf(typ.Field(i).Type)
+			if !ok {
+				return 0, 0, false
+			}
+			ints += fieldInts
+			floats += fieldFloats
+		}
+		return ints, floats, true
+	default:
+		// bool、整数、指针、map、chan、func 等
+		return 1, 0, true
+	}
+}
+
+// alignUp 将 n 向上对齐到 a 的整数倍
+func alignUp(n, a uintptr) uintptr {
+	return (n + a - 1) &^ (a - 1)
+}
@@ -0,0 +1,17 @@
+//go:build (go1.17 && amd64) || (go1.18 && arm64)
+// +build go1.17,amd64 go1.18,arm64
+
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件定义了寄存器传参(ABIInternal)时可以使用的参数寄存器。
+package mocker
+
+import "runtime"
+
+// argRegs 参数可以使用的整数和浮点数寄存器的数量
+func argRegs() (int, int) {
+	if runtime.GOARCH == "arm64" {
+		return 16, 16
+	}
+	return 9, 15
+}
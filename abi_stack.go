@@ -0,0 +1,13 @@
+//go:build !((go1.17 && amd64) || (go1.18 && arm64))
+// +build !go1.17 !amd64
+// +build !go1.18 !arm64
+
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件定义了通过栈传参(ABI0)时的参数寄存器。
+package mocker
+
+// argRegs 通过栈传参, 没有参数寄存器
+func argRegs() (int, int) {
+	return 0, 0
+}
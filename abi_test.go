@@ -0,0 +1,58 @@
+package mocker
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/tencent/goom/internal/unexports"
+)
+
+func TestAbiTestSuite(t *testing.T) {
+	suite.Run(t, new(abiTestSuite))
+}
+
+type abiTestSuite struct {
+	suite.Suite
+}
+
+type abiPoint struct {
+	x, y float64
+}
+
+type abiArgs struct {
+	b    bool
+	i    int32
+	s    string
+	arr  [2]int
+	p    abiPoint
+	many [1]abiPoint
+}
+
+//go:noinline
+func abiScalars(a int8, b int64, c float32, d complex128, e uintptr) (bool, float64) {
+	return a == 0, float64(b) + float64(c) + real(d) + float64(e)
+}
+
+//go:noinline
+func abiComposite(a abiArgs, s []string, m map[string]int, i interface{}) (abiPoint, [2]int, error) {
+	return a.p, a.arr, nil
+}
+
+//go:noinline
+func abiMany(a, b, c, d, e, f, g, h, i, j int, s string) (x, y, z string) {
+	return s, s, s
+}
+
+//go:noinline
+func abiEmpty() {}
+
+func (s *abiTestSuite) TestArgsSize() {
+	for _, f := range []interface{}{abiScalars, abiComposite, abiMany, abiEmpty, (*abiTestSuite).TestArgsSize} {
+		v := reflect.ValueOf(f)
+		size, ok := unexports.FuncArgsSize(v.Pointer())
+		s.Require().True(ok, functionName(f))
+		s.Equal(size, argsSize(v.Type()), functionName(f))
+	}
+}
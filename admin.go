@@ -0,0 +1,294 @@
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件实现了本地的 HTTP 管理接口: 在运行中的进程里通过 HTTP+JSON 查看、应用和取消 mock,
+// mock 规则的格式同 LoadConfig, 只能 mock 白名单中的函数。
+package mocker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/tencent/goom/erro"
+	"github.com/tencent/goom/internal/unexports"
+)
+
+// adminPath 管理接口的路径
+const adminPath = "/goom/mocks"
+
+// errNotAllowed 规则中的函数不在白名单中
+var errNotAllowed = errors.New("func is not allowed to mock")
+
+// errApplyFailed 规则校验通过, 但是应用失败
+var errApplyFailed = errors.New("apply rules failed")
+
+// AdminHandler mock 管理接口的 HTTP 处理器, 可以注册到已有的 http.ServeMux 或者 httptest.Server
+//
+// GET    /goom/mocks             查看白名单和已经应用的规则
+// POST   /goom/mocks             应用请求体中的规则, 格式同 LoadConfig, 同一个函数之前应用的规则会被覆盖
+// DELETE /goom/mocks?func=name   取消函数的 mock, 不指定 func 时取消所有 mock
+//
+// 函数的签名固定为白名单中的类型, 请求的规则中可以省略 signature, 声明的 signature 需要和白名单一致
+type AdminHandler struct {
+	// lock 保护 active
+	lock sync.Mutex
+	// allowed 白名单中的函数和固定的签名, key 为函数名称, 创建之后只读
+	allowed map[string]reflect.Type
+	builder *Builder
+	// active 已经应用的规则, key 为函数名称
+	active map[string][]*ConfigRule
+}
+
+// adminStatus 查看接口的返回值
+type adminStatus struct {
+	// Allowed 可以 mock 的函数
+	Allowed []string `json:"allowed"`
+	// Active 已经应用的规则
+	Active []*ConfigRule `json:"active"`
+}
+
+// adminError 出错时的返回值
+type adminError struct {
+	Error string `json:"error"`
+}
+
+// AdminFunc 白名单中按名称声明的函数, 用于无法获取函数值的未导出函数或方法
+type AdminFunc struct {
+	// Name 函数的完整名称, 同 ConfigRule.Func
+	Name string
+	// Signature 函数的签名, 同 ConfigRule.Signature, 会和函数的真实信息比较参数和返回值的大小
+	Signature string
+}
+
+// NewAdminHandler 创建 mock 管理接口的 HTTP 处理器, 白名单不合法时 panic
+// allowed 允许 mock 的函数, 为函数值(方法使用方法表达式, 比如 (*Struct).Method)或者 AdminFunc,
+// 函数的签名固定为白名单中的类型, 为空时不允许 mock 任何函数
+func NewAdminHandler(allowed ...interface{}) *AdminHandler {
+	h, err := newAdminHandler(allowed)
+	if err != nil {
+		panic(erro.NewIllegalStatusError("NewAdminHandler", err.Error()))
+	}
+	return h
+}
+
+// newAdminHandler 创建 mock 管理接口的 HTTP 处理器, 解析白名单中函数的名称和签名
+func newAdminHandler(allowed []interface{}) (*AdminHandler, error) {
+	h := &AdminHandler{
+		allowed: make(map[string]reflect.Type, len(allowed)),
+		builder: Create(),
+		active:  make(map[string][]*ConfigRule),
+	}
+	for _, f := range allowed {
+		name, typ, err := allowedFunc(f)
+		if err != nil {
+			return nil, err
+		}
+		h.allowed[name] = typ
+	}
+	return h, nil
+}
+
+// allowedFunc 获取白名单中函数的名称和签名
+func allowedFunc(f interface{}) (string, reflect.Type, error) {
+	if af, ok := f.(AdminFunc); ok {
+		entry, err := unexports.FindFuncByName(af.Name)
+		if err != nil {
+			return "", nil, err
+		}
+		if _, _, err := splitFuncName(af.Name); err != nil {
+			return "", nil, err
+		}
+		typ, err := parseSignature(af.Signature)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", af.Name, err)
+		}
+		if err := checkArgsSize(af.Name, entry, typ); err != nil {
+			return "", nil, err
+		}
+		return af.Name, typ, nil
+	}
+	if f == nil || reflect.TypeOf(f).Kind() != reflect.Func {
+		return "", nil, fmt.Errorf("allowed func must be a func or AdminFunc, actual: %T", f)
+	}
+	return functionName(f), reflect.TypeOf(f), nil
+}
+
+// ServeHTTP 处理管理接口的请求
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != adminPath {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, h.status())
+	case http.MethodPost:
+		h.apply(w, r)
+	case http.MethodDelete:
+		if err := h.Cancel(r.URL.Query().Get("func")); err != nil {
+			writeJSON(w, http.StatusNotFound, &adminError{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, h.status())
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		writeJSON(w, http.StatusMethodNotAllowed, &adminError{Error: "method not allowed: " + r.Method})
+	}
+}
+
+// apply 应用请求体中的规则
+func (h *AdminHandler) apply(w http.ResponseWriter, r *http.Request) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, &adminError{Error: err.Error()})
+		return
+	}
+	// 白名单创建之后只读, 解析时不需要加锁
+	config, err := parseConfig("request", data, h.allowed)
+	if err != nil {
+		code := http.StatusBadRequest
+		if errors.Is(err, errNotAllowed) {
+			code = http.StatusForbidden
+		}
+		writeJSON(w, code, &adminError{Error: err.Error()})
+		return
+	}
+	if err := h.Apply(config); err != nil {
+		code := http.StatusForbidden
+		if errors.Is(err, errApplyFailed) {
+			code = http.StatusInternalServerError
+		}
+		writeJSON(w, code, &adminError{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, h.status())
+}
+
+// Apply 应用配置中的规则, 同一个函数之前应用的规则会被覆盖
+// 配置中有不在白名单中的函数, 或者签名和白名单不一致时不应用任何规则;
+// 应用失败时取消本次已经应用的函数的 mock, 这些函数之前应用的规则同样被取消
+func (h *AdminHandler) Apply(config *Config) error {
+	rules := make(map[string][]*ConfigRule)
+	for _, r := range config.Rules() {
+		typ, ok := h.allowed[r.Func]
+		if !ok {
+			return fmt.Errorf("%s: %w: %s", r.Pos(), errNotAllowed, r.Func)
+		}
+		if r.funcTyp != typ {
+			return fmt.Errorf("%s: signature %v of %s is different from the allowed signature %v",
+				r.Pos(), r.funcTyp, r.Func, typ)
+		}
+		rules[r.Func] = append(rules[r.Func], r)
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if rolledBack, err := config.apply(h.builder); err != nil {
+		for _, name := range rolledBack {
+			delete(h.active, name)
+		}
+		return fmt.Errorf("%w: %v", errApplyFailed, err)
+	}
+	for name, rs := range rules {
+		h.active[name] = rs
+	}
+	return nil
+}
+
+// Cancel 取消函数的 mock, name 为空时取消所有 mock
+func (h *AdminHandler) Cancel(name string) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if name == "" {
+		h.builder.Reset()
+		h.active = make(map[string][]*ConfigRule)
+		return nil
+	}
+	if _, ok := h.active[name]; !ok {
+		return fmt.Errorf("func %s is not mocked", name)
+	}
+	pkgName, funcName, _ := splitFuncName(name)
+	h.builder.ExportPkgFunc(pkgName, funcName).Cancel()
+	delete(h.active, name)
+	return nil
+}
+
+// status 获取白名单和已经应用的规则
+func (h *AdminHandler) status() *adminStatus {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	status := &adminStatus{Allowed: make([]string, 0, len(h.allowed)), Active: make([]*ConfigRule, 0)}
+	for name := range h.allowed {
+		status.Allowed = append(status.Allowed, name)
+	}
+	sort.Strings(status.Allowed)
+	names := make([]string, 0, len(h.active))
+	for name := range h.active {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		status.Active = append(status.Active, h.active[name]...)
+	}
+	return status
+}
+
+// writeJSON 输出 JSON 格式的返回值
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// AdminServer mock 管理接口的 HTTP 服务
+type AdminServer struct {
+	*AdminHandler
+	listener net.Listener
+	server   *http.Server
+}
+
+// ServeAdmin 在 addr 上启动 mock 管理接口的 HTTP 服务, 比如 127.0.0.1:0
+// allowed 允许 mock 的函数, 同 NewAdminHandler, 为空时不允许 mock 任何函数
+// 注意: 管理接口可以修改进程中的任意白名单函数, 请只监听本地地址, 并在测试结束时调用 Close
+func ServeAdmin(addr string, allowed ...interface{}) (*AdminServer, error) {
+	handler, err := newAdminHandler(allowed)
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &AdminServer{
+		AdminHandler: handler,
+		listener:     listener,
+		server:       &http.Server{Handler: handler},
+	}
+	go func() {
+		_ = s.server.Serve(listener)
+	}()
+	return s, nil
+}
+
+// Addr 服务监听的地址
+func (s *AdminServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// URL 管理接口的地址
+func (s *AdminServer) URL() string {
+	return "http://" + s.Addr() + adminPath
+}
+
+// Close 关闭服务, 并取消通过服务应用的所有 mock
+func (s *AdminServer) Close() error {
+	err := s.server.Close()
+	_ = s.Cancel("")
+	return err
+}
@@ -0,0 +1,139 @@
+// Package mocker_test 对 mocker 包的测试
+// 当前文件实现了对 admin.go 的单测
+package mocker_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	mocker "github.com/tencent/goom"
+	"github.com/tencent/goom/test"
+)
+
+// TestUnitAdminTestSuite 测试入口
+func TestUnitAdminTestSuite(t *testing.T) {
+	suite.Run(t, new(adminTestSuite))
+}
+
+// adminTestSuite mock 管理接口测试套件
+type adminTestSuite struct {
+	suite.Suite
+}
+
+// joinRule 测试使用的规则
+const joinRule = `[{"name": "join", "func": "github.com/tencent/goom/test.Join",
+  "signature": "func([]string, string) string", "return": ["admin"]}]`
+
+// request 发送请求并解码返回值
+func (s *adminTestSuite) request(method, url, body string) (int, map[string]interface{}) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	s.Require().NoError(err)
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	result := make(map[string]interface{})
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&result))
+	return resp.StatusCode, result
+}
+
+// TestHandler 测试通过 httptest 查看、应用和取消 mock
+func (s *adminTestSuite) TestHandler() {
+	server := httptest.NewServer(mocker.NewAdminHandler(test.Join))
+	defer server.Close()
+	url := server.URL + "/goom/mocks"
+
+	code, status := s.request(http.MethodGet, url, "")
+	s.Equal(http.StatusOK, code)
+	s.Equal([]interface{}{"github.com/tencent/goom/test.Join"}, status["allowed"])
+	s.Empty(status["active"])
+
+	code, status = s.request(http.MethodPost, url, joinRule)
+	s.Equal(http.StatusOK, code)
+	s.Len(status["active"], 1)
+	s.Equal("admin", test.Join([]string{"a"}, ","))
+
+	code, _ = s.request(http.MethodDelete, url+"?func=github.com/tencent/goom/test.Join", "")
+	s.Equal(http.StatusOK, code)
+	s.Equal("a", test.Join([]string{"a"}, ","))
+
+	code, result := s.request(http.MethodDelete, url+"?func=github.com/tencent/goom/test.Join", "")
+	s.Equal(http.StatusNotFound, code)
+	s.Contains(result["error"], "not mocked")
+}
+
+// TestNotAllowed 测试不在白名单中的函数和不合法的规则
+func (s *adminTestSuite) TestNotAllowed() {
+	server := httptest.NewServer(mocker.NewAdminHandler())
+	defer server.Close()
+	url := server.URL + "/goom/mocks"
+
+	code, result := s.request(http.MethodPost, url, joinRule)
+	s.Equal(http.StatusForbidden, code)
+	s.Contains(result["error"], "not allowed")
+	s.Equal("a", test.Join([]string{"a"}, ","))
+
+	code, result = s.request(http.MethodPost, url, `[{"func": "github.com/tencent/goom/test.Join", "retrun": [1]}]`)
+	s.Equal(http.StatusBadRequest, code)
+	s.Contains(result["error"], "request:1")
+
+	code, _ = s.request(http.MethodPut, url, "")
+	s.Equal(http.StatusMethodNotAllowed, code)
+}
+
+// TestPinnedSignature 测试函数的签名固定为白名单中的类型
+func (s *adminTestSuite) TestPinnedSignature() {
+	server := httptest.NewServer(mocker.NewAdminHandler(test.Join, mocker.AdminFunc{
+		Name: "github.com/tencent/goom/test.(*Fake).call", Signature: "func(unsafe.Pointer, int) int",
+	}))
+	defer server.Close()
+	url := server.URL + "/goom/mocks"
+
+	code, result := s.request(http.MethodPost, url, `[{"func": "github.com/tencent/goom/test.Join",
+  "signature": "func([]string, string) int", "return": [1]}]`)
+	s.Equal(http.StatusBadRequest, code)
+	s.Contains(result["error"], "allowed signature")
+	s.Equal("a", test.Join([]string{"a"}, ","))
+
+	// 省略签名时使用白名单中的签名
+	code, _ = s.request(http.MethodPost, url, `[{"func": "github.com/tencent/goom/test.Join", "return": ["admin"]},
+  {"func": "github.com/tencent/goom/test.(*Fake).call", "return": [42]}]`)
+	s.Equal(http.StatusOK, code)
+	s.Equal("admin", test.Join([]string{"a"}, ","))
+	s.Equal(42, (&test.Fake{}).Call(1))
+
+	code, _ = s.request(http.MethodDelete, url, "")
+	s.Equal(http.StatusOK, code)
+	s.Equal(1, (&test.Fake{}).Call(1))
+}
+
+// TestIllegalAllowed 测试不合法的白名单
+func (s *adminTestSuite) TestIllegalAllowed() {
+	_, err := mocker.ServeAdmin("127.0.0.1:0", mocker.AdminFunc{
+		Name: "github.com/tencent/goom/test.(*Fake).call", Signature: "func(int) int",
+	})
+	s.Error(err)
+	s.Contains(err.Error(), "size")
+
+	s.Panics(func() {
+		mocker.NewAdminHandler("github.com/tencent/goom/test.Join")
+	})
+}
+
+// TestServeAdmin 测试在本地地址上启动管理接口
+func (s *adminTestSuite) TestServeAdmin() {
+	server, err := mocker.ServeAdmin("127.0.0.1:0", test.Join)
+	s.Require().NoError(err)
+
+	code, _ := s.request(http.MethodPost, server.URL(), joinRule)
+	s.Equal(http.StatusOK, code)
+	s.Equal("admin", test.Join([]string{"a"}, ","))
+
+	// 关闭服务时取消所有 mock
+	s.NoError(server.Close())
+	s.Equal("a", test.Join([]string{"a"}, ","))
+}
@@ -0,0 +1,191 @@
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件实现了锚点的 Mocker: 对业务代码中通过 anchor.Point 声明的锚点指定条件、返回的错误、延迟或回调函数。
+package mocker
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/tencent/goom/anchor"
+	"github.com/tencent/goom/arg"
+)
+
+// AnchorMocker 锚点 mock, 不修改二进制代码, 因此不受内联的影响
+// 锚点的参数没有固定的类型, 参数条件按照调用时参数的实际类型比较
+type AnchorMocker struct {
+	// lock 保护 imp、conds 和 canceled
+	lock sync.Mutex
+	name string
+	// imp Apply 指定的回调函数, 优先于 conds
+	imp anchor.Hook
+	// conds 参数条件, 按照指定的顺序匹配
+	conds    []*anchorCond
+	canceled bool
+}
+
+// anchorCond 锚点的一个参数条件
+type anchorCond struct {
+	// args 参数条件, 为 nil 时匹配任意参数
+	args  []interface{}
+	err   error
+	delay time.Duration
+}
+
+// NewAnchorMocker 创建锚点 Mocker
+// name 锚点名称, 和 anchor.Point 的 name 一致
+func NewAnchorMocker(name string) *AnchorMocker {
+	return &AnchorMocker{name: name}
+}
+
+// String mock 的名称或描述, 方便调试和问题排查
+func (m *AnchorMocker) String() string {
+	return "anchor " + m.name
+}
+
+// Apply 指定锚点执行的回调函数, 签名为 func(args ...interface{}) error, 返回值作为 anchor.Point 的返回值
+// 注意: Apply 会覆盖之前设定的 When 条件和 Return
+func (m *AnchorMocker) Apply(imp interface{}) {
+	var hook anchor.Hook
+	switch f := imp.(type) {
+	case anchor.Hook:
+		hook = f
+	case func(args ...interface{}) error:
+		hook = f
+	default:
+		panic(fmt.Sprintf("imp of %s must be func(args ...interface{}) error, actual: %T", m.String(), imp))
+	}
+	m.lock.Lock()
+	m.imp, m.conds = hook, nil
+	m.lock.Unlock()
+	m.enable()
+	logApply(m, 5)
+}
+
+// When 指定参数条件, 之后的 Return 和 Delay 作用于该条件; 条件按照指定的顺序匹配, 都不满足时锚点返回 nil
+// 参数可以使用 arg 包中的表达式, 比如 arg.Any()、arg.In(1, 2)
+func (m *AnchorMocker) When(args ...interface{}) *AnchorMocker {
+	m.lock.Lock()
+	m.imp = nil
+	m.conds = append(m.conds, &anchorCond{args: args})
+	m.lock.Unlock()
+	m.enable()
+	return m
+}
+
+// Return 指定锚点返回的错误, 没有指定 When 时匹配任意参数
+func (m *AnchorMocker) Return(err error) *AnchorMocker {
+	m.lock.Lock()
+	m.currentCond().err = err
+	m.lock.Unlock()
+	m.enable()
+	return m
+}
+
+// Delay 指定锚点返回之前的延迟, 没有指定 When 时匹配任意参数
+func (m *AnchorMocker) Delay(d time.Duration) *AnchorMocker {
+	m.lock.Lock()
+	m.currentCond().delay = d
+	m.lock.Unlock()
+	m.enable()
+	return m
+}
+
+// Cancel 取消锚点的 mock
+func (m *AnchorMocker) Cancel() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	// 锚点可能已经被其它 Mocker 覆盖, 只删除当前 Mocker 设置的回调
+	anchor.RemoveOwned(m.name, m)
+	if !m.canceled {
+		logCancel(m)
+	}
+	m.imp, m.conds, m.canceled = nil, nil, true
+}
+
+// Canceled 是否已经被取消
+func (m *AnchorMocker) Canceled() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.canceled
+}
+
+// currentCond 获取最后指定的条件, 没有条件时创建匹配任意参数的条件, 调用方需持有 m.lock
+func (m *AnchorMocker) currentCond() *anchorCond {
+	m.imp = nil
+	if len(m.conds) == 0 {
+		m.conds = append(m.conds, &anchorCond{})
+	}
+	return m.conds[len(m.conds)-1]
+}
+
+// enable 将锚点的回调设置为当前 Mocker
+func (m *AnchorMocker) enable() {
+	m.lock.Lock()
+	m.canceled = false
+	m.lock.Unlock()
+	anchor.SetOwned(m.name, m, m.call)
+}
+
+// call 锚点的回调
+func (m *AnchorMocker) call(args ...interface{}) error {
+	m.lock.Lock()
+	if imp := m.imp; imp != nil {
+		m.lock.Unlock()
+		return imp(args...)
+	}
+	// 表达式在匹配时按照参数的实际类型解析, 需要持有锁; 条件的延迟和错误可能被并发修改, 释放锁之前复制
+	var (
+		delay time.Duration
+		err   error
+	)
+	for _, c := range m.conds {
+		if c.match(args) {
+			delay, err = c.delay, c.err
+			break
+		}
+	}
+	m.lock.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	return err
+}
+
+// match 参数是否满足条件
+func (c *anchorCond) match(args []interface{}) bool {
+	if c.args == nil {
+		return true
+	}
+	if len(c.args) != len(args) {
+		return false
+	}
+	types := make([]reflect.Type, len(args))
+	values := make([]reflect.Value, len(args))
+	for i, a := range args {
+		if a == nil {
+			types[i] = reflect.TypeOf((*interface{})(nil)).Elem()
+			values[i] = reflect.Zero(types[i])
+			continue
+		}
+		types[i], values[i] = reflect.TypeOf(a), reflect.ValueOf(a)
+		// 类型不同的结构体和指针不相等, 避免按照内存布局强制转换
+		if _, ok := c.args[i].(arg.Expr); !ok && c.args[i] != nil && reflect.TypeOf(c.args[i]) != types[i] &&
+			(types[i].Kind() == reflect.Struct || types[i].Kind() == reflect.Ptr) {
+			return false
+		}
+	}
+	exprs, err := arg.ToExpr(c.args, types)
+	if err != nil {
+		return false
+	}
+	for i, expr := range exprs {
+		if ok, err := expr.Eval([]reflect.Value{values[i]}); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+// Package anchor 实现了 mock 锚点: 在业务代码中声明具名的锚点, 测试时通过 mocker.Builder.Anchor 指定锚点的行为,
+// 比如注入异常、延迟, 不依赖对二进制代码的修改, 也无需使用 -gcflags=all=-l 关闭内联。
+// 锚点没有被 mock 时, Point 只有一次原子读取的开销。
+package anchor
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Hook 锚点的回调, args 为 Point 的参数, 返回的 error 作为 Point 的返回值
+type Hook func(args ...interface{}) error
+
+var (
+	// enabled 设置了回调的锚点数量, 为 0 时 Point 直接返回
+	enabled int32
+	// lock 保护 hooks
+	lock  sync.RWMutex
+	hooks = make(map[string]*ownedHook)
+)
+
+// ownedHook 锚点的回调和设置回调的所有者
+type ownedHook struct {
+	hook  Hook
+	owner interface{}
+}
+
+// Point 声明锚点, name 为锚点名称, 比如 order.beforeCommit, args 为传递给回调的参数
+// 锚点被 mock 时执行回调并返回回调的结果, 否则返回 nil
+// 注意: args 会被装箱为 interface{}, 调用频繁的代码可以先通过 Enabled 判断
+func Point(name string, args ...interface{}) error {
+	if atomic.LoadInt32(&enabled) == 0 {
+		return nil
+	}
+	lock.RLock()
+	h := hooks[name]
+	lock.RUnlock()
+	if h == nil {
+		return nil
+	}
+	return h.hook(args...)
+}
+
+// Enabled 是否有锚点被 mock
+func Enabled() bool {
+	return atomic.LoadInt32(&enabled) > 0
+}
+
+// Set 设置锚点的回调, 覆盖之前设置的回调
+func Set(name string, hook Hook) {
+	SetOwned(name, nil, hook)
+}
+
+// SetOwned 设置锚点的回调并记录所有者, 覆盖之前设置的回调; 所有者需要是可比较的值, 比如指针
+// 通过 RemoveOwned 删除时, 只有回调仍属于该所有者才会被删除
+func SetOwned(name string, owner interface{}, hook Hook) {
+	if hook == nil {
+		Remove(name)
+		return
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	hooks[name] = &ownedHook{hook: hook, owner: owner}
+	atomic.StoreInt32(&enabled, int32(len(hooks)))
+}
+
+// Remove 删除锚点的回调
+func Remove(name string) {
+	lock.Lock()
+	defer lock.Unlock()
+	delete(hooks, name)
+	atomic.StoreInt32(&enabled, int32(len(hooks)))
+}
+
+// RemoveOwned 删除 owner 设置的锚点的回调, 回调已经被其它所有者覆盖时不删除, 返回是否删除
+func RemoveOwned(name string, owner interface{}) bool {
+	lock.Lock()
+	defer lock.Unlock()
+	if h, ok := hooks[name]; !ok || h.owner != owner {
+		return false
+	}
+	delete(hooks, name)
+	atomic.StoreInt32(&enabled, int32(len(hooks)))
+	return true
+}
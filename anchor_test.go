@@ -0,0 +1,121 @@
+// Package mocker_test 对 mocker 包的测试
+// 当前文件实现了对 anchor.go 的单测
+package mocker_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	mocker "github.com/tencent/goom"
+	"github.com/tencent/goom/anchor"
+	"github.com/tencent/goom/arg"
+	"github.com/tencent/goom/test"
+)
+
+// TestUnitAnchorTestSuite 测试入口
+func TestUnitAnchorTestSuite(t *testing.T) {
+	suite.Run(t, new(anchorTestSuite))
+}
+
+// anchorTestSuite 锚点测试套件
+type anchorTestSuite struct {
+	suite.Suite
+}
+
+// TestNotEnabled 测试锚点没有被 mock 时不生效
+func (s *anchorTestSuite) TestNotEnabled() {
+	s.False(anchor.Enabled())
+	s.NoError(anchor.Point("order.beforeCommit", 1, "a"))
+	s.NoError(test.Commit(1, "a"))
+}
+
+// TestReturn 测试指定锚点返回的错误
+func (s *anchorTestSuite) TestReturn() {
+	mock := mocker.Create()
+	mock.Anchor("order.beforeCommit").
+		When(arg.In(1, 2), "a").Return(errors.New("locked")).
+		When(3, arg.Any()).Return(errors.New("timeout"))
+	s.True(anchor.Enabled())
+
+	s.EqualError(test.Commit(1, "a"), "commit order 1: locked")
+	s.EqualError(test.Commit(2, "a"), "commit order 2: locked")
+	s.EqualError(test.Commit(3, "b"), "commit order 3: timeout")
+	// 不满足条件时返回 nil
+	s.NoError(test.Commit(1, "b"))
+	s.NoError(test.Commit(4, "a"))
+
+	mock.Reset()
+	s.False(anchor.Enabled())
+	s.NoError(test.Commit(1, "a"))
+}
+
+// TestDelay 测试指定锚点的延迟
+func (s *anchorTestSuite) TestDelay() {
+	mock := mocker.Create()
+	defer mock.Reset()
+	mock.Anchor("order.beforeCommit").Delay(10 * time.Millisecond)
+
+	start := time.Now()
+	s.NoError(test.Commit(1, "a"))
+	s.True(time.Since(start) >= 10*time.Millisecond, "delay must be applied")
+}
+
+// TestConcurrentReturn 测试锚点被调用时并发修改返回的错误, 需要使用 -race 运行
+func (s *anchorTestSuite) TestConcurrentReturn() {
+	mock := mocker.Create()
+	defer mock.Reset()
+	am := mock.Anchor("order.beforeCommit").When(1, "a").Return(errors.New("locked"))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_ = test.Commit(1, "a")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		am.Return(errors.New("locked"))
+	}
+	wg.Wait()
+	s.EqualError(test.Commit(1, "a"), "commit order 1: locked")
+}
+
+// TestApply 测试指定锚点的回调函数
+func (s *anchorTestSuite) TestApply() {
+	mock := mocker.Create()
+	defer mock.Reset()
+	var calls [][]interface{}
+	mock.Anchor("order.beforeCommit").Apply(func(args ...interface{}) error {
+		calls = append(calls, args)
+		if args[0].(int) > 1 {
+			return errors.New("rejected")
+		}
+		return nil
+	})
+
+	s.NoError(test.Commit(1, "a"))
+	s.EqualError(test.Commit(2, "b"), "commit order 2: rejected")
+	s.Equal([][]interface{}{{1, "a"}, {2, "b"}}, calls)
+
+	s.Panics(func() {
+		mock.Anchor("order.beforeCommit").Apply(func(int) error { return nil })
+	})
+}
+
+// TestCancelOverridden 测试取消已经被其它 Builder 覆盖的锚点时不删除覆盖的回调
+func (s *anchorTestSuite) TestCancelOverridden() {
+	first, second := mocker.Create(), mocker.Create()
+	defer second.Reset()
+	first.Anchor("order.beforeCommit").Return(errors.New("first"))
+	second.Anchor("order.beforeCommit").Return(errors.New("second"))
+
+	first.Reset()
+	s.EqualError(test.Commit(1, "a"), "commit order 1: second")
+	second.Reset()
+	s.NoError(test.Commit(1, "a"))
+}
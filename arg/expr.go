@@ -3,6 +3,7 @@ package arg
 import (
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 // Expr 表达式接口, 实现了 equals、any、in、field(x)等表达式匹配
@@ -31,6 +32,11 @@ func (a *AnyExpr) Eval(_ []reflect.Value) (bool, error) {
 	return true, nil
 }
 
+// String 表达式的描述
+func (a *AnyExpr) String() string {
+	return "arg.Any()"
+}
+
 // EqualsExpr 表达式实现了两个参数是否相等的规则计算
 type EqualsExpr struct {
 	param  interface{}
@@ -59,6 +65,11 @@ func (e *EqualsExpr) Eval(input []reflect.Value) (bool, error) {
 	return false, nil
 }
 
+// String 表达式的描述, 即比较的值
+func (e *EqualsExpr) String() string {
+	return Sprint(e.param)
+}
+
 // InExpr 包含表达式执行
 type InExpr struct {
 	params      []interface{}
@@ -105,3 +116,33 @@ outer:
 	}
 	return false, nil
 }
+
+// String 表达式的描述
+func (i *InExpr) String() string {
+	s := make([]string, len(i.params))
+	for j, p := range i.params {
+		s[j] = Sprint(p)
+	}
+	return "arg.In(" + strings.Join(s, ", ") + ")"
+}
+
+// Sprint 获取参数条件的描述: 表达式使用表达式的描述, 字符串带引号, 多个参数的条件使用 [] 表示
+func Sprint(param interface{}) string {
+	switch p := param.(type) {
+	case nil:
+		return "nil"
+	case fmt.Stringer:
+		if _, ok := p.(Expr); ok {
+			return p.String()
+		}
+	case string:
+		return fmt.Sprintf("%q", p)
+	case []interface{}:
+		s := make([]string, len(p))
+		for i, v := range p {
+			s[i] = Sprint(v)
+		}
+		return "[" + strings.Join(s, ", ") + "]"
+	}
+	return fmt.Sprintf("%v", param)
+}
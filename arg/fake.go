@@ -0,0 +1,68 @@
+package arg
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// CheckFake 检查 fake 结构体和原结构体的内存布局是否一致, 不一致时 panic
+// 供 goom gen-fake 生成的代码在 init 时调用, 大小的检查规则和 mock 回调参数强制转换时一致
+// fake fake 结构体的值
+// origin 原结构体名称, 比如: github.com/xxx/pkg.struct2
+// size、align、offsets 生成代码时原结构体的大小、对齐和各个字段的偏移
+func CheckFake(fake interface{}, origin string, size, align uintptr, offsets ...uintptr) {
+	typ := reflect.TypeOf(fake)
+	if err := checkFake(typ, origin, size, align, offsets); err != nil {
+		panic(fmt.Sprintf("fake %s does not match the layout of %s: %v, "+
+			"please regenerate it with: goom gen-fake %s", typ, origin, err, origin))
+	}
+}
+
+// CheckFakeOf 检查 fake 结构体和原结构体的内存布局是否一致, 不一致时 panic
+// 供 goom gen-fake 生成的代码在 init 时调用, 原结构体可以在其它包中引用时, 直接和原结构体的实际内存布局比较
+// fake fake 结构体的值
+// origin 原结构体的值
+func CheckFakeOf(fake interface{}, origin interface{}) {
+	typ, originTyp := reflect.TypeOf(fake), reflect.TypeOf(origin)
+	if err := checkFakeOf(typ, originTyp); err != nil {
+		name := originTyp.PkgPath() + "." + originTyp.Name()
+		panic(fmt.Sprintf("fake %s does not match the layout of %s: %v, "+
+			"please regenerate it with: goom gen-fake %s", typ, name, err, name))
+	}
+}
+
+// checkFakeOf 检查 fake 结构体和原结构体的大小、对齐以及各个字段的偏移和大小
+func checkFakeOf(typ, originTyp reflect.Type) error {
+	offsets := make([]uintptr, originTyp.NumField())
+	for i := range offsets {
+		offsets[i] = originTyp.Field(i).Offset
+	}
+	if err := checkFake(typ, originTyp.String(), originTyp.Size(), uintptr(originTyp.Align()), offsets); err != nil {
+		return err
+	}
+	for i := range offsets {
+		if f, size := typ.Field(i), originTyp.Field(i).Type.Size(); f.Type.Size() != size {
+			return fmt.Errorf("field %s size mismatch,must: %d, actual: %d", f.Name, size, f.Type.Size())
+		}
+	}
+	return nil
+}
+
+// checkFake 检查 fake 结构体的内存布局
+func checkFake(typ reflect.Type, origin string, size, align uintptr, offsets []uintptr) error {
+	if err := checkCast(typ, origin, size); err != nil {
+		return fmt.Errorf("%v, size must: %d, actual: %d", err, size, typ.Size())
+	}
+	if uintptr(typ.Align()) != align {
+		return fmt.Errorf("align mismatch,must: %d, actual: %d", align, typ.Align())
+	}
+	if typ.Kind() != reflect.Struct || typ.NumField() != len(offsets) {
+		return fmt.Errorf("field count mismatch,must: %d", len(offsets))
+	}
+	for i, offset := range offsets {
+		if f := typ.Field(i); f.Offset != offset {
+			return fmt.Errorf("field %s offset mismatch,must: %d, actual: %d", f.Name, offset, f.Offset)
+		}
+	}
+	return nil
+}
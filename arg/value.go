@@ -27,8 +27,8 @@ func I2V(params []interface{}, types []reflect.Type) []reflect.Value {
 func toValue(r interface{}, out reflect.Type) reflect.Value {
 	v := reflect.ValueOf(r)
 	if r != nil && v.Type() != out && (out.Kind() == reflect.Struct || out.Kind() == reflect.Ptr) {
-		if v.Type().Size() != out.Size() {
-			panic(fmt.Sprintf("type mismatch,must: %s, actual: %v", v.Type(), out))
+		if err := checkCast(v.Type(), out.String(), out.Size()); err != nil {
+			panic(err.Error())
 		}
 		// 类型强制转换,适用于结构体 fake 场景
 		v = cast(v, out)
@@ -49,6 +49,14 @@ func toValue(r interface{}, out reflect.Type) reflect.Value {
 	return v
 }
 
+// checkCast 检查类型 from 是否可以强制转换为大小为 size 的类型 to
+func checkCast(from reflect.Type, to string, size uintptr) error {
+	if from.Size() != size {
+		return fmt.Errorf("type mismatch,must: %s, actual: %v", to, from)
+	}
+	return nil
+}
+
 // cast 将reflect.Value类型强制转换为执行type类型的reflect.Value
 func cast(v reflect.Value, typ reflect.Type) reflect.Value {
 	originV := (*hack.Value)(unsafe.Pointer(&v))
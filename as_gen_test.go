@@ -0,0 +1,44 @@
+// Code generated by goom gen-as github.com/tencent/goom/test.foo github.com/tencent/goom/test.(*Fake).call; DO NOT EDIT.
+
+package mocker_test
+
+import (
+	mocker "github.com/tencent/goom"
+	"github.com/tencent/goom/test"
+)
+
+// originFoo github.com/tencent/goom/test.foo 的原函数, Apply 之后可以通过它调用原函数
+var originFoo func(int) int
+
+// fooMocker github.com/tencent/goom/test.foo 的 mocker, 回调函数的签名和原函数一致
+type fooMocker struct {
+	mocker.ExportedMocker
+}
+
+// asFoo 将未导出的 github.com/tencent/goom/test.foo 转换为导出函数的 mocker
+func asFoo(b *mocker.Builder) *fooMocker {
+	return &fooMocker{b.ExportPkgFunc("github.com/tencent/goom/test", "foo").As((func(int) int)(nil))}
+}
+
+// Apply 指定 mock 执行的回调函数, 回调函数中可以通过 originFoo 调用原函数
+func (m *fooMocker) Apply(imp func(int) int) {
+	m.ExportedMocker.Origin(&originFoo).Apply(imp)
+}
+
+// originFakeCall github.com/tencent/goom/test.(*Fake).call 的原函数, Apply 之后可以通过它调用原函数
+var originFakeCall func(*test.Fake, int) int
+
+// fakeCallMocker github.com/tencent/goom/test.(*Fake).call 的 mocker, 回调函数的签名和原函数一致
+type fakeCallMocker struct {
+	mocker.ExportedMocker
+}
+
+// asFakeCall 将未导出的 github.com/tencent/goom/test.(*Fake).call 转换为导出函数的 mocker
+func asFakeCall(b *mocker.Builder) *fakeCallMocker {
+	return &fakeCallMocker{b.ExportPkgFunc("github.com/tencent/goom/test", "(*Fake).call").As((func(*test.Fake, int) int)(nil))}
+}
+
+// Apply 指定 mock 执行的回调函数, 回调函数中可以通过 originFakeCall 调用原函数
+func (m *fakeCallMocker) Apply(imp func(*test.Fake, int) int) {
+	m.ExportedMocker.Origin(&originFakeCall).Apply(imp)
+}
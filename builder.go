@@ -8,13 +8,17 @@ import (
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/tencent/goom/internal/iface"
 	"github.com/tencent/goom/internal/logger"
 )
 
 // Builder Mock 构建器, 负责创建一个链式构造器.
+// Builder 是线程安全的, 可以在多个协程中并发地创建 mock 或 Reset
 type Builder struct {
+	// lock 保护 pkgName 和 mockers
+	lock    sync.Mutex
 	pkgName string
 	mockers map[interface{}]Mocker
 }
@@ -24,6 +28,8 @@ type Builder struct {
 // 后续仅支持同包下的未导出方法的 mock
 // Deprecated: 对于跨包目录的私有函数的 mock 通常都是因为代码设计可能有问题
 func (b *Builder) Pkg(name string) *Builder {
+	b.lock.Lock()
+	defer b.lock.Unlock()
 	b.pkgName = name
 	return b
 }
@@ -31,11 +37,13 @@ func (b *Builder) Pkg(name string) *Builder {
 // PkgName 返回包名
 // Deprecated: 对于跨包目录的私有函数的 mock 通常都是因为代码设计可能有问题
 func (b *Builder) PkgName() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
 	return b.pkgName
 }
 
 // Create 创建 Mock 构建器
-// 非线程安全的,不能在多协程中并发地 mock 或 reset 同一个函数
+// 线程安全的, 可以在其它协程调用被 mock 函数的同时 mock 或 reset 同一个函数
 func Create() *Builder {
 	// callerDeps 当前的调用栈栈层次
 	const callerDeps = 2
@@ -48,6 +56,9 @@ func Create() *Builder {
 // Interface 指定接口类型的变量定义
 // iFace 必须是指针类型, 比如 i 为 interface 类型变量, iFace 传递&i
 func (b *Builder) Interface(iFace interface{}) *CachedInterfaceMocker {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
 	mKey := reflect.TypeOf(iFace).String()
 	if mocker, ok := b.mockers[mKey]; ok && !mocker.Canceled() {
 		b.reset2CurPkg()
@@ -63,7 +74,7 @@ func (b *Builder) Interface(iFace interface{}) *CachedInterfaceMocker {
 	return cachedMocker
 }
 
-// cache 添加到缓存
+// cache 添加到缓存, 调用方需持有 b.lock
 func (b *Builder) cache(mKey interface{}, cachedMocker Mocker) {
 	b.mockers[mKey] = cachedMocker
 }
@@ -71,6 +82,9 @@ func (b *Builder) cache(mKey interface{}, cachedMocker Mocker) {
 // Struct 指定结构体名称
 // 比如需要 mock 结构体函数 (*conn).Write(b []byte)，则 name="conn"
 func (b *Builder) Struct(obj interface{}) *CachedMethodMocker {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
 	mKey := reflect.ValueOf(obj).Type().String()
 	if mocker, ok := b.mockers[mKey]; ok && !mocker.Canceled() {
 		b.reset2CurPkg()
@@ -88,6 +102,9 @@ func (b *Builder) Struct(obj interface{}) *CachedMethodMocker {
 // funcDef 函数，比如 foo
 // 方法的 mock, 比如 &Struct{}.method
 func (b *Builder) Func(obj interface{}) *DefMocker {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
 	var key = runtime.FuncForPC(reflect.ValueOf(obj).Pointer()).Name()
 	if mocker, ok := b.mockers[key]; ok && !mocker.Canceled() {
 		b.reset2CurPkg()
@@ -100,9 +117,30 @@ func (b *Builder) Func(obj interface{}) *DefMocker {
 	return mocker
 }
 
+// Closure 指定闭包函数(函数字面量)
+// closure 闭包函数的值, 比如函数 Foo 中定义并返回的 func literal(pkg.Foo.func1)
+// mock 作用于闭包函数的代码, 因此同一个函数字面量创建的所有闭包对象都会被 mock
+func (b *Builder) Closure(closure interface{}) *ClosureMocker {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	mocker := NewClosureMocker(b.pkgName, closure)
+	key := "closure_" + mocker.String()
+	if cached, ok := b.mockers[key]; ok && !cached.Canceled() {
+		b.reset2CurPkg()
+		return cached.(*ClosureMocker)
+	}
+	b.cache(key, mocker)
+	b.reset2CurPkg()
+	return mocker
+}
+
 // ExportStruct 导出私有结构体
 // 比如需要 mock 结构体函数 (*conn).Write(b []byte)，则 name="conn"
 func (b *Builder) ExportStruct(name string) *CachedUnexportedMethodMocker {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
 	if mocker, ok := b.mockers[b.pkgName+"_"+name]; ok && !mocker.Canceled() {
 		b.reset2CurPkg()
 		return mocker.(*CachedUnexportedMethodMocker)
@@ -129,19 +167,42 @@ func (b *Builder) ExportFunc(name string) *UnexportedFuncMocker {
 		panic("func name is empty")
 	}
 
-	if mocker, ok := b.mockers[b.pkgName+"_"+name]; ok && !mocker.Canceled() {
-		b.reset2CurPkg()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	mocker := b.exportFunc(b.pkgName, name)
+	b.reset2CurPkg()
+	return mocker
+}
+
+// ExportPkgFunc 导出指定包中的私有函数, 和 Pkg(pkgPath).ExportFunc(name) 相同, 但不修改 Builder 当前的包名
+// 比如: ExportPkgFunc("github.com/xxx/pkg", "(*struct_name).method_name")
+func (b *Builder) ExportPkgFunc(pkgPath, name string) *UnexportedFuncMocker {
+	if name == "" {
+		panic("func name is empty")
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.exportFunc(pkgPath, name)
+}
+
+// exportFunc 获取或者创建包 pkgName 中私有函数的 mocker, 调用方需持有 lock
+func (b *Builder) exportFunc(pkgName, name string) *UnexportedFuncMocker {
+	if mocker, ok := b.mockers[pkgName+"_"+name]; ok && !mocker.Canceled() {
 		return mocker.(*UnexportedFuncMocker)
 	}
 
-	mocker := NewUnexportedFuncMocker(b.pkgName, name)
-	b.cache(b.pkgName+"_"+name, mocker)
-	b.reset2CurPkg()
+	mocker := NewUnexportedFuncMocker(pkgName, name)
+	b.cache(pkgName+"_"+name, mocker)
 	return mocker
 }
 
 // Var 变量 mock, target 类型必须传递指针类型
 func (b *Builder) Var(target interface{}) VarMock {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
 	cacheKey := fmt.Sprintf("var_%d", reflect.ValueOf(target).Pointer())
 	if mocker, ok := b.mockers[cacheKey]; ok && !mocker.Canceled() {
 		return mocker.(VarMock)
@@ -152,8 +213,34 @@ func (b *Builder) Var(target interface{}) VarMock {
 	return mocker
 }
 
+// Anchor 指定锚点名称, 对业务代码中通过 anchor.Point 声明的锚点进行 mock
+// 锚点的 mock 不修改二进制代码, 因此不受内联的影响
+func (b *Builder) Anchor(name string) *AnchorMocker {
+	if name == "" {
+		panic("anchor name is empty")
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	key := "anchor_" + name
+	if mocker, ok := b.mockers[key]; ok && !mocker.Canceled() {
+		return mocker.(*AnchorMocker)
+	}
+
+	mocker := NewAnchorMocker(name)
+	b.cache(key, mocker)
+	return mocker
+}
+
 // Reset 取消当前 builder 的所有 Mock
+// 取消之前会将覆盖率报告(从未匹配过的条件、从未使用过的返回值、没有匹配任何条件的调用)输出到日志, 参考 Report
 func (b *Builder) Reset() *Builder {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	emitReport(b.report())
+
 	for _, mocker := range b.mockers {
 		mocker.Cancel()
 		// callerDeps 当前的调用栈栈层次
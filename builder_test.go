@@ -1,6 +1,8 @@
 package mocker
 
 import (
+	"fmt"
+	"reflect"
 	"runtime/debug"
 	"testing"
 
@@ -35,6 +37,28 @@ func (s *builderTestSuite) TestBuilder_reset2CurPkg() {
 	})
 }
 
+func (s *builderTestSuite) TestBuilder_ExportPkgFunc() {
+	s.Run("success", func() {
+		b := Create()
+		m := b.ExportPkgFunc("github.com/tencent/goom/test", "foo")
+		s.Equal("github.com/tencent/goom/test.foo", m.String())
+		s.Equal("github.com/tencent/goom", b.pkgName)
+		s.Equal(m, b.ExportPkgFunc("github.com/tencent/goom/test", "foo"))
+	})
+}
+
+func (s *builderTestSuite) Test_callbackNoMatch() {
+	m := Create().ExportPkgFunc("github.com/tencent/goom/test", "foo")
+	var msg string
+	func() {
+		defer func() { msg = fmt.Sprint(recover()) }()
+		m.callback([]reflect.Value{reflect.ValueOf(1), reflect.ValueOf("a")})
+	}()
+	s.Equal(`mocker [github.com/tencent/goom/test.foo]: there is no suitable condition matched, `+
+		`or set default return with: mocker.Return(...)
+  actual args: (1,a)`, msg)
+}
+
 func (s *builderTestSuite) Test_currentPackage() {
 	tests := []struct {
 		name string
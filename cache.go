@@ -6,6 +6,7 @@ package mocker
 
 import (
 	"strings"
+	"sync"
 
 	"github.com/tencent/goom/internal/iface"
 )
@@ -13,6 +14,8 @@ import (
 // CachedMethodMocker 带缓存的方法 Mocker,将同一个函数或方法的 Mocker 进行 cache
 type CachedMethodMocker struct {
 	*MethodMocker
+	// lock 保护 mCache 和 umCache
+	lock    sync.Mutex
 	mCache  map[string]*MethodMocker
 	umCache map[string]UnExportedMocker
 }
@@ -28,6 +31,9 @@ func NewCachedMethodMocker(m *MethodMocker) *CachedMethodMocker {
 
 // String mock 的名称
 func (m *CachedMethodMocker) String() string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	s := make([]string, 0, len(m.mCache)+len(m.umCache))
 	for _, v := range m.mCache {
 		s = append(s, v.String())
@@ -39,18 +45,38 @@ func (m *CachedMethodMocker) String() string {
 }
 
 // Method 设置结构体的方法名
-func (m *CachedMethodMocker) Method(name string) ExportedMocker {
+func (m *CachedMethodMocker) Method(name string) SpyMocker {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	if mocker, ok := m.mCache[name]; ok && !mocker.Canceled() {
 		return mocker
 	}
 	mocker := NewMethodMocker(m.pkgName, m.MethodMocker.structDef)
+	mocker.setUnchecked(m.isUnchecked())
 	mocker.Method(name)
 	m.mCache[name] = mocker
 	return mocker
 }
 
+// Unsafe 跳过结构体方法的回调函数和原方法的签名兼容性检查, 比如回调函数的接收体为内存布局一致的 fake 类型
+// 对已经指定和之后通过 Method 指定的方法都生效
+func (m *CachedMethodMocker) Unsafe() *CachedMethodMocker {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.setUnchecked(true)
+	for _, v := range m.mCache {
+		v.setUnchecked(true)
+	}
+	return m
+}
+
 // ExportMethod 导出私有方法
 func (m *CachedMethodMocker) ExportMethod(name string) UnExportedMocker {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	if mocker, ok := m.umCache[name]; ok && !mocker.Canceled() {
 		return mocker
 	}
@@ -60,8 +86,26 @@ func (m *CachedMethodMocker) ExportMethod(name string) UnExportedMocker {
 	return exportedMocker
 }
 
+// children 获取所有方法的 Mocker
+func (m *CachedMethodMocker) children() []Mocker {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	mockers := make([]Mocker, 0, len(m.mCache)+len(m.umCache))
+	for _, v := range m.mCache {
+		mockers = append(mockers, v)
+	}
+	for _, v := range m.umCache {
+		mockers = append(mockers, v)
+	}
+	return mockers
+}
+
 // Cancel 取消 mock
 func (m *CachedMethodMocker) Cancel() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	for _, v := range m.mCache {
 		v.Cancel()
 	}
@@ -73,6 +117,8 @@ func (m *CachedMethodMocker) Cancel() {
 // CachedUnexportedMethodMocker 带缓存的未导出方法 Mocker
 type CachedUnexportedMethodMocker struct {
 	*UnexportedMethodMocker
+	// lock 保护 mockers
+	lock    sync.Mutex
 	mockers map[string]*UnexportedMethodMocker
 }
 
@@ -86,6 +132,9 @@ func NewCachedUnexportedMethodMocker(m *UnexportedMethodMocker) *CachedUnexporte
 
 // String mock 的名称或描述
 func (m *CachedUnexportedMethodMocker) String() string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	s := make([]string, 0, len(m.mockers))
 	for _, v := range m.mockers {
 		s = append(s, v.String())
@@ -95,6 +144,9 @@ func (m *CachedUnexportedMethodMocker) String() string {
 
 // Method 设置结构体的方法名
 func (m *CachedUnexportedMethodMocker) Method(name string) UnExportedMocker {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	if mocker, ok := m.mockers[name]; ok && !mocker.Canceled() {
 		return mocker
 	}
@@ -104,8 +156,23 @@ func (m *CachedUnexportedMethodMocker) Method(name string) UnExportedMocker {
 	return mocker
 }
 
+// children 获取所有方法的 Mocker
+func (m *CachedUnexportedMethodMocker) children() []Mocker {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	mockers := make([]Mocker, 0, len(m.mockers))
+	for _, v := range m.mockers {
+		mockers = append(mockers, v)
+	}
+	return mockers
+}
+
 // Cancel 清除 mock
 func (m *CachedUnexportedMethodMocker) Cancel() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	for _, v := range m.mockers {
 		v.Cancel()
 	}
@@ -114,6 +181,8 @@ func (m *CachedUnexportedMethodMocker) Cancel() {
 // CachedInterfaceMocker 带缓存的 Interface Mocker
 type CachedInterfaceMocker struct {
 	*DefaultInterfaceMocker
+	// lock 保护 mockers
+	lock    sync.Mutex
 	mockers map[string]InterfaceMocker
 	ctx     *iface.IContext
 }
@@ -129,6 +198,9 @@ func NewCachedInterfaceMocker(interfaceMocker *DefaultInterfaceMocker) *CachedIn
 
 // String mock 的名称或描述
 func (m *CachedInterfaceMocker) String() string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	s := make([]string, 0, len(m.mockers))
 	for _, v := range m.mockers {
 		s = append(s, v.String())
@@ -138,6 +210,9 @@ func (m *CachedInterfaceMocker) String() string {
 
 // Method 指定方法名
 func (m *CachedInterfaceMocker) Method(name string) InterfaceMocker {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	if mocker, ok := m.mockers[name]; ok && !mocker.Canceled() {
 		return mocker
 	}
@@ -147,8 +222,23 @@ func (m *CachedInterfaceMocker) Method(name string) InterfaceMocker {
 	return mocker
 }
 
+// children 获取所有方法的 Mocker
+func (m *CachedInterfaceMocker) children() []Mocker {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	mockers := make([]Mocker, 0, len(m.mockers))
+	for _, v := range m.mockers {
+		mockers = append(mockers, v)
+	}
+	return mockers
+}
+
 // Cancel 取消 mock
 func (m *CachedInterfaceMocker) Cancel() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	for _, v := range m.mockers {
 		v.Cancel()
 	}
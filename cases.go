@@ -0,0 +1,210 @@
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件实现了数据驱动测试: 从 YAML 或 JSON 文件中加载测试用例, 每个用例声明 mock 和被测函数的参数、期望的返回值,
+// 每个用例作为一个子测试运行, 运行结束之后自动 Reset。
+package mocker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tencent/goom/arg"
+)
+
+// Cases 从文件中加载的测试用例
+type Cases struct {
+	path  string
+	cases []*Case
+	// targets 可以被 mock 的函数, key 为函数的完整名称
+	targets map[string]interface{}
+}
+
+// Case 测试用例
+type Case struct {
+	// Name 用例名称, 作为子测试的名称
+	Name string `json:"name"`
+	// Mocks 用例中声明的 mock
+	Mocks []*CaseMock `json:"mocks"`
+	// Args 被测函数的参数
+	Args []interface{} `json:"args"`
+	// Want 被测函数期望的返回值, 不包括最后一个 error 类型的返回值, 为空时不检查
+	Want []interface{} `json:"want"`
+	// WantErr 被测函数期望的错误信息, 最后一个返回值为 error 类型时使用, 为空时期望没有错误
+	WantErr string `json:"wantErr"`
+}
+
+// CaseMock 用例中声明的 mock, 同一个函数的多个 mock 按声明的顺序匹配参数条件
+type CaseMock struct {
+	// Func 被 mock 的函数名称, 比如 test.Foo 或者 github.com/tencent/goom/test.Foo, 需要通过 Cases.Target 注册
+	Func string `json:"func"`
+	// When 参数条件, 为空时匹配任意参数, 支持参数表达式 {any: true}、{in: [1, 2]}、{eq: 值}
+	When []interface{} `json:"when"`
+	// Return 返回值, 不包括最后一个 error 类型的返回值
+	Return []interface{} `json:"return"`
+	// Error 最后一个 error 类型的返回值的错误信息
+	Error string `json:"error"`
+	// Delay 返回之前的延迟, 格式同 time.ParseDuration, 比如 10ms
+	Delay string `json:"delay"`
+}
+
+// LoadCases 从 YAML(.yaml、.yml) 或 JSON 文件中加载测试用例, 文件的内容为用例的列表
+// 用例中 mock 的函数需要在 Run 之前通过 Cases.Target 注册
+// 参数和返回值按照函数的真实类型转换, 结构体等复合类型按照 JSON 的规则转换, error 类型使用错误信息表示
+func LoadCases(path string) (*Cases, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cases []*Case
+	if err := decodeFile(path, data, &cases); err != nil {
+		return nil, fmt.Errorf("load cases from %s error: %w", path, err)
+	}
+	for i, c := range cases {
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("case%d", i)
+		}
+	}
+	return &Cases{path: path, cases: cases, targets: make(map[string]interface{})}, nil
+}
+
+// Cases 获取加载的测试用例
+func (c *Cases) Cases() []*Case {
+	return c.cases
+}
+
+// Target 注册可以被用例 mock 的函数, 用例中通过函数的名称引用
+// 方法可以使用方法表达式注册, 比如 (*Struct).Method
+// 注意: 用例文件中没有声明函数的签名, 参数和返回值需要按照注册的函数的类型转换, 所以用例中 mock 的函数都必须先注册,
+// 未注册的函数在运行用例时报错; 需要按名称 mock 未注册的(包括未导出的)函数时, 请使用 LoadConfig 并声明函数的签名
+func (c *Cases) Target(funcs ...interface{}) *Cases {
+	for _, f := range funcs {
+		if reflect.TypeOf(f).Kind() != reflect.Func {
+			panic(fmt.Sprintf("target must be a func, actual: %T", f))
+		}
+		c.targets[functionName(f)] = f
+	}
+	return c
+}
+
+// Run 运行所有的测试用例, 每个用例作为 t 的一个子测试运行
+// fn 被测函数, 用例中的参数和期望的返回值按照 fn 的签名转换
+func (c *Cases) Run(t *testing.T, fn interface{}) {
+	fnTyp := reflect.TypeOf(fn)
+	if fnTyp.Kind() != reflect.Func {
+		panic(fmt.Sprintf("fn must be a func, actual: %T", fn))
+	}
+	for _, tc := range c.cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			mock := Create()
+			defer mock.Reset()
+
+			if err := c.apply(mock, tc); err != nil {
+				t.Fatalf("%s: %v", c.path, err)
+			}
+			if err := check(reflect.ValueOf(fn), tc); err != nil {
+				t.Errorf("%s: %v", c.path, err)
+			}
+		})
+	}
+}
+
+// apply 应用用例中声明的 mock
+func (c *Cases) apply(mock *Builder, tc *Case) error {
+	var (
+		names []string
+		rules = make(map[string][]*rule)
+	)
+	for i, m := range tc.Mocks {
+		name, target, err := c.lookup(m.Func)
+		if err != nil {
+			return fmt.Errorf("mock %d: %w", i, err)
+		}
+		r, err := newRule(reflect.TypeOf(target), m.When, m.Return, m.Error, m.Delay)
+		if err != nil {
+			return fmt.Errorf("mock %d of %s: %w", i, m.Func, err)
+		}
+		if _, ok := rules[name]; !ok {
+			names = append(names, name)
+		}
+		rules[name] = append(rules[name], r)
+	}
+	for _, name := range names {
+		m := mock.Func(c.targets[name])
+		if err := m.applyRules(reflect.TypeOf(c.targets[name]), rules[name]); err != nil {
+			return fmt.Errorf("mock %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// lookup 根据名称查找注册的函数, 名称可以省略包路径中最后一个元素之前的部分
+func (c *Cases) lookup(name string) (string, interface{}, error) {
+	if target, ok := c.targets[name]; ok {
+		return name, target, nil
+	}
+	var found []string
+	for full := range c.targets {
+		if strings.HasSuffix(full, "/"+name) {
+			found = append(found, full)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", nil, fmt.Errorf("func %s is not registered, please register it with Cases.Target", name)
+	case 1:
+		return found[0], c.targets[found[0]], nil
+	default:
+		return "", nil, fmt.Errorf("func %s is ambiguous: %s", name, strings.Join(found, ", "))
+	}
+}
+
+// check 调用被测函数, 检查返回值是否和期望一致
+func check(fn reflect.Value, tc *Case) error {
+	fnTyp := fn.Type()
+	values, err := convertValues(tc.Args, inTypes(false, fnTyp))
+	if err != nil {
+		return fmt.Errorf("args: %w", err)
+	}
+	args := arg.I2V(values, inTypes(false, fnTyp))
+	var results []reflect.Value
+	if fnTyp.IsVariadic() {
+		results = fn.CallSlice(args)
+	} else {
+		results = fn.Call(args)
+	}
+
+	out := outTypes(fnTyp)
+	if len(out) > 0 && out[len(out)-1] == errorType {
+		errV := results[len(results)-1]
+		results, out = results[:len(results)-1], out[:len(out)-1]
+		if actual := errorMessage(errV); actual != tc.WantErr {
+			return fmt.Errorf("error must: %q, actual: %q", tc.WantErr, actual)
+		}
+	}
+	if tc.Want == nil {
+		// 没有声明期望的返回值时不检查
+		return nil
+	}
+	want, err := convertValues(tc.Want, out)
+	if err != nil {
+		return fmt.Errorf("want: %w", err)
+	}
+	for i, w := range want {
+		if actual := results[i].Interface(); !reflect.DeepEqual(w, actual) {
+			return fmt.Errorf("result %d must: %v, actual: %v", i, w, actual)
+		}
+	}
+	return nil
+}
+
+// errorMessage 获取 error 的错误信息, 没有错误时返回空字符串
+func errorMessage(v reflect.Value) string {
+	if v.IsNil() {
+		return ""
+	}
+	return v.Interface().(error).Error()
+}
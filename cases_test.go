@@ -0,0 +1,73 @@
+// Package mocker_test 对 mocker 包的测试
+// 当前文件实现了对 cases.go 的单测
+package mocker_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	mocker "github.com/tencent/goom"
+	"github.com/tencent/goom/test"
+)
+
+// TestUnitCasesTestSuite 测试入口
+func TestUnitCasesTestSuite(t *testing.T) {
+	suite.Run(t, new(casesTestSuite))
+}
+
+// casesTestSuite 数据驱动测试套件
+type casesTestSuite struct {
+	suite.Suite
+}
+
+// TestRunYAML 测试运行 YAML 文件中的用例
+func (s *casesTestSuite) TestRunYAML() {
+	cases, err := mocker.LoadCases("testdata/ratio_cases.yaml")
+	s.Require().NoError(err)
+	s.Equal(6, len(cases.Cases()))
+	s.Equal("10ms", cases.Cases()[4].Mocks[0].Delay)
+
+	cases.Target(test.Divide, test.Join).Run(s.T(), test.Ratio)
+
+	// 用例运行结束之后自动 Reset
+	r, err := test.Ratio(6, 3)
+	s.NoError(err)
+	s.Equal("6/3=2", r)
+}
+
+// TestRunJSON 测试运行 JSON 文件中的用例
+func (s *casesTestSuite) TestRunJSON() {
+	cases, err := mocker.LoadCases("testdata/ratio_cases.json")
+	s.Require().NoError(err)
+	cases.Target(test.Divide).Run(s.T(), test.Ratio)
+}
+
+// TestLoadError 测试加载不合法的用例文件
+func (s *casesTestSuite) TestLoadError() {
+	_, err := mocker.LoadCases("testdata/not_exists.yaml")
+	s.Error(err)
+
+	dir, err := ioutil.TempDir("", "goom-cases")
+	s.Require().NoError(err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	path := filepath.Join(dir, "cases.yaml")
+	s.Require().NoError(ioutil.WriteFile(path, []byte("name: not a list"), 0644))
+	_, err = mocker.LoadCases(path)
+	s.Error(err)
+	s.Contains(err.Error(), path)
+}
+
+// TestTargetNotFunc 测试注册不是函数的 Target
+func (s *casesTestSuite) TestTargetNotFunc() {
+	cases, err := mocker.LoadCases("testdata/ratio_cases.json")
+	s.Require().NoError(err)
+	s.Panics(func() {
+		cases.Target(1)
+	})
+}
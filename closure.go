@@ -0,0 +1,250 @@
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件实现了闭包函数(函数字面量)的 Mocker。
+package mocker
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/tencent/goom/arg"
+	"github.com/tencent/goom/erro"
+	"github.com/tencent/goom/internal/hack"
+	"github.com/tencent/goom/internal/proxy"
+	"github.com/tencent/goom/internal/unexports"
+)
+
+// ClosureContext 闭包调用的上下文, 即调用方闭包对象
+// Closure 的 Apply 回调函数的第一个参数可以声明为 *ClosureContext, 用于区分调用方闭包对象,
+// 以及使用调用方闭包对象捕获的变量调用原闭包函数
+type ClosureContext struct {
+	ptr    uintptr
+	origin reflect.Value
+}
+
+// Ptr 调用方闭包对象的地址
+func (c *ClosureContext) Ptr() uintptr {
+	return c.ptr
+}
+
+// Is 是否是指定的闭包对象发起的调用
+func (c *ClosureContext) Is(closure interface{}) bool {
+	return closurePtr(closure) == c.ptr
+}
+
+// CallOrigin 使用调用方闭包对象的上下文(捕获的变量)调用原闭包函数
+// args 原函数的参数列表; 返回原函数的返回值列表
+func (c *ClosureContext) CallOrigin(args ...interface{}) []interface{} {
+	if !c.origin.IsValid() {
+		panic(erro.NewIllegalStatusError("CallOrigin", "origin func is not available, "+
+			"the trampoline of the mocked closure is not allocated"))
+	}
+	typ := c.origin.Type()
+	in := inTypes(false, typ)
+	results := c.origin.Call(append(arg.I2V(args, in[:len(in)-1]), reflect.ValueOf(c.ptr)))
+	return arg.V2I(results, outTypes(typ))
+}
+
+// closurePtr 获取闭包对象(funcval)的地址, 函数类型的值在 interface 中直接保存 funcval 的地址
+func closurePtr(closure interface{}) uintptr {
+	return uintptr(hack.UnpackEFace(closure).Data)
+}
+
+// ClosureMocker 对闭包函数(函数字面量, 比如 pkg.Foo.func1)进行 mock
+// mock 作用于闭包函数的代码, 因此同一个函数字面量创建的所有闭包对象都会被 mock
+type ClosureMocker struct {
+	*baseMocker
+	closure interface{}
+	name    string
+	// ctxOrigin 可调用的原函数, 参数为闭包函数的参数追加 uintptr 类型的闭包上下文
+	ctxOrigin reflect.Value
+}
+
+// NewClosureMocker 创建 ClosureMocker
+// pkgName 包路径
+// closure 闭包函数的值
+func NewClosureMocker(pkgName string, closure interface{}) *ClosureMocker {
+	if closure == nil || reflect.TypeOf(closure).Kind() != reflect.Func || reflect.ValueOf(closure).IsNil() {
+		panic(erro.NewIllegalParamError("closure", fmt.Sprintf("%v", closure)))
+	}
+	_, name, err := unexports.FindFuncByPtr(reflect.ValueOf(closure).Pointer())
+	if err != nil {
+		panic(erro.NewIllegalParamCError("closure", fmt.Sprintf("%v", closure), err))
+	}
+	m := &ClosureMocker{
+		baseMocker: newBaseMocker(pkgName),
+		closure:    closure,
+		name:       name,
+	}
+	m.self = m
+	return m
+}
+
+// String mock 的名称或描述
+func (m *ClosureMocker) String() string {
+	return m.name
+}
+
+// Apply 代理闭包函数实现
+// imp 的签名可以和闭包函数一致, 也可以在第一个参数声明 *ClosureContext 用于访问调用方闭包对象,
+// 比如: func(ctx *mocker.ClosureContext, arg1 type1) type2
+func (m *ClosureMocker) Apply(imp interface{}) {
+	m.doApply(imp)
+}
+
+func (m *ClosureMocker) doApply(imp interface{}) {
+	typ := reflect.TypeOf(m.closure)
+	withCtx := m.checkImp(reflect.TypeOf(imp))
+	imp, _ = interceptDebugInfo(imp, nil, m)
+
+	impV := reflect.ValueOf(imp)
+	guard, origin, err := proxy.Closure(reflect.ValueOf(m.closure).Pointer(), typ,
+		func(ctx uintptr, args []reflect.Value) []reflect.Value {
+			if m.Canceled() && m.currentOrigin().IsValid() {
+				// 取消之后仍在执行中的调用转发到原函数
+				return m.currentOrigin().Call(append(args, reflect.ValueOf(ctx)))
+			}
+			if withCtx {
+				args = append([]reflect.Value{reflect.ValueOf(&ClosureContext{ptr: ctx, origin: m.currentOrigin()})},
+					args...)
+			}
+			if impV.Type().IsVariadic() {
+				return impV.CallSlice(args)
+			}
+			return impV.Call(args)
+		})
+	if err != nil {
+		panic(fmt.Sprintf("proxy closure error: %v", err))
+	}
+
+	m.lock.Lock()
+	m.guard = newPatchMockGuard(guard)
+	m.imp = imp
+	m.ctxOrigin = origin
+	m.originFunc = m.instanceOrigin(typ, origin)
+	m.lock.Unlock()
+	guard.Apply()
+	logApply(m, 6)
+}
+
+// checkImp 检查代理函数的签名, 返回第一个参数是否为 *ClosureContext
+func (m *ClosureMocker) checkImp(impTyp reflect.Type) bool {
+	typ := reflect.TypeOf(m.closure)
+	if impTyp == typ {
+		return false
+	}
+	if impTyp == nil || impTyp.Kind() != reflect.Func || impTyp.NumIn() != typ.NumIn()+1 ||
+		impTyp.In(0) != reflect.TypeOf(&ClosureContext{}) || impTyp.IsVariadic() != typ.IsVariadic() {
+		panic(erro.NewIllegalParamTypeError("imp", fmt.Sprintf("%v", impTyp), typ.String()))
+	}
+	for i := 0; i < typ.NumIn(); i++ {
+		if impTyp.In(i+1) != typ.In(i) {
+			panic(erro.NewIllegalParamTypeError("imp", impTyp.String(), typ.String()))
+		}
+	}
+	for i := 0; i < typ.NumOut(); i++ {
+		if i >= impTyp.NumOut() || impTyp.Out(i) != typ.Out(i) {
+			panic(erro.NewIllegalParamTypeError("imp", impTyp.String(), typ.String()))
+		}
+	}
+	return true
+}
+
+// instanceOrigin 构造使用 Closure 指定的闭包对象的上下文调用的原函数, 没有跳板函数时返回零值
+func (m *ClosureMocker) instanceOrigin(typ reflect.Type, origin reflect.Value) reflect.Value {
+	if !origin.IsValid() {
+		return reflect.Value{}
+	}
+	ctx := reflect.ValueOf(closurePtr(m.closure))
+	return reflect.MakeFunc(typ, func(args []reflect.Value) []reflect.Value {
+		return origin.Call(append(args, ctx))
+	})
+}
+
+// currentOrigin 获取可调用的原函数
+func (m *ClosureMocker) currentOrigin() reflect.Value {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.ctxOrigin
+}
+
+// When 指定条件匹配
+func (m *ClosureMocker) When(args ...interface{}) *When {
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+	if when := m.currentWhen(); when != nil {
+		return when.When(args...)
+	}
+	when, err := CreateWhen(m, m.closure, args, nil, false)
+	if err != nil {
+		panic(err)
+	}
+	if err := m.whens(when); err != nil {
+		panic(err)
+	}
+	m.doApply(m.currentImp())
+	return when
+}
+
+// Return 代理方法返回
+func (m *ClosureMocker) Return(returns ...interface{}) *When {
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+	if when := m.currentWhen(); when != nil {
+		return when.Return(returns...)
+	}
+	when, err := CreateWhen(m, m.closure, nil, returns, false)
+	if err != nil {
+		panic(err)
+	}
+	if err := m.whens(when); err != nil {
+		panic(err)
+	}
+	m.doApply(m.currentImp())
+	return when
+}
+
+// Returns 依次按顺序返回值, 如果是多参可使用[]interface{}
+func (m *ClosureMocker) Returns(rets ...interface{}) *When {
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+	if when := m.currentWhen(); when != nil {
+		return when.Returns(rets...)
+	}
+	when, err := CreateWhen(m, m.closure, nil, nil, false)
+	if err != nil {
+		panic(err)
+	}
+	if err := m.whens(when); err != nil {
+		panic(err)
+	}
+	when.Returns(rets...)
+	m.doApply(m.currentImp())
+	return when
+}
+
+// Origin 闭包函数只支持自动分配的跳板函数, 请使用 CallOrigin 或 ClosureContext.CallOrigin 调用原函数
+func (m *ClosureMocker) Origin(_ interface{}) ExportedMocker {
+	panic(erro.NewIllegalStatusError("Origin", "closure only supports automatic trampoline, "+
+		"please use CallOrigin instead"))
+}
+
+// Spy 监视闭包函数的调用, 调用总是使用调用方闭包对象的上下文转发到原函数, 同时记录调用的参数和返回值
+func (m *ClosureMocker) Spy() *Spy {
+	typ := reflect.TypeOf(m.closure)
+	spy := m.getSpy()
+	in := append([]reflect.Type{reflect.TypeOf(&ClosureContext{})}, inTypes(false, typ)...)
+	imp := reflect.MakeFunc(reflect.FuncOf(in, outTypes(typ), typ.IsVariadic()),
+		func(args []reflect.Value) []reflect.Value {
+			ctx := args[0].Interface().(*ClosureContext)
+			results := ctx.origin.Call(append(args[1:], reflect.ValueOf(ctx.ptr)))
+			spy.record(typ, args[1:], results)
+			return results
+		})
+	m.doApply(imp.Interface())
+	if err := m.prepareOrigin(); err != nil {
+		m.Cancel()
+		panic(erro.NewIllegalStatusError("Spy", m.String()+": "+err.Error()))
+	}
+	return spy
+}
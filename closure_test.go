@@ -0,0 +1,105 @@
+package mocker_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/tencent/goom"
+	"github.com/tencent/goom/test"
+)
+
+// TestUnitClosureTestSuite 测试入口
+func TestUnitClosureTestSuite(t *testing.T) {
+	suite.Run(t, new(closureTestSuite))
+}
+
+// closureTestSuite 闭包函数 mock 测试
+type closureTestSuite struct {
+	suite.Suite
+}
+
+// TestClosureReturn 测试闭包函数的返回值 mock
+func (s *closureTestSuite) TestClosureReturn() {
+	s.Run("success", func() {
+		mock := mocker.Create()
+		add1, add2 := test.NewAdder(1), test.NewAdder(2)
+		mock.Closure(add1).Return(100)
+		s.Equal(100, add1(1), "closure mock check")
+		s.Equal(100, add2(1), "closure of same func literal mock check")
+
+		mock.Reset()
+		s.Equal(2, add1(1), "closure reset check")
+		s.Equal(3, add2(1), "closure reset check")
+	})
+}
+
+// TestClosureWhen 测试闭包函数的条件 mock
+func (s *closureTestSuite) TestClosureWhen() {
+	s.Run("success", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+
+		add := test.NewAdder(10)
+		mock.Closure(add).When(1).Return(100).When(2).CallOrigin()
+		s.Equal(100, add(1), "when check")
+		s.Equal(12, add(2), "call origin with captured variable check")
+	})
+}
+
+// TestClosureContext 测试在回调函数中访问调用方闭包对象的捕获变量
+func (s *closureTestSuite) TestClosureContext() {
+	s.Run("success", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+
+		add1, add2 := test.NewAdder(1), test.NewAdder(2)
+		mock.Closure(add1).Apply(func(ctx *mocker.ClosureContext, n int) int {
+			if ctx.Is(add1) {
+				return -1
+			}
+			return ctx.CallOrigin(n)[0].(int) * 10
+		})
+		s.Equal(-1, add1(1), "closure context check")
+		s.Equal(30, add2(1), "call origin with caller's captured variable check")
+		s.Equal(4, mock.Closure(add2).CallOrigin(3)[0], "mocker call origin check")
+	})
+}
+
+// TestClosureArgs 测试多种参数类型的闭包函数
+func (s *closureTestSuite) TestClosureArgs() {
+	s.Run("success", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+
+		format := test.NewFormatter("> ")
+		mock.Closure(format).Apply(func(ctx *mocker.ClosureContext,
+			name string, values []int, ratio float64, n int) (string, error) {
+			results := ctx.CallOrigin(name, values, ratio, n)
+			if results[1] != nil {
+				return "", results[1].(error)
+			}
+			return results[0].(string) + "!", nil
+		})
+		result, err := format("a", []int{1, 2}, 0.5, 3)
+		s.NoError(err, "call check")
+		s.Equal("> a[1,2]0.5*3!", result, "args check")
+		_, err = format("a", nil, 0, -1)
+		s.Error(err, "error result check")
+	})
+}
+
+// TestClosureSpy 测试监视闭包函数的调用
+func (s *closureTestSuite) TestClosureSpy() {
+	s.Run("success", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+
+		add1, add2 := test.NewAdder(1), test.NewAdder(2)
+		spy := mock.Closure(add1).Spy()
+		s.Equal(2, add1(1), "spy call origin check")
+		s.Equal(4, add2(2), "spy call origin with caller's context check")
+		s.Equal(2, spy.Times(), "spy times check")
+		s.Equal([]interface{}{2}, spy.LastCall().Args, "spy args check")
+	})
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/tencent/goom/internal/gen"
+)
+
+// genAsCommand 生成未导出函数(或方法)的类型安全的 As 辅助代码
+// 比如: //go:generate go run github.com/tencent/goom/cmd/goom gen-as -o as_gen.go github.com/xxx/pkg.foo
+var genAsCommand = &command{
+	usage: "generate typed As helpers of unexported funcs or methods from another package: " +
+		"gen-as [-o file] [-pkg name] [-name helperName] [-check] pkgpath.func|pkgpath.(*type).method...",
+	run: func(args []string) error {
+		fs, out := newFlagSet("gen-as")
+		name := fs.String("name", "", "name of the helper, default is the func name or type name + method name")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() == 0 {
+			return fmt.Errorf("usage: goom gen-as [-o file] [-pkg name] [-name helperName] [-check] " +
+				"pkgpath.func|pkgpath.(*type).method...")
+		}
+		src, err := gen.GenAs(&gen.AsConfig{
+			Symbols: fs.Args(),
+			Package: out.pkg,
+			Name:    *name,
+		})
+		if err != nil {
+			return err
+		}
+		return out.write(src)
+	},
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/tencent/goom/internal/gen"
+)
+
+// genFakeCommand 生成和未导出结构体内存布局一致的 fake 结构体
+// 比如: //go:generate go run github.com/tencent/goom/cmd/goom gen-fake -o fake_gen.go github.com/xxx/pkg.struct2
+// 原结构体为未导出类型时, 生成代码的 init 无法发现原结构体的变化, CI 中必须使用 -check 检查生成的代码是否是最新的
+var genFakeCommand = &command{
+	usage: "generate a layout-compatible fake struct of a struct from another package: " +
+		"gen-fake [-o file] [-pkg name] [-name fakeName] [-check] pkgpath.struct",
+	run: func(args []string) error {
+		fs, out := newFlagSet("gen-fake")
+		name := fs.String("name", "", "name of the fake struct, default is fake + struct name")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: goom gen-fake [-o file] [-pkg name] [-name fakeName] [-check] pkgpath.struct")
+		}
+		src, err := gen.GenFake(&gen.FakeConfig{
+			Symbol:  fs.Arg(0),
+			Package: out.pkg,
+			Name:    *name,
+		})
+		if err != nil {
+			return err
+		}
+		return out.write(src)
+	},
+}
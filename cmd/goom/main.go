@@ -0,0 +1,96 @@
+// Package main goom 命令行工具, 用于生成 mock 辅助代码, 可以配合 go:generate 使用
+// 用法: goom <command> [flags] <args>
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+// command 子命令
+type command struct {
+	// usage 子命令的用法说明
+	usage string
+	// run 执行子命令
+	run func(args []string) error
+}
+
+// commands 支持的子命令
+var commands = map[string]*command{
+	"gen-as":   genAsCommand,
+	"gen-fake": genFakeCommand,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "goom: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "goom %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+// usage 打印用法
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(os.Stderr, "usage: goom <command> [flags] <args>\n\ncommands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].usage)
+	}
+}
+
+// newFlagSet 创建子命令的参数解析器, 包含通用的 -o、-pkg、-check 参数
+func newFlagSet(name string) (*flag.FlagSet, *outputFlags) {
+	fs := flag.NewFlagSet("goom "+name, flag.ContinueOnError)
+	out := &outputFlags{}
+	fs.StringVar(&out.file, "o", "", "output file, default is stdout")
+	// go generate 执行时会设置 GOPACKAGE 环境变量
+	fs.StringVar(&out.pkg, "pkg", os.Getenv("GOPACKAGE"), "package name of the generated code, default is $GOPACKAGE")
+	fs.BoolVar(&out.check, "check", false, "check whether the output file is up to date instead of writing it")
+	return fs, out
+}
+
+// outputFlags 生成代码的输出参数
+type outputFlags struct {
+	file  string
+	pkg   string
+	check bool
+}
+
+// write 输出生成的代码; check 模式下只检查输出文件是否是最新的
+func (o *outputFlags) write(src []byte) error {
+	if o.file == "" {
+		if o.check {
+			return fmt.Errorf("-check requires -o")
+		}
+		_, err := os.Stdout.Write(src)
+		return err
+	}
+	if o.check {
+		old, err := ioutil.ReadFile(o.file)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(old, src) {
+			return fmt.Errorf("%s is out of date, please regenerate it", o.file)
+		}
+		return nil
+	}
+	return ioutil.WriteFile(o.file, src, 0644)
+}
@@ -0,0 +1,301 @@
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件实现了声明式的 mock 配置: 从 YAML 或 JSON 文件中加载 mock 规则, 无需编写代码即可注入异常、延迟,
+// 规则中的函数使用 unexports.FindFuncByName 支持的完整名称引用, 通过 Builder 应用。
+package mocker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tencent/goom/internal/unexports"
+)
+
+// Config 从文件中加载的声明式 mock 配置
+type Config struct {
+	path  string
+	rules []*ConfigRule
+	// pinned 白名单中固定了签名的函数, 不为 nil 时只能声明其中的函数, 使用固定的签名
+	pinned map[string]reflect.Type
+}
+
+// ConfigRule 配置中声明的一条 mock 规则, 同一个函数的多条规则按声明的顺序匹配参数条件, 都不满足时调用原函数
+type ConfigRule struct {
+	// Name 规则名称, 可选, 用于输出规则的激活情况
+	Name string `json:"name"`
+	// Func 被 mock 函数的完整名称, 比如 github.com/xxx/pkg.foo、github.com/xxx/pkg.(*Struct).method
+	Func string `json:"func"`
+	// Signature 被 mock 函数的签名, 比如 func(int, string) (int, error), 方法的第一个参数为接收体
+	Signature string `json:"signature"`
+	// When 参数条件, 为空时匹配任意参数, 支持参数表达式 {any: true}、{in: [1, 2]}、{eq: 值}
+	When []interface{} `json:"when"`
+	// Return 返回值, 不包括最后一个 error 类型的返回值
+	Return []interface{} `json:"return"`
+	// Error 最后一个 error 类型的返回值的错误信息
+	Error string `json:"error"`
+	// Delay 返回之前的延迟, 格式同 time.ParseDuration, 比如 10ms
+	Delay string `json:"delay"`
+
+	// pos 规则在配置文件中的位置, 格式为 文件:行号
+	pos     string
+	funcTyp reflect.Type
+	rule    *rule
+}
+
+// configFields 规则中可以声明的字段
+var configFields = map[string]bool{
+	"name": true, "func": true, "signature": true, "when": true, "return": true, "error": true, "delay": true,
+}
+
+// LoadConfig 从 YAML(.yaml、.yml) 或 JSON 文件中加载 mock 配置, 文件的内容为规则的列表
+// 加载时会校验规则, 错误信息中包含出错的文件和行号
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(path, data)
+}
+
+// ParseConfig 解析 YAML 或 JSON 格式的 mock 配置, path 为配置的来源, 用于错误信息
+func ParseConfig(path string, data []byte) (*Config, error) {
+	return parseConfig(path, data, nil)
+}
+
+// parseConfig 解析 mock 配置, pinned 不为 nil 时只能声明其中的函数, 函数的签名以 pinned 为准
+func parseConfig(path string, data []byte, pinned map[string]reflect.Type) (*Config, error) {
+	// JSON 也是合法的 YAML, 统一使用 YAML 解析以获取行号
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	c := &Config{path: path, pinned: pinned}
+	if len(doc.Content) == 0 {
+		return c, nil
+	}
+	list := doc.Content[0]
+	if list.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("%s:%d: mock config must be a list of rules", path, list.Line)
+	}
+
+	signatures := make(map[string]*ConfigRule)
+	for _, node := range list.Content {
+		r, err := c.parseRule(node)
+		if err != nil {
+			return nil, err
+		}
+		// 同一个函数的规则需要使用相同的签名
+		if first, ok := signatures[r.Func]; ok && first.funcTyp != r.funcTyp {
+			return nil, fmt.Errorf("%s: signature %v of %s is different from %s at %s",
+				c.fieldPos(node, "signature"), r.funcTyp, r.Func, first.funcTyp, first.pos)
+		}
+		signatures[r.Func] = r
+		c.rules = append(c.rules, r)
+	}
+	return c, nil
+}
+
+// parseRule 解析并校验一条规则
+func (c *Config) parseRule(node *yaml.Node) (*ConfigRule, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: mock rule must be an object", c.path, node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if key := node.Content[i]; !configFields[key.Value] {
+			return nil, fmt.Errorf("%s:%d: unknown field %q", c.path, key.Line, key.Value)
+		}
+	}
+	r := &ConfigRule{pos: fmt.Sprintf("%s:%d", c.path, node.Line)}
+	if err := decodeNode(node, r); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("%s: %s must be %v, actual: %s",
+				c.fieldPos(node, typeErr.Field), typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return nil, fmt.Errorf("%s: %w", r.pos, err)
+	}
+
+	if r.Func == "" {
+		return nil, fmt.Errorf("%s: func is empty", r.pos)
+	}
+	typ, err := c.ruleType(node, r)
+	if err != nil {
+		return nil, err
+	}
+	r.funcTyp = typ
+
+	if r.rule, err = newRule(typ, r.When, r.Return, r.Error, r.Delay); err != nil {
+		var ruleErr *ruleError
+		if errors.As(err, &ruleErr) {
+			return nil, fmt.Errorf("%s: %w", c.fieldPos(node, ruleErr.field), err)
+		}
+		return nil, fmt.Errorf("%s: %w", r.pos, err)
+	}
+	return r, nil
+}
+
+// ruleType 获取规则中函数的类型: 白名单固定了签名时使用固定的签名, 否则使用声明的签名并和函数的真实信息比较
+func (c *Config) ruleType(node *yaml.Node, r *ConfigRule) (reflect.Type, error) {
+	if c.pinned != nil {
+		typ, ok := c.pinned[r.Func]
+		if !ok {
+			return nil, fmt.Errorf("%s: %w: %s", c.fieldPos(node, "func"), errNotAllowed, r.Func)
+		}
+		// 不接受和白名单不一致的签名, 避免按错误的签名调用函数破坏栈
+		if r.Signature != "" {
+			declared, err := parseSignature(r.Signature)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", c.fieldPos(node, "signature"), err)
+			}
+			if declared != typ {
+				return nil, fmt.Errorf("%s: signature %v of %s is different from the allowed signature %v",
+					c.fieldPos(node, "signature"), declared, r.Func, typ)
+			}
+		}
+		r.Signature = typ.String()
+		return typ, nil
+	}
+
+	entry, err := unexports.FindFuncByName(r.Func)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.fieldPos(node, "func"), err)
+	}
+	if _, _, err := splitFuncName(r.Func); err != nil {
+		return nil, fmt.Errorf("%s: %w", c.fieldPos(node, "func"), err)
+	}
+	if r.Signature == "" {
+		return nil, fmt.Errorf("%s: signature of %s is empty", r.pos, r.Func)
+	}
+	typ, err := parseSignature(r.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.fieldPos(node, "signature"), err)
+	}
+	if err := checkArgsSize(r.Func, entry, typ); err != nil {
+		return nil, fmt.Errorf("%s: %w", c.fieldPos(node, "signature"), err)
+	}
+	return typ, nil
+}
+
+// checkArgsSize 比较签名和函数的真实信息中参数和返回值的大小
+// 签名和函数的真实签名不一致时调用会破坏栈, 至少需要参数和返回值的大小一致
+func checkArgsSize(name string, entry uintptr, typ reflect.Type) error {
+	if size, ok := unexports.FuncArgsSize(entry); ok && size != argsSize(typ) {
+		return fmt.Errorf("args and results size of signature %v is %d, but %s is %d", typ, argsSize(typ), name, size)
+	}
+	return nil
+}
+
+// fieldPos 获取规则中字段所在的位置, 字段不存在时返回规则所在的位置
+func (c *Config) fieldPos(node *yaml.Node, field string) string {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == field {
+			return fmt.Sprintf("%s:%d", c.path, node.Content[i+1].Line)
+		}
+	}
+	return fmt.Sprintf("%s:%d", c.path, node.Line)
+}
+
+// Rules 获取配置中的所有规则
+func (c *Config) Rules() []*ConfigRule {
+	return c.rules
+}
+
+// Apply 通过 Builder 应用配置中的所有规则, 调用 Builder.Reset 取消
+// 应用失败时返回错误, 并取消本次已经应用的所有函数的 mock(这些函数之前应用的规则同样被取消)
+func (c *Config) Apply(b *Builder) error {
+	_, err := c.apply(b)
+	return err
+}
+
+// apply 按函数分组应用规则, 失败时回滚, 返回被回滚的函数名
+func (c *Config) apply(b *Builder) ([]string, error) {
+	var (
+		names   []string
+		grouped = make(map[string][]*ConfigRule)
+	)
+	for _, r := range c.rules {
+		if _, ok := grouped[r.Func]; !ok {
+			names = append(names, r.Func)
+		}
+		grouped[r.Func] = append(grouped[r.Func], r)
+	}
+	var (
+		applied      []*DefMocker
+		appliedNames []string
+	)
+	for _, name := range names {
+		m, err := applyConfigRules(b, name, grouped[name])
+		if m != nil {
+			applied, appliedNames = append(applied, m), append(appliedNames, name)
+		}
+		if err != nil {
+			for _, m := range applied {
+				m.Cancel()
+			}
+			return appliedNames, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil, nil
+}
+
+// applyConfigRules 应用同一个函数的规则, 返回已经应用的 mocker; 应用过程中的 panic 转换为错误
+func applyConfigRules(b *Builder, name string, rules []*ConfigRule) (m *DefMocker, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	pkgName, funcName, _ := splitFuncName(name)
+	funcTyp := rules[0].funcTyp
+	m = b.ExportPkgFunc(pkgName, funcName).As(reflect.Zero(funcTyp).Interface()).(*DefMocker)
+
+	compiled := make([]*rule, len(rules))
+	for i, r := range rules {
+		compiled[i] = r.rule
+	}
+	return m, m.applyRules(funcTyp, compiled)
+}
+
+// String 规则的名称和位置
+func (r *ConfigRule) String() string {
+	if r.Name != "" {
+		return fmt.Sprintf("%s(%s)", r.Name, r.pos)
+	}
+	return fmt.Sprintf("%s(%s)", r.Func, r.pos)
+}
+
+// Pos 规则在配置文件中的位置, 格式为 文件:行号
+func (r *ConfigRule) Pos() string {
+	return r.pos
+}
+
+// decodeNode 将 YAML 节点解码为结构体, 按照 JSON 的规则转换
+func decodeNode(node *yaml.Node, v interface{}) error {
+	var raw interface{}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(data, v)
+}
+
+// splitFuncName 将函数的完整名称拆分为包路径和函数名, 比如 github.com/xxx/pkg.(*Struct).method
+// 拆分为 github.com/xxx/pkg 和 (*Struct).method
+func splitFuncName(name string) (string, string, error) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot <= 0 || slash+1+dot == len(name)-1 {
+		return "", "", fmt.Errorf("illegal func name %s, must be: package path.func name", name)
+	}
+	dot += slash + 1
+	return name[:dot], name[dot+1:], nil
+}
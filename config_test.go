@@ -0,0 +1,111 @@
+// Package mocker_test 对 mocker 包的测试
+// 当前文件实现了对 config.go 的单测
+package mocker_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	mocker "github.com/tencent/goom"
+	"github.com/tencent/goom/test"
+)
+
+// TestUnitConfigTestSuite 测试入口
+func TestUnitConfigTestSuite(t *testing.T) {
+	suite.Run(t, new(configTestSuite))
+}
+
+// configTestSuite 声明式 mock 配置测试套件
+type configTestSuite struct {
+	suite.Suite
+}
+
+// TestApply 测试应用配置文件中的规则
+func (s *configTestSuite) TestApply() {
+	config, err := mocker.LoadConfig("testdata/faults.yaml")
+	s.Require().NoError(err)
+	s.Equal(3, len(config.Rules()))
+	s.Equal("divide_overflow(testdata/faults.yaml:2)", config.Rules()[0].String())
+
+	mock := mocker.Create()
+	s.Require().NoError(config.Apply(mock))
+
+	start := time.Now()
+	_, err = test.Divide(6, 3)
+	s.EqualError(err, "overflow")
+	s.True(time.Since(start) >= 10*time.Millisecond, "delay must be applied")
+	_, err = test.Divide(8, 0)
+	s.EqualError(err, "overflow")
+
+	r, err := test.Divide(100, 1)
+	s.NoError(err)
+	s.Equal(7, r)
+
+	// 不满足条件时调用原函数
+	r, err = test.Divide(9, 3)
+	s.NoError(err)
+	s.Equal(3, r)
+
+	s.Equal(42, (&test.Fake{}).Call(1))
+
+	mock.Reset()
+	_, err = test.Divide(6, 3)
+	s.NoError(err)
+	s.Equal(1, (&test.Fake{}).Call(1))
+}
+
+// TestParseJSON 测试解析 JSON 格式的配置
+func (s *configTestSuite) TestParseJSON() {
+	config, err := mocker.ParseConfig("faults.json", []byte(`[
+  {"func": "github.com/tencent/goom/test.Join", "signature": "func([]string, string) string",
+   "when": [["a", "b"], {"eq": "-"}], "return": ["mocked"]}
+]`))
+	s.Require().NoError(err)
+
+	mock := mocker.Create()
+	defer mock.Reset()
+	s.Require().NoError(config.Apply(mock))
+	s.Equal("mocked", test.Join([]string{"a", "b"}, "-"))
+	s.Equal("a+b", test.Join([]string{"a", "b"}, "+"))
+}
+
+// TestValidate 测试校验错误中包含出错的文件和行号
+func (s *configTestSuite) TestValidate() {
+	tests := []struct {
+		name   string
+		config string
+		errPos string
+		errMsg string
+	}{
+		{"not list", "func: a", "faults.yaml:1", "must be a list"},
+		{"unknown field", "- func: github.com/tencent/goom/test.Divide\n  retrun: [1]", "faults.yaml:2",
+			"unknown field"},
+		{"func not found", "- name: a\n  func: github.com/tencent/goom/test.NotExists", "faults.yaml:2",
+			"NotExists"},
+		{"empty signature", "- func: github.com/tencent/goom/test.Divide", "faults.yaml:1", "signature"},
+		{"unsupported type", "- func: github.com/tencent/goom/test.Divide\n  signature: func(test.T) int",
+			"faults.yaml:2", "unsupported type test.T"},
+		{"illegal when", "- func: github.com/tencent/goom/test.Divide\n  signature: func(int, int) (int, error)\n" +
+			"  when: [1]", "faults.yaml:3", "when"},
+		{"illegal return", "- func: github.com/tencent/goom/test.Divide\n  signature: func(int, int) (int, error)\n" +
+			"\n  return: [a]", "faults.yaml:4", "return"},
+		{"illegal error", "- func: github.com/tencent/goom/test.Join\n  signature: func([]string, string) string\n" +
+			"  error: e", "faults.yaml:3", "error"},
+		{"illegal delay", "- func: github.com/tencent/goom/test.Divide\n  signature: func(int, int) (int, error)\n" +
+			"  delay: 1", "faults.yaml:3", "delay"},
+		{"signature size", "- func: github.com/tencent/goom/test.Divide\n  signature: func(int) int", "faults.yaml:2",
+			"size"},
+		{"signature conflict", "- func: github.com/tencent/goom/test.Divide\n  signature: func(int, int) (int, error)\n" +
+			"- func: github.com/tencent/goom/test.Divide\n  signature: func(int, int) int", "faults.yaml:4",
+			"faults.yaml:1"},
+	}
+	for _, tt := range tests {
+		_, err := mocker.ParseConfig("faults.yaml", []byte(tt.config))
+		s.Require().Error(err, tt.name)
+		s.True(strings.HasPrefix(err.Error(), tt.errPos+":"), "%s: %v", tt.name, err)
+		s.Contains(err.Error(), tt.errMsg, tt.name)
+	}
+}
@@ -1,12 +1,14 @@
 package mocker
 
 import (
+	"fmt"
 	"reflect"
 
 	"github.com/tencent/goom/arg"
 	"github.com/tencent/goom/internal/hack"
 	"github.com/tencent/goom/internal/iface"
 	"github.com/tencent/goom/internal/logger"
+	"github.com/tencent/goom/logging"
 )
 
 // excludeFunc 对 excludeFunc 不进行拦截
@@ -14,9 +16,9 @@ const (
 	excludeFunc = "time.Now"
 )
 
-// interceptDebugInfo 添加对 apply 的拦截代理，截取函数调用信息用于 debug
+// interceptDebugInfo 添加对 apply 的拦截代理，截取函数调用信息用于 debug、结构化日志和调用时间线
 func interceptDebugInfo(imp interface{}, pFunc iface.PFunc, mocker Mocker) (interface{}, iface.PFunc) {
-	if !logger.IsDebugOpen() {
+	if !logger.IsDebugOpen() && !logging.Enabled(logging.LevelDebug) && currentTrace() == nil {
 		return imp, pFunc
 	}
 
@@ -24,13 +26,14 @@ func interceptDebugInfo(imp interface{}, pFunc iface.PFunc, mocker Mocker) (inte
 	if pFunc != nil {
 		originPFunc := pFunc
 		pFunc = func(params []reflect.Value) []reflect.Value {
-			results := originPFunc(params)
 			// 日志打印用到了 time.Now,避免递归死循环
 			if mocker.String() == excludeFunc {
-				return results
+				return originPFunc(params)
 			}
+			results := traceCall(mocker, params, originPFunc)
 			logger.Consolefc(logger.DebugLevel, "mocker [%s] called, args [%s], results [%s]",
 				logger.Caller(hack.InterceptCallerSkip), mocker.String(), arg.SprintV(params), arg.SprintV(results))
+			emitCall(mocker, params, results)
 			return results
 		}
 		return imp, pFunc
@@ -39,13 +42,14 @@ func interceptDebugInfo(imp interface{}, pFunc iface.PFunc, mocker Mocker) (inte
 	if imp != nil {
 		originImp := imp
 		imp = reflect.MakeFunc(reflect.TypeOf(imp), func(params []reflect.Value) []reflect.Value {
-			results := reflect.ValueOf(originImp).Call(params)
 			// 日志打印用到了 time.Now,避免递归死循环
 			if mocker.String() == excludeFunc {
-				return results
+				return reflect.ValueOf(originImp).Call(params)
 			}
+			results := traceCall(mocker, params, reflect.ValueOf(originImp).Call)
 			logger.Consolefc(logger.DebugLevel, "mocker [%s] called, args [%s], results [%s]",
 				logger.Caller(hack.InterceptCallerSkip), mocker.String(), arg.SprintV(params), arg.SprintV(results))
+			emitCall(mocker, params, results)
 			return results
 		}).Interface()
 		return imp, pFunc
@@ -53,3 +57,28 @@ func interceptDebugInfo(imp interface{}, pFunc iface.PFunc, mocker Mocker) (inte
 
 	return imp, pFunc
 }
+
+// logApply 输出 mocker 应用的日志和 logging.Apply 事件, skip 为 Apply 相对于 logApply 的调用栈层次, 同 logger.Caller
+func logApply(m fmt.Stringer, skip int) {
+	logger.Consolefc(logger.DebugLevel, "mocker [%s] apply.", logger.Caller(skip+1), m.String())
+	if logging.Enabled(logging.LevelDebug) {
+		logging.Emit(logging.LevelDebug, logging.Apply, "mocker apply", logging.Any(logging.KeyMocker, m.String()))
+	}
+}
+
+// logCancel 输出 mocker 取消的 logging.Cancel 事件
+func logCancel(m fmt.Stringer) {
+	if logging.Enabled(logging.LevelDebug) {
+		logging.Emit(logging.LevelDebug, logging.Cancel, "mocker cancel", logging.Any(logging.KeyMocker, m.String()))
+	}
+}
+
+// emitCall 输出 mock 被调用的 logging.Call 事件
+func emitCall(mocker Mocker, params []reflect.Value, results []reflect.Value) {
+	if logging.Enabled(logging.LevelDebug) {
+		logging.Emit(logging.LevelDebug, logging.Call, "mocker called",
+			logging.Any(logging.KeyMocker, mocker.String()),
+			logging.Any(logging.KeyArgs, arg.SprintV(params)),
+			logging.Any(logging.KeyResults, arg.SprintV(results)))
+	}
+}
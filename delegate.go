@@ -0,0 +1,115 @@
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件实现了将结构体的所有方法委托给 fake 实现的能力。
+package mocker
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/tencent/goom/erro"
+	"github.com/tencent/goom/internal/logger"
+)
+
+// DelegateReport 结构体方法委托的结果
+type DelegateReport struct {
+	// Delegated 已经委托给 fake 的方法
+	Delegated []string
+	// TargetOnly 只存在于被 mock 的结构体中的方法, 没有被委托, 调用时仍然执行原方法
+	TargetOnly []string
+	// FakeOnly 只存在于 fake 中的方法
+	FakeOnly []string
+	// Mismatched 两边都存在但签名不一致的方法, 没有被委托
+	Mismatched []string
+}
+
+// String 委托结果的描述
+func (r *DelegateReport) String() string {
+	return fmt.Sprintf("delegated: [%s], target only: [%s], fake only: [%s], mismatched: [%s]",
+		strings.Join(r.Delegated, ", "), strings.Join(r.TargetOnly, ", "),
+		strings.Join(r.FakeOnly, ", "), strings.Join(r.Mismatched, ", "))
+}
+
+// Delegate 将结构体的所有导出方法委托给 fake 实现
+// fake 中同名且签名兼容的方法会被用作 mock 回调, 签名兼容是指:
+// 1. 和被 mock 的方法去掉接收体之后的签名一致, 比如 func(a int) int
+// 2. 或者第一个参数为被 mock 的方法的接收体, 比如 func(s *Struct, a int) int, 用于区分调用的结构体对象
+// 只存在于一边或者签名不一致的方法会在返回结果中列出, 不会被委托
+func (m *CachedMethodMocker) Delegate(fake interface{}) *DelegateReport {
+	if fake == nil {
+		panic(erro.NewIllegalParamError("fake", "nil"))
+	}
+	target := reflect.TypeOf(m.MethodMocker.structDef)
+	fakeV := reflect.ValueOf(fake)
+
+	report := &DelegateReport{}
+	for i := 0; i < target.NumMethod(); i++ {
+		method := target.Method(i)
+		fakeMethod := fakeV.MethodByName(method.Name)
+		if !fakeMethod.IsValid() {
+			report.TargetOnly = append(report.TargetOnly, method.Name)
+			continue
+		}
+		withReceiver, ok := delegateCompatible(method.Type, fakeMethod.Type())
+		if !ok {
+			report.Mismatched = append(report.Mismatched, fmt.Sprintf("%s: %s != %s",
+				method.Name, method.Type, fakeMethod.Type()))
+			continue
+		}
+		m.Method(method.Name).Apply(delegateImp(method.Type, fakeMethod, withReceiver))
+		report.Delegated = append(report.Delegated, method.Name)
+	}
+	for i := 0; i < fakeV.Type().NumMethod(); i++ {
+		name := fakeV.Type().Method(i).Name
+		if _, ok := target.MethodByName(name); !ok {
+			report.FakeOnly = append(report.FakeOnly, name)
+		}
+	}
+	logger.Debugf("delegate %s to %T: %s", target, fake, report)
+	return report
+}
+
+// delegateCompatible 判断 fake 的方法签名和被 mock 的方法(第一个参数为接收体)是否兼容
+// 返回 fake 的方法是否需要接收体参数
+func delegateCompatible(method reflect.Type, fake reflect.Type) (withReceiver bool, ok bool) {
+	if method.IsVariadic() != fake.IsVariadic() || method.NumOut() != fake.NumOut() {
+		return false, false
+	}
+	for i := 0; i < method.NumOut(); i++ {
+		if method.Out(i) != fake.Out(i) {
+			return false, false
+		}
+	}
+	switch fake.NumIn() {
+	case method.NumIn() - 1:
+		return false, sameIn(method, fake, 1)
+	case method.NumIn():
+		return true, sameIn(method, fake, 0)
+	default:
+		return false, false
+	}
+}
+
+// sameIn 判断 method 从 skip 开始的参数类型和 fake 的参数类型是否一致
+func sameIn(method reflect.Type, fake reflect.Type, skip int) bool {
+	for i := skip; i < method.NumIn(); i++ {
+		if method.In(i) != fake.In(i-skip) {
+			return false
+		}
+	}
+	return true
+}
+
+// delegateImp 构造转发到 fake 方法的 mock 回调
+func delegateImp(method reflect.Type, fakeMethod reflect.Value, withReceiver bool) interface{} {
+	return reflect.MakeFunc(method, func(args []reflect.Value) []reflect.Value {
+		if !withReceiver {
+			args = args[1:]
+		}
+		if method.IsVariadic() {
+			return fakeMethod.CallSlice(args)
+		}
+		return fakeMethod.Call(args)
+	}).Interface()
+}
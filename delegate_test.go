@@ -0,0 +1,92 @@
+package mocker_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/tencent/goom"
+	"github.com/tencent/goom/test"
+)
+
+// TestUnitDelegateTestSuite 测试入口
+func TestUnitDelegateTestSuite(t *testing.T) {
+	suite.Run(t, new(delegateTestSuite))
+}
+
+// delegateTestSuite 结构体方法委托测试
+type delegateTestSuite struct {
+	suite.Suite
+}
+
+// fakeAccount test.Account 的 fake 实现
+type fakeAccount struct {
+	deposits []int
+}
+
+// Balance 和原方法去掉接收体之后的签名一致
+func (f *fakeAccount) Balance() int {
+	return 1000
+}
+
+// Deposit 第一个参数为原方法的接收体
+func (f *fakeAccount) Deposit(a *test.Account, amount int) int {
+	f.deposits = append(f.deposits, amount)
+	return len(a.Owner) + amount
+}
+
+// Transfer 可变参数
+func (f *fakeAccount) Transfer(_ *test.Account, amounts ...int) error {
+	if len(amounts) > 1 {
+		return errors.New("fake transfer error")
+	}
+	return nil
+}
+
+// Name 签名不一致
+func (f *fakeAccount) Name() int {
+	return 0
+}
+
+// Close 只存在于 fake 中
+func (f *fakeAccount) Close() {}
+
+// TestDelegate 测试将结构体的方法委托给 fake
+func (s *delegateTestSuite) TestDelegate() {
+	s.Run("success", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+
+		fake := &fakeAccount{}
+		report := mock.Struct(&test.Account{}).Delegate(fake)
+		s.ElementsMatch([]string{"Balance", "Deposit", "Transfer"}, report.Delegated, "delegated check")
+		s.Equal([]string{"Close"}, report.FakeOnly, "fake only check")
+		s.Len(report.Mismatched, 1, "mismatched check")
+		s.Empty(report.TargetOnly, "target only check")
+
+		account := &test.Account{Owner: "tom"}
+		s.Equal(1000, account.Balance(), "delegate check")
+		s.Equal(13, account.Deposit(10), "delegate with receiver check")
+		s.Equal([]int{10}, fake.deposits, "fake state check")
+		s.NoError(account.Transfer(&test.Account{}, 1), "delegate variadic check")
+		s.Error(account.Transfer(&test.Account{}, 1, 2), "delegate variadic check")
+		s.Equal("tom", account.Name(), "mismatched method not mocked check")
+
+		mock.Reset()
+		s.Equal(0, account.Balance(), "reset check")
+	})
+}
+
+// TestDelegateTargetOnly 测试只存在于被 mock 结构体中的方法
+func (s *delegateTestSuite) TestDelegateTargetOnly() {
+	s.Run("success", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+
+		report := mock.Struct(&test.Account{}).Delegate(struct{}{})
+		s.Empty(report.Delegated, "delegated check")
+		s.ElementsMatch([]string{"Balance", "Deposit", "Name", "Transfer"}, report.TargetOnly, "target only check")
+		s.Equal(10, (&test.Account{}).Deposit(10), "not mocked check")
+	})
+}
@@ -0,0 +1,45 @@
+package erro
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxInlinedCallers 错误信息中最多展示的调用方数量
+const maxInlinedCallers = 5
+
+// FuncInlined 函数被内联异常, 被内联的调用位置 mock 不会生效
+type FuncInlined struct {
+	funcName string
+	callers  []string
+}
+
+// Error 返回错误字符串
+func (e *FuncInlined) Error() string {
+	callers := e.callers
+	more := ""
+	if len(callers) > maxInlinedCallers {
+		more = fmt.Sprintf("\n* ... and %d more", len(callers)-maxInlinedCallers)
+		callers = callers[:maxInlinedCallers]
+	}
+	return fmt.Sprintf("func %s is inlined at:\n* %s%s\nmock has no effect at these call sites, "+
+		"please build with -gcflags=all=-l or add //go:noinline to the func",
+		e.funcName, strings.Join(callers, "\n* "), more)
+}
+
+// FuncName 被内联的函数名
+func (e *FuncInlined) FuncName() string {
+	return e.funcName
+}
+
+// Callers 内联了该函数的调用位置
+func (e *FuncInlined) Callers() []string {
+	return e.callers
+}
+
+// NewFuncInlinedError 函数被内联
+// funcName 函数名称
+// callers 内联了该函数的调用位置
+func NewFuncInlinedError(funcName string, callers []string) error {
+	return &FuncInlined{funcName: funcName, callers: callers}
+}
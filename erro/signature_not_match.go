@@ -0,0 +1,56 @@
+package erro
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// SignatureNotMatch 代理函数和原函数的签名不兼容异常
+// 参数的寄存器分类(整数或浮点)、指针位置或者结构体内存布局不一致时, 代理函数会错误地解析参数
+type SignatureNotMatch struct {
+	origin      reflect.Type
+	replacement reflect.Type
+	position    string
+	index       int
+	reason      string
+}
+
+// Error 返回错误字符串
+func (s *SignatureNotMatch) Error() string {
+	where := fmt.Sprintf("%s %d", s.position, s.index)
+	if s.index < 0 {
+		where = s.position + " len"
+	}
+	return fmt.Sprintf("func signature mismatch, %s %s, origin: %v, replacement: %v; "+
+		"if the replacement is a fake, please use Unsafe() to skip the check", where, s.reason, s.origin, s.replacement)
+}
+
+// Origin 原函数类型
+func (s *SignatureNotMatch) Origin() reflect.Type {
+	return s.origin
+}
+
+// Replacement 代理函数类型
+func (s *SignatureNotMatch) Replacement() reflect.Type {
+	return s.replacement
+}
+
+// Position 不兼容的位置: args 或 returns
+func (s *SignatureNotMatch) Position() string {
+	return s.position
+}
+
+// Index 不兼容的参数(或返回值)的下标, 参数个数不一致时为 -1
+func (s *SignatureNotMatch) Index() int {
+	return s.index
+}
+
+// NewSignatureNotMatchError 创建签名不兼容异常
+// origin 原函数类型
+// replacement 代理函数类型
+// position 不兼容的位置: args 或 returns
+// index 不兼容的参数(或返回值)的下标, 参数个数不一致时为 -1
+// reason 不兼容的原因
+func NewSignatureNotMatchError(origin, replacement reflect.Type, position string, index int, reason string) error {
+	return &SignatureNotMatch{origin: origin, replacement: replacement, position: position, index: index, reason: reason}
+}
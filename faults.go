@@ -0,0 +1,121 @@
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件实现了通过环境变量注入异常: 测试进程启动时读取 GOOM_FAULTS 中的 mock 配置并应用, 无需修改代码即可注入异常、延迟。
+package mocker
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/tencent/goom/internal/logger"
+)
+
+// faultsEnv 启动时注入异常的环境变量, 值为 mock 配置文件的路径或者配置的内容, 配置格式同 LoadConfig
+const faultsEnv = "GOOM_FAULTS"
+
+var (
+	// faultsLock 保护 faults 和 activeFaults
+	faultsLock sync.Mutex
+	// faults 注入异常使用的 Builder
+	faults *Builder
+	// activeFaults 已经激活的规则, key 为函数名称
+	activeFaults = make(map[string][]*ConfigRule)
+	// faultFuncs 已经激活规则的函数名称, 按第一次激活的顺序
+	faultFuncs []string
+)
+
+// init 应用 GOOM_FAULTS 中声明的异常, 配置不合法时 panic, 避免异常注入没有生效而不被发现
+func init() {
+	value := os.Getenv(faultsEnv)
+	if value == "" {
+		return
+	}
+	if _, err := ApplyFaults(value); err != nil {
+		panic(fmt.Sprintf("%s: %v", faultsEnv, err))
+	}
+}
+
+// ApplyFaults 应用异常注入的配置, value 为 mock 配置文件的路径或者配置的内容, 返回激活的规则
+// 测试进程启动时会自动应用环境变量 GOOM_FAULTS 中的配置; 激活的规则会输出到日志和控制台
+// 多次调用时, 同一个函数的规则以最后一次应用的配置为准
+func ApplyFaults(value string) ([]*ConfigRule, error) {
+	var (
+		config *Config
+		err    error
+	)
+	if info, statErr := os.Stat(value); statErr == nil && !info.IsDir() {
+		config, err = LoadConfig(value)
+	} else {
+		config, err = ParseConfig("$"+faultsEnv, []byte(value))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	faultsLock.Lock()
+	defer faultsLock.Unlock()
+	if faults == nil {
+		faults = Create()
+	}
+	if rolledBack, err := config.apply(faults); err != nil {
+		// 被回滚的函数之前激活的规则同样已经被取消
+		for _, name := range rolledBack {
+			removeFault(name)
+		}
+		return nil, err
+	}
+	// 同一个函数之前激活的规则已经被覆盖, 同 AdminHandler.Apply
+	applied := make(map[string]bool)
+	for _, r := range config.Rules() {
+		if _, ok := activeFaults[r.Func]; !ok {
+			faultFuncs = append(faultFuncs, r.Func)
+		}
+		if !applied[r.Func] {
+			applied[r.Func] = true
+			activeFaults[r.Func] = nil
+		}
+		activeFaults[r.Func] = append(activeFaults[r.Func], r)
+	}
+	for _, r := range config.Rules() {
+		logger.Importantf("fault [%s] activated on %s", r, r.Func)
+		logger.Consolef(logger.WarningLevel, "fault [%s] activated on %s", r, r.Func)
+	}
+	return config.Rules(), nil
+}
+
+// removeFault 移除函数已经激活的规则, 调用方需持有 faultsLock
+func removeFault(name string) {
+	if _, ok := activeFaults[name]; !ok {
+		return
+	}
+	delete(activeFaults, name)
+	for i, n := range faultFuncs {
+		if n == name {
+			faultFuncs = append(faultFuncs[:i], faultFuncs[i+1:]...)
+			break
+		}
+	}
+}
+
+// ActiveFaults 获取通过 ApplyFaults 或 GOOM_FAULTS 激活的规则
+func ActiveFaults() []*ConfigRule {
+	faultsLock.Lock()
+	defer faultsLock.Unlock()
+	var rules []*ConfigRule
+	for _, name := range faultFuncs {
+		rules = append(rules, activeFaults[name]...)
+	}
+	return rules
+}
+
+// ResetFaults 取消通过 ApplyFaults 或 GOOM_FAULTS 注入的所有异常
+func ResetFaults() {
+	faultsLock.Lock()
+	defer faultsLock.Unlock()
+	if faults != nil {
+		faults.Reset()
+	}
+	activeFaults = make(map[string][]*ConfigRule)
+	faultFuncs = nil
+}
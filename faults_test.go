@@ -0,0 +1,81 @@
+// Package mocker_test 对 mocker 包的测试
+// 当前文件实现了对 faults.go 的单测
+package mocker_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	mocker "github.com/tencent/goom"
+	"github.com/tencent/goom/test"
+)
+
+// TestUnitFaultsTestSuite 测试入口
+func TestUnitFaultsTestSuite(t *testing.T) {
+	suite.Run(t, new(faultsTestSuite))
+}
+
+// faultsTestSuite 异常注入测试套件
+type faultsTestSuite struct {
+	suite.Suite
+}
+
+// TearDownTest 取消注入的异常
+func (s *faultsTestSuite) TearDownTest() {
+	mocker.ResetFaults()
+}
+
+// TestApplyFile 测试应用配置文件中的异常
+func (s *faultsTestSuite) TestApplyFile() {
+	rules, err := mocker.ApplyFaults("testdata/faults.yaml")
+	s.Require().NoError(err)
+	s.Equal(3, len(rules))
+	s.Equal(rules, mocker.ActiveFaults())
+
+	_, err = test.Divide(6, 3)
+	s.EqualError(err, "overflow")
+	s.Equal(42, (&test.Fake{}).Call(1))
+
+	mocker.ResetFaults()
+	s.Empty(mocker.ActiveFaults())
+	_, err = test.Divide(6, 3)
+	s.NoError(err)
+	s.Equal(1, (&test.Fake{}).Call(1))
+}
+
+// TestApplyContent 测试应用配置内容中的异常
+func (s *faultsTestSuite) TestApplyContent() {
+	rules, err := mocker.ApplyFaults(`[{name: join, func: github.com/tencent/goom/test.Join, ` +
+		`signature: "func([]string, string) string", return: [fault]}]`)
+	s.Require().NoError(err)
+	s.Equal("join($GOOM_FAULTS:1)", rules[0].String())
+	s.Equal("fault", test.Join([]string{"a"}, ","))
+}
+
+// TestApplyOverride 测试多次应用时同一个函数的规则被覆盖
+func (s *faultsTestSuite) TestApplyOverride() {
+	_, err := mocker.ApplyFaults("testdata/faults.yaml")
+	s.Require().NoError(err)
+	rules, err := mocker.ApplyFaults(`[{name: divide, func: github.com/tencent/goom/test.Divide, ` +
+		`signature: "func(int, int) (int, error)", return: [9]}]`)
+	s.Require().NoError(err)
+
+	active := mocker.ActiveFaults()
+	s.Require().Equal(2, len(active))
+	s.Equal("divide($GOOM_FAULTS:1)", active[0].String())
+	s.Equal(rules[0], active[0])
+	s.Equal("github.com/tencent/goom/test.(*Fake).call", active[1].Func)
+
+	r, err := test.Divide(6, 3)
+	s.NoError(err)
+	s.Equal(9, r)
+}
+
+// TestApplyError 测试应用不合法的配置
+func (s *faultsTestSuite) TestApplyError() {
+	_, err := mocker.ApplyFaults("- func: github.com/tencent/goom/test.NotExists")
+	s.Error(err)
+	s.Contains(err.Error(), "$GOOM_FAULTS:1")
+	s.Empty(mocker.ActiveFaults())
+}
@@ -0,0 +1,88 @@
+//go:build go1.18
+// +build go1.18
+
+// Package mocker_test 对 mocker 包的测试
+// 当前文件实现了对泛型函数和方法 mock 的单测
+package mocker_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	mocker "github.com/tencent/goom"
+	"github.com/tencent/goom/test"
+)
+
+// TestUnitGenericTestSuite 测试入口
+func TestUnitGenericTestSuite(t *testing.T) {
+	suite.Run(t, new(genericTestSuite))
+}
+
+// genericTestSuite 泛型 mock 测试套件
+type genericTestSuite struct {
+	suite.Suite
+}
+
+// TestGenericFunc 测试泛型函数的 mock
+func (s *genericTestSuite) TestGenericFunc() {
+	s.Run("apply", func() {
+		mock := mocker.Create()
+		mock.Func(test.Map[int, string]).Apply(func(s []int, f func(int) string) []string {
+			return []string{"fake"}
+		})
+		s.Equal([]string{"fake"}, test.Map([]int{1, 2}, strconv.Itoa), "generic func mock check")
+
+		mock.Reset()
+		s.Equal([]string{"1", "2"}, test.Map([]int{1, 2}, strconv.Itoa), "generic func reset check")
+	})
+	s.Run("other instantiation call origin", func() {
+		type myInt int
+		mock := mocker.Create()
+		defer mock.Reset()
+		mock.Func(test.Map[int, string]).Return([]string{"fake"})
+
+		s.Equal([]string{"fake"}, test.Map([]int{1}, strconv.Itoa), "generic func mock check")
+		s.Equal([]string{"2"}, test.Map([]myInt{2}, func(i myInt) string {
+			return strconv.Itoa(int(i))
+		}), "other instantiation check")
+	})
+	s.Run("call origin", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+		m := mock.Func(test.Map[int, string])
+		m.Apply(func(s []int, f func(int) string) []string {
+			return append(m.CallOrigin(s, f)[0].([]string), "fake")
+		})
+		s.Equal([]string{"1", "fake"}, test.Map([]int{1}, strconv.Itoa), "generic func call origin check")
+	})
+	s.Run("export func", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+		mock.Pkg("github.com/tencent/goom/test").ExportFunc("Map[int,string]").
+			Apply(func(s []int, f func(int) string) []string {
+				return nil
+			})
+		s.Nil(test.Map([]int{1}, strconv.Itoa), "generic func export check")
+	})
+}
+
+// TestGenericMethod 测试泛型方法的 mock
+func (s *genericTestSuite) TestGenericMethod() {
+	s.Run("when", func() {
+		mock := mocker.Create()
+		mock.Struct(&test.Cache[string, int]{}).Method("Get").When("a").Return(100, true).DefaultCallOrigin()
+
+		c := test.NewCache[string, int]()
+		c.Put("b", 2)
+		v, ok := c.Get("a")
+		s.Equal(100, v, "generic method when check")
+		s.True(ok, "generic method when check")
+		v, _ = c.Get("b")
+		s.Equal(2, v, "generic method call origin check")
+
+		mock.Reset()
+		_, ok = c.Get("a")
+		s.False(ok, "generic method reset check")
+	})
+}
@@ -0,0 +1,354 @@
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件实现了调用的录制和回放: 录制时调用转发到原函数, 同时记录每次调用的参数和返回值, 取消 mock 时写入 golden 文件;
+// 回放时将 golden 文件转换为以参数为条件的 When 返回值表。
+package mocker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/tencent/goom/arg"
+	"github.com/tencent/goom/erro"
+	"github.com/tencent/goom/internal/logger"
+)
+
+// updateEnv 是否录制 golden 文件, 运行测试时指定 GOOM_UPDATE=true 开启, 否则回放 golden 文件
+const updateEnv = "GOOM_UPDATE"
+
+// updating 是否为录制模式
+func updating() bool {
+	update, _ := strconv.ParseBool(os.Getenv(updateEnv))
+	return update
+}
+
+// Codec golden 文件的编解码器
+type Codec interface {
+	// Marshal 编码
+	Marshal(v interface{}) ([]byte, error)
+	// Unmarshal 解码
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec JSON 编解码器, golden 文件默认使用的编解码器
+type JSONCodec struct{}
+
+// Marshal 编码为缩进格式的 JSON, 方便查看和 diff
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// Unmarshal 解码 JSON
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// golden golden 文件的内容
+type golden struct {
+	// Func 被录制的函数名称
+	Func string `json:"func"`
+	// Calls 调用记录, 按调用的先后顺序排列
+	Calls []*goldenCall `json:"calls"`
+}
+
+// goldenCall 一次调用的记录
+type goldenCall struct {
+	// Args 调用参数, error 类型的参数记录为错误信息
+	Args []interface{} `json:"args"`
+	// Results 返回值, error 类型的返回值记录为错误信息
+	Results []interface{} `json:"results"`
+}
+
+// recorder 记录调用, 在取消 mock 时写入 golden 文件
+type recorder struct {
+	lock   sync.Mutex
+	path   string
+	codec  Codec
+	golden *golden
+}
+
+// Record 录制函数的调用: 调用默认转发到原函数, 同时记录每次调用的参数和返回值,
+// 在 Cancel 或者 Builder.Reset 时写入 golden 文件 path; 可以通过返回的 When 指定部分调用的返回值, 同样会被录制
+// 未指定环境变量 GOOM_UPDATE=true 时, 回放 path 中已经录制的调用, 等同于 Replay
+// codec 编解码器, 默认使用 JSONCodec
+func (m *DefMocker) Record(path string, codec ...Codec) *When {
+	if !updating() {
+		return m.Replay(path, codec...)
+	}
+	if m.funcDef == nil {
+		panic("funcDef is empty")
+	}
+	rec := &recorder{
+		path:   path,
+		codec:  codecOf(codec),
+		golden: &golden{Func: m.String(), Calls: []*goldenCall{}},
+	}
+	// 先写入空的调用记录, 尽早发现路径错误
+	if err := rec.flush(); err != nil {
+		panic(err)
+	}
+	when, err := CreateWhen(m, m.funcDef, nil, nil, false)
+	if err != nil {
+		panic(err)
+	}
+	when.DefaultCallOrigin()
+	m.setRecorder(when, rec)
+	m.doApply(m.recordImp(reflect.TypeOf(m.funcDef), when, rec))
+	if err := m.prepareOrigin(); err != nil {
+		m.Cancel()
+		panic(erro.NewIllegalStatusError("Record", m.String()+": "+err.Error()))
+	}
+	return when
+}
+
+// Replay 回放 golden 文件 path 中录制的调用: 参数和录制时一致时, 返回录制时的返回值
+// 相同参数的多次调用按录制的顺序依次返回, 参数为接口、func、chan 类型时匹配任意值;
+// 参数没有被录制过的调用会 panic, 可以通过返回的 When 指定默认的返回值, 或者使用 DefaultCallOrigin 调用原函数
+// codec 编解码器, 默认使用 JSONCodec
+func (m *DefMocker) Replay(path string, codec ...Codec) *When {
+	if m.funcDef == nil {
+		panic("funcDef is empty")
+	}
+	calls, err := loadGolden(path, codecOf(codec), reflect.TypeOf(m.funcDef))
+	if err != nil {
+		panic(err)
+	}
+
+	// 相同参数的调用合并为一个条件, 依次返回录制的返回值
+	var (
+		keys    []string
+		grouped = make(map[string][]*replayCall, len(calls))
+	)
+	for _, call := range calls {
+		if _, ok := grouped[call.key]; !ok {
+			keys = append(keys, call.key)
+		}
+		grouped[call.key] = append(grouped[call.key], call)
+	}
+	if len(keys) == 0 {
+		// 没有录制到调用, 返回零值
+		return m.Return(zeroValues(outTypes(reflect.TypeOf(m.funcDef)))...)
+	}
+
+	var when *When
+	for _, key := range keys {
+		group := grouped[key]
+		if when == nil {
+			when = m.When(group[0].args...)
+		} else {
+			when.When(group[0].args...)
+		}
+		when.Return(group[0].results...)
+		for _, call := range group[1:] {
+			when.AndReturn(call.results...)
+		}
+	}
+	return when
+}
+
+// setRecorder 设置录制模式的 When 条件和调用记录器
+func (m *baseMocker) setRecorder(when *When, rec *recorder) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.when = when
+	m.recorder = rec
+}
+
+// flushRecorder 将录制的调用写入 golden 文件, 没有录制时忽略
+func (m *baseMocker) flushRecorder() {
+	m.lock.Lock()
+	rec := m.recorder
+	m.recorder = nil
+	m.lock.Unlock()
+
+	if rec == nil {
+		return
+	}
+	if err := rec.flush(); err != nil {
+		logger.Consolefc(logger.ErrorLevel, "write golden file [%s] error: %v", logger.Caller(4), rec.path, err)
+	}
+}
+
+// recordImp 生成录制模式的代理函数: 按 when 的条件调用原函数或者返回指定的值, 并记录调用
+func (m *baseMocker) recordImp(funcTyp reflect.Type, when *When, rec *recorder) interface{} {
+	return reflect.MakeFunc(funcTyp, func(args []reflect.Value) []reflect.Value {
+		results := when.invoke(args)
+		rec.record(args, results)
+		return results
+	}).Interface()
+}
+
+// record 记录一次调用, 在 flush 时写入 golden 文件
+func (r *recorder) record(args []reflect.Value, results []reflect.Value) {
+	call := &goldenCall{
+		Args:    encodeValues(args),
+		Results: encodeValues(results),
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.golden.Calls = append(r.golden.Calls, call)
+}
+
+// flush 将记录的调用写入 golden 文件
+func (r *recorder) flush() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	data, err := r.codec.Marshal(r.golden)
+	if err != nil {
+		return fmt.Errorf("marshal golden file %s error: %w", r.path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, data, 0644)
+}
+
+// replayCall 回放的一次调用
+type replayCall struct {
+	// key 参数编码后的内容, 用于识别相同参数的调用
+	key     string
+	args    []interface{}
+	results []interface{}
+}
+
+// loadGolden 读取 golden 文件, 按照函数的参数和返回值类型解码
+func loadGolden(path string, codec Codec, funcTyp reflect.Type) ([]*replayCall, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("golden file %s not found, please record it with: GOOM_UPDATE=true go test", path)
+		}
+		return nil, err
+	}
+	g := &golden{}
+	if err := codec.Unmarshal(data, g); err != nil {
+		return nil, fmt.Errorf("unmarshal golden file %s error: %w", path, err)
+	}
+
+	in, out := inTypes(false, funcTyp), outTypes(funcTyp)
+	calls := make([]*replayCall, 0, len(g.Calls))
+	for i, call := range g.Calls {
+		if len(call.Args) != len(in) || len(call.Results) != len(out) {
+			return nil, fmt.Errorf("call %d in golden file %s does not match the signature %v, "+
+				"please record it again with: GOOM_UPDATE=true go test", i, path, funcTyp)
+		}
+		key, err := codec.Marshal(call.Args)
+		if err != nil {
+			return nil, err
+		}
+		args, err := decodeValues(codec, call.Args, in, true)
+		if err != nil {
+			return nil, fmt.Errorf("decode args of call %d in golden file %s error: %w", i, path, err)
+		}
+		results, err := decodeValues(codec, call.Results, out, false)
+		if err != nil {
+			return nil, fmt.Errorf("decode results of call %d in golden file %s error: %w", i, path, err)
+		}
+		calls = append(calls, &replayCall{key: string(key), args: args, results: results})
+	}
+	return calls, nil
+}
+
+// errorType error 类型
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// encodeValues 将参数或返回值转换为可编码的值, error 转换为错误信息, 无法解码的值记录为空
+func encodeValues(values []reflect.Value) []interface{} {
+	encoded := make([]interface{}, len(values))
+	for i, v := range values {
+		if !v.IsValid() || !v.CanInterface() {
+			continue
+		}
+		if v.Type() == errorType {
+			if !v.IsNil() {
+				encoded[i] = v.Interface().(error).Error()
+			}
+			continue
+		}
+		if decodable(v.Type()) {
+			encoded[i] = v.Interface()
+		}
+	}
+	return encoded
+}
+
+// decodeValues 将 golden 文件中的值按照类型解码
+// isArg 是否为参数, 无法解码的参数类型解码为 arg.Any(), 匹配任意值;
+// interface{} 类型的参数解码后丢失了原来的类型(比如 int 解码为 float64), 同样匹配任意值
+func decodeValues(codec Codec, values []interface{}, types []reflect.Type, isArg bool) ([]interface{}, error) {
+	decoded := make([]interface{}, len(values))
+	for i, typ := range types {
+		if isArg && (!decodable(typ) || typ.Kind() == reflect.Interface) {
+			decoded[i] = arg.Any()
+			continue
+		}
+		v, err := decodeValue(codec, values[i], typ)
+		if err != nil {
+			return nil, fmt.Errorf("%d: %w", i, err)
+		}
+		decoded[i] = v
+	}
+	return decoded, nil
+}
+
+// decodeValue 将 golden 文件中的值按照类型解码
+func decodeValue(codec Codec, value interface{}, typ reflect.Type) (interface{}, error) {
+	if value == nil {
+		return reflect.Zero(typ).Interface(), nil
+	}
+	if typ == errorType {
+		msg, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("error must be recorded as string, actual: %v", value)
+		}
+		return errors.New(msg), nil
+	}
+	if !decodable(typ) {
+		return reflect.Zero(typ).Interface(), nil
+	}
+	data, err := codec.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	v := reflect.New(typ)
+	if err := codec.Unmarshal(data, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
+// decodable 是否能从 golden 文件中解码出原来的值
+func decodable(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Interface:
+		return typ.NumMethod() == 0
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return false
+	default:
+		return true
+	}
+}
+
+// zeroValues 获取类型列表的零值
+func zeroValues(types []reflect.Type) []interface{} {
+	values := make([]interface{}, len(types))
+	for i, typ := range types {
+		values[i] = reflect.Zero(typ).Interface()
+	}
+	return values
+}
+
+// codecOf 获取指定的编解码器, 默认使用 JSONCodec
+func codecOf(codec []Codec) Codec {
+	if len(codec) > 0 && codec[0] != nil {
+		return codec[0]
+	}
+	return JSONCodec{}
+}
@@ -0,0 +1,151 @@
+// Package mocker_test 对 mocker 包的测试
+// 当前文件实现了对 golden.go 的单测
+package mocker_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	mocker "github.com/tencent/goom"
+	"github.com/tencent/goom/test"
+)
+
+// TestUnitGoldenTestSuite 测试入口
+func TestUnitGoldenTestSuite(t *testing.T) {
+	suite.Run(t, new(goldenTestSuite))
+}
+
+// goldenTestSuite 调用录制和回放测试套件
+type goldenTestSuite struct {
+	suite.Suite
+	dir string
+}
+
+// SetupTest 创建 golden 文件的临时目录
+func (s *goldenTestSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "goom-golden")
+	s.Require().NoError(err)
+	s.dir = dir
+}
+
+// TearDownTest 删除 golden 文件的临时目录
+func (s *goldenTestSuite) TearDownTest() {
+	_ = os.RemoveAll(s.dir)
+}
+
+// TestRecordAndReplay 测试录制调用之后回放
+func (s *goldenTestSuite) TestRecordAndReplay() {
+	path := filepath.Join(s.dir, "testdata", "divide.golden.json")
+	s.Run("record", func() {
+		s.Require().NoError(os.Setenv("GOOM_UPDATE", "true"))
+		defer func() { _ = os.Unsetenv("GOOM_UPDATE") }()
+
+		mock := mocker.Create()
+		mock.Func(test.Divide).Record(path)
+		r, err := test.Divide(6, 3)
+		s.Equal(2, r, "record call through check")
+		s.NoError(err)
+		_, err = test.Divide(1, 0)
+		s.EqualError(err, "divide by zero", "record call through check")
+		data, err := ioutil.ReadFile(path)
+		s.Require().NoError(err)
+		s.JSONEq(`{"func": "github.com/tencent/goom/test.Divide", "calls": []}`, string(data),
+			"golden file is written on reset check")
+		mock.Reset()
+
+		data, err = ioutil.ReadFile(path)
+		s.Require().NoError(err)
+		s.JSONEq(`{"func": "github.com/tencent/goom/test.Divide", "calls": [
+			{"args": [6, 3], "results": [2, null]},
+			{"args": [1, 0], "results": [0, "divide by zero"]}]}`, string(data), "golden file check")
+	})
+	s.Run("replay", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+
+		// 未指定 GOOM_UPDATE=true 时回放
+		mock.Func(test.Divide).Record(path)
+		r, err := test.Divide(6, 3)
+		s.Equal(2, r, "replay check")
+		s.NoError(err)
+		_, err = test.Divide(1, 0)
+		s.EqualError(err, "divide by zero", "replay check")
+	})
+}
+
+// TestRecordWhen 测试录制模式下通过 When 指定部分调用的返回值
+func (s *goldenTestSuite) TestRecordWhen() {
+	s.Require().NoError(os.Setenv("GOOM_UPDATE", "true"))
+	defer func() { _ = os.Unsetenv("GOOM_UPDATE") }()
+
+	path := filepath.Join(s.dir, "divide.golden.json")
+	mock := mocker.Create()
+	mock.Func(test.Divide).Record(path).When(1, 0).Return(-1, nil)
+	r, _ := test.Divide(6, 3)
+	s.Equal(2, r, "record call through check")
+	r, err := test.Divide(1, 0)
+	s.Equal(-1, r, "record when check")
+	s.NoError(err)
+	mock.Reset()
+
+	data, err := ioutil.ReadFile(path)
+	s.Require().NoError(err)
+	s.JSONEq(`{"func": "github.com/tencent/goom/test.Divide", "calls": [
+		{"args": [6, 3], "results": [2, null]},
+		{"args": [1, 0], "results": [-1, null]}]}`, string(data), "golden file check")
+}
+
+// TestReplay 测试回放手工编辑的 golden 文件
+func (s *goldenTestSuite) TestReplay() {
+	path := filepath.Join(s.dir, "join.golden.json")
+	s.Require().NoError(ioutil.WriteFile(path, []byte(`{"func": "github.com/tencent/goom/test.Join", "calls": [
+		{"args": [["a", "b"], ","], "results": ["first"]},
+		{"args": [["c"], "-"], "results": ["other"]},
+		{"args": [["a", "b"], ","], "results": ["second"]}]}`), 0644))
+
+	mock := mocker.Create()
+	defer mock.Reset()
+	mock.Func(test.Join).Replay(path)
+
+	s.Equal("first", test.Join([]string{"a", "b"}, ","), "replay check")
+	s.Equal("second", test.Join([]string{"a", "b"}, ","), "replay in order check")
+	s.Equal("other", test.Join([]string{"c"}, "-"), "replay check")
+	s.Panics(func() { test.Join([]string{"d"}, ",") }, "replay not recorded check")
+}
+
+// describe 参数为 interface{} 的函数
+//
+//go:noinline
+func describe(v interface{}) string {
+	return fmt.Sprint(v)
+}
+
+// TestReplayEmptyInterface 测试回放 interface{} 类型的参数: 解码后类型和录制时不一致, 匹配任意值
+func (s *goldenTestSuite) TestReplayEmptyInterface() {
+	path := filepath.Join(s.dir, "describe.golden.json")
+	s.Require().NoError(ioutil.WriteFile(path, []byte(`{"func": "describe", "calls": [
+		{"args": [1], "results": ["one"]}]}`), 0644))
+
+	mock := mocker.Create()
+	defer mock.Reset()
+	mock.Func(describe).Replay(path)
+
+	// 录制时的 int 解码为 float64, 按值匹配时无法匹配
+	s.Equal("one", describe(1), "replay empty interface check")
+	s.Equal("one", describe("other"), "empty interface matches any check")
+}
+
+// TestReplayNotFound 测试回放不存在的 golden 文件
+func (s *goldenTestSuite) TestReplayNotFound() {
+	defer func() {
+		err, _ := recover().(error)
+		s.Require().Error(err, "replay not found check")
+		s.Contains(err.Error(), "not found, please record it with: GOOM_UPDATE=true go test", "replay not found check")
+	}()
+	mocker.Create().Func(test.Divide).Replay(filepath.Join(s.dir, "none.json"))
+}
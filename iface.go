@@ -9,7 +9,6 @@ import (
 	"unsafe"
 
 	"github.com/tencent/goom/internal/iface"
-	"github.com/tencent/goom/internal/logger"
 )
 
 // IContext 接口 mock 的接收体
@@ -57,11 +56,13 @@ func (m *DefaultInterfaceMocker) String() string {
 // pkgName 包路径
 // iFace 接口变量定义
 func NewDefaultInterfaceMocker(pkgName string, iFace interface{}, ctx *iface.IContext) *DefaultInterfaceMocker {
-	return &DefaultInterfaceMocker{
+	m := &DefaultInterfaceMocker{
 		baseMocker: newBaseMocker(pkgName),
 		ctx:        ctx,
 		iFace:      iFace,
 	}
+	m.self = m
+	return m
 }
 
 // Method 指定 mock 的方法名
@@ -107,8 +108,10 @@ func (m *DefaultInterfaceMocker) When(args ...interface{}) *When {
 	if m.method == "" {
 		panic("method is empty")
 	}
-	if m.when != nil {
-		return m.when.When(args...)
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+	if when := m.currentWhen(); when != nil {
+		return when.When(args...)
 	}
 
 	var (
@@ -119,7 +122,7 @@ func (m *DefaultInterfaceMocker) When(args ...interface{}) *When {
 		panic(err)
 	}
 	m.applyByIFaceMethod(m.ctx, m.iFace, m.method, m.funcDef, m.callback)
-	m.when = when
+	m.setWhen(when)
 	return when
 }
 
@@ -131,8 +134,10 @@ func (m *DefaultInterfaceMocker) Return(returns ...interface{}) *When {
 	if m.method == "" {
 		panic("method is empty")
 	}
-	if m.when != nil {
-		return m.when.Return(returns...)
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+	if when := m.currentWhen(); when != nil {
+		return when.Return(returns...)
 	}
 
 	var (
@@ -143,7 +148,7 @@ func (m *DefaultInterfaceMocker) Return(returns ...interface{}) *When {
 		panic(err)
 	}
 	m.applyByIFaceMethod(m.ctx, m.iFace, m.method, m.funcDef, m.callback)
-	m.when = when
+	m.setWhen(when)
 	return when
 }
 
@@ -155,8 +160,10 @@ func (m *DefaultInterfaceMocker) Returns(returns ...interface{}) *When {
 	if m.method == "" {
 		panic("method is empty")
 	}
-	if m.when != nil {
-		return m.when.Returns(returns...)
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+	if when := m.currentWhen(); when != nil {
+		return when.Returns(returns...)
 	}
 
 	var (
@@ -168,7 +175,7 @@ func (m *DefaultInterfaceMocker) Returns(returns ...interface{}) *When {
 	}
 	when.Returns(returns...)
 	m.applyByIFaceMethod(m.ctx, m.iFace, m.method, m.funcDef, m.callback)
-	m.when = when
+	m.setWhen(when)
 	return when
 }
 
@@ -187,5 +194,5 @@ func (m *DefaultInterfaceMocker) applyByIFaceMethod(ctx *iface.IContext, iFace i
 	method string, imp interface{}, implV iface.PFunc) {
 	imp, implV = interceptDebugInfo(imp, implV, m)
 	m.baseMocker.applyByIFaceMethod(ctx, iFace, method, imp, implV)
-	logger.Consolefc(logger.DebugLevel, "mocker [%s] apply.", logger.Caller(6), m.String())
+	logApply(m, 6)
 }
@@ -0,0 +1,57 @@
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件实现了目标函数被内联时的处理模式和被内联调用位置的报告, 只用于诊断, 不会修改被内联的调用位置。
+package mocker
+
+import (
+	"github.com/tencent/goom/internal/proxy"
+)
+
+// InlineMode 被 mock 的函数被内联时的处理模式
+// 被内联的调用位置不会调用目标函数, 因此 mock 在这些位置不会生效
+type InlineMode int
+
+const (
+	// InlineWarn 在控制台输出被内联的调用位置(默认)
+	InlineWarn InlineMode = proxy.InlineWarn
+	// InlineReport 不输出到控制台, 记录被内联的调用位置, 可以通过 InlinedSites 获取
+	InlineReport InlineMode = proxy.InlineReport
+	// InlineStrict 目标函数被内联时 mock 失败(panic), 错误为 erro.FuncInlined
+	InlineStrict InlineMode = proxy.InlineStrict
+)
+
+// InlinedSite 被 mock 的函数被内联的调用位置, 根据运行时内联树(FUNCDATA_InlTree)解析得到
+type InlinedSite struct {
+	// Func 被内联的函数名
+	Func string
+	// Caller 内联了该函数的调用方函数名
+	Caller string
+	// File 调用位置的源码文件
+	File string
+	// Line 调用位置的源码行号
+	Line int
+	// PC 调用位置的指令地址
+	PC uintptr
+}
+
+// SetInlineMode 设置被 mock 的函数被内联时的处理模式, 对之后的 Apply 生效
+// 无法通过 -gcflags=all=-l 关闭内联时(比如 benchmark), 可以使用 InlineReport 模式检查哪些调用位置 mock 不会生效
+func SetInlineMode(mode InlineMode) {
+	proxy.SetInlineMode(int(mode))
+}
+
+// InlinedSites 获取 InlineReport 模式下记录的被内联的调用位置
+func InlinedSites() []InlinedSite {
+	reported := proxy.InlinedReport()
+	sites := make([]InlinedSite, 0, len(reported))
+	for _, s := range reported {
+		sites = append(sites, InlinedSite{
+			Func:   s.Func,
+			Caller: s.Caller,
+			File:   s.File,
+			Line:   s.Line,
+			PC:     s.CallPC,
+		})
+	}
+	return sites
+}
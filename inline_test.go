@@ -0,0 +1,106 @@
+package mocker_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/tencent/goom"
+)
+
+// TestUnitInlineTestSuite 测试入口
+func TestUnitInlineTestSuite(t *testing.T) {
+	suite.Run(t, new(inlineTestSuite))
+}
+
+// inlineTestSuite 被内联函数的 mock 测试
+type inlineTestSuite struct {
+	suite.Suite
+}
+
+// TearDownTest 恢复默认的处理模式
+func (s *inlineTestSuite) TearDownTest() {
+	mocker.SetInlineMode(mocker.InlineWarn)
+}
+
+// sumValues 可以被内联的函数
+func sumValues(m map[string]int) int {
+	sum := 0
+	for _, v := range m {
+		sum += v
+	}
+	return sum
+}
+
+// sumValuesNoInline 不会被内联的函数
+//
+//go:noinline
+func sumValuesNoInline(m map[string]int) int {
+	sum := 0
+	for _, v := range m {
+		sum += v
+	}
+	return sum
+}
+
+// inlined sumValues 是否被内联, 需要在 InlineReport 模式下 mock 之后调用
+func inlined() bool {
+	return len(reportOf("sumValues")) > 0
+}
+
+// reportOf 获取函数被内联的调用位置
+func reportOf(funcName string) []mocker.InlinedSite {
+	var sites []mocker.InlinedSite
+	for _, site := range mocker.InlinedSites() {
+		if strings.HasSuffix(site.Func, "."+funcName) {
+			sites = append(sites, site)
+		}
+	}
+	return sites
+}
+
+// TestInlineReport 测试记录被内联的调用位置
+func (s *inlineTestSuite) TestInlineReport() {
+	s.Run("success", func() {
+		mocker.SetInlineMode(mocker.InlineReport)
+		mock := mocker.Create()
+		defer mock.Reset()
+
+		mock.Func(sumValues).Return(100)
+		mock.Func(sumValuesNoInline).Return(100)
+		s.Equal(100, sumValuesNoInline(nil), "no inline mock check")
+		s.Equal(0, len(reportOf("sumValuesNoInline")), "no inline report check")
+		if !inlined() {
+			s.T().Skip("inlining is disabled")
+		}
+		s.Equal(1, sumValues(map[string]int{"a": 1}), "inlined call check")
+		for _, site := range reportOf("sumValues") {
+			s.True(strings.HasSuffix(site.File, "inline_test.go"), "file check")
+			s.NotEqual(0, site.Line, "line check")
+		}
+	})
+}
+
+// TestInlineStrict 测试被内联时 mock 失败
+func (s *inlineTestSuite) TestInlineStrict() {
+	s.Run("success", func() {
+		mocker.SetInlineMode(mocker.InlineReport)
+		mock := mocker.Create()
+		defer mock.Reset()
+		mock.Func(sumValues).Return(100)
+		if !inlined() {
+			s.T().Skip("inlining is disabled")
+		}
+		mock.Reset()
+
+		mocker.SetInlineMode(mocker.InlineStrict)
+		defer func() {
+			r := recover()
+			s.NotNil(r, "strict mode check")
+			s.Contains(r, "is inlined at", "error message check")
+			s.Contains(r, "-gcflags=all=-l", "suggestion check")
+		}()
+		mock.Func(sumValues).Return(100)
+	})
+}
@@ -85,6 +85,9 @@ func IsValidPtr(value interface{}) bool {
 
 // PrintInst PrintInst 调试内存指令替换,对原指令、替换之后的指令进行输出对比
 func PrintInst(name string, from uintptr, size int, level int) {
+	if logger.LogLevel < level {
+		return
+	}
 	_, funcName, _ := unexports.FindFuncByPtr(from)
 	instBytes := memory.RawRead(from, size)
 	PrintInstf(fmt.Sprintf("show [%s = %s] inst>>: ", name, funcName), from, instBytes, level)
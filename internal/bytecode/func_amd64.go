@@ -3,6 +3,7 @@ package bytecode
 import (
 	"bytes"
 	"encoding/hex"
+	"fmt"
 	"strings"
 
 	"github.com/tencent/goom/internal/arch/x86asm"
@@ -19,6 +20,10 @@ var funcPrologue = defaultFuncPrologue64
 // CallInsName call 指令名称
 const CallInsName = "CALL"
 
+// genericWrapperSize 泛型包装函数中查找 shape 函数调用的最大指令长度
+// -race 模式下包装函数会先调用 racefuncenter, 指令会更长
+const genericWrapperSize = 128
+
 // GetFuncSize get func binary size
 // not absolutely safe
 func GetFuncSize(mode int, start uintptr, minimal bool) (length int, err error) {
@@ -159,7 +164,8 @@ func GetInnerFunc(mode int, start uintptr) (uintptr, error) {
 
 		if inst.Op.String() == CallInsName {
 			relativeAddr := DecodeRelativeAddr(&inst, code, inst.PCRelOff)
-			return start + (uintptr)(relativeAddr) + uintptr(inst.Len), nil
+			// 相对地址以 call 指令的下一条指令为基准
+			return start + uintptr(curLen) + (uintptr)(relativeAddr) + uintptr(inst.Len), nil
 		}
 
 		curLen = curLen + inst.Len
@@ -169,3 +175,31 @@ func GetInnerFunc(mode int, start uintptr) (uintptr, error) {
 		}
 	}
 }
+
+// GetGenericShape 从泛型函数实例化的包装函数中获取 shape 函数地址和字典地址
+// 包装函数(比如 pkg.Map[int,string])的指令为: LEAQ pkg..dict.Map[int,string](SB), AX; CALL pkg.Map[go.shape.int,...](SB)
+// 加载字典之前的 CALL(比如 -race 模式下的 racefuncenter)不是 shape 函数调用, 跳过继续查找
+// not absolutely safe
+func GetGenericShape(mode int, wrapper uintptr) (shape uintptr, dict uintptr, err error) {
+	code := memory.RawRead(wrapper, defaultInsLen)
+	curLen := 0
+	for curLen < genericWrapperSize {
+		inst, err := x86asm.Decode(code, mode)
+		if err != nil || inst.Len == 0 || (inst.Len == 1 && code[0] == 0xcc) {
+			break
+		}
+
+		pc := wrapper + uintptr(curLen)
+		switch {
+		case inst.Op == x86asm.LEA && inst.PCRel > 0:
+			// 字典是以 RIP 相对地址加载的只读数据
+			dict = pc + uintptr(DecodeRelativeAddr(&inst, code, inst.PCRelOff)) + uintptr(inst.Len)
+		case inst.Op.String() == CallInsName && inst.PCRel > 0 && dict != 0:
+			return pc + uintptr(DecodeRelativeAddr(&inst, code, inst.PCRelOff)) + uintptr(inst.Len), dict, nil
+		}
+
+		curLen = curLen + inst.Len
+		code = memory.RawRead(wrapper+uintptr(curLen), defaultInsLen)
+	}
+	return 0, 0, fmt.Errorf("generic shape func not found in wrapper 0x%x", wrapper)
+}
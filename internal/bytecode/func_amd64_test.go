@@ -0,0 +1,31 @@
+package bytecode
+
+import (
+	"reflect"
+	"testing"
+)
+
+// innerFunc 被调用的函数
+//
+//go:noinline
+func innerFunc() int {
+	return 1
+}
+
+// outerFunc 在栈检查等指令之后调用 innerFunc
+//
+//go:noinline
+func outerFunc() int {
+	return innerFunc() + 1
+}
+
+// TestGetInnerFunc 测试 call 指令不在函数开头时, 相对地址以 call 指令的下一条指令为基准计算
+func TestGetInnerFunc(t *testing.T) {
+	addr, err := GetInnerFunc(64, reflect.ValueOf(outerFunc).Pointer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := reflect.ValueOf(innerFunc).Pointer(); addr != want {
+		t.Fatalf("inner func addr check fail, want: 0x%x, actual: 0x%x", want, addr)
+	}
+}
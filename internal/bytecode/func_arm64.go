@@ -3,6 +3,7 @@ package bytecode
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
 
 	"github.com/tencent/goom/internal/arch/arm64asm"
 	"github.com/tencent/goom/internal/bytecode/memory"
@@ -140,3 +141,9 @@ func GetInnerFunc(mode int, start uintptr) (uintptr, error) {
 		}
 	}
 }
+
+// GetGenericShape 从泛型函数实例化的包装函数中获取 shape 函数地址和字典地址
+// arm64 暂不支持
+func GetGenericShape(_ int, _ uintptr) (shape uintptr, dict uintptr, err error) {
+	return 0, 0, errors.New("mock generic func is not supported on arm64")
+}
@@ -2,6 +2,7 @@ package stub
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/tencent/goom/internal/bytecode/memory"
 )
@@ -53,3 +54,43 @@ func Write(s *Space, data []byte) error {
 		return fmt.Errorf("stub write fail, illegal type: %d", s.typ)
 	}
 }
+
+var (
+	// freeLock 保护 freeHolders
+	freeLock sync.Mutex
+	// freeHolders 归还的 PlaceHolder 区空间, key 为空间的长度
+	freeHolders = make(map[int][]*Space)
+)
+
+// AcquireHolder 从 PlaceHolder 区获取可执行空间, 优先复用 ReleaseHolder 归还的相同长度的空间
+// PlaceHolder 区和代码段相邻, 其中的指令可以使用相对地址跳转到原函数, 适合用作跳板函数
+func AcquireHolder(spaceLen int) (*Space, error) {
+	freeLock.Lock()
+	if free := freeHolders[spaceLen]; len(free) > 0 {
+		s := free[len(free)-1]
+		freeHolders[spaceLen] = free[:len(free)-1]
+		freeLock.Unlock()
+		return s, nil
+	}
+	freeLock.Unlock()
+
+	addr, space, err := acquireFromHolder(spaceLen)
+	if err != nil {
+		return nil, err
+	}
+	return &Space{
+		Addr:  addr,
+		Space: space,
+		typ:   TypeHolder,
+	}, nil
+}
+
+// ReleaseHolder 归还 AcquireHolder 获取的空间, 空间中的指令不能再被执行
+func ReleaseHolder(s *Space) {
+	if s == nil || s.typ != TypeHolder {
+		return
+	}
+	freeLock.Lock()
+	defer freeLock.Unlock()
+	freeHolders[len(*s.Space)] = append(freeHolders[len(*s.Space)], s)
+}
@@ -0,0 +1,27 @@
+package stub
+
+import "testing"
+
+// TestReleaseHolder 测试归还的 PlaceHolder 空间被相同长度的分配复用
+func TestReleaseHolder(t *testing.T) {
+	const spaceLen = 37
+	s, err := AcquireHolder(spaceLen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ReleaseHolder(s)
+	reused, err := AcquireHolder(spaceLen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reused.Addr != s.Addr {
+		t.Fatalf("released space must be reused, expected: 0x%x, actual: 0x%x", s.Addr, reused.Addr)
+	}
+	other, err := AcquireHolder(spaceLen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Addr == s.Addr {
+		t.Fatal("space must not be acquired twice")
+	}
+}
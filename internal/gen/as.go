@@ -0,0 +1,206 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// AsConfig As 辅助代码的生成配置
+type AsConfig struct {
+	// Symbols 未导出函数或方法的完整名称, 格式为: 包路径.函数名 或者 包路径.(*类型名).方法名,
+	// 比如: github.com/xxx/pkg.foo、github.com/xxx/pkg.(*struct2).call
+	Symbols []string
+	// Package 生成代码所在的包名
+	Package string
+	// Name 生成的辅助代码名称, 为空时使用函数名(方法则使用类型名+方法名), 只能在 Symbols 只有一个时指定
+	Name string
+}
+
+// asTarget 需要生成 As 辅助代码的函数或方法
+type asTarget struct {
+	// symbol 完整名称
+	symbol string
+	// pkgPath 包路径
+	pkgPath string
+	// funcName 传递给 ExportFunc 的名称, 比如: foo、(*struct2).call
+	funcName string
+	// name 生成的辅助代码名称
+	name string
+	// fn 函数或方法
+	fn *types.Func
+}
+
+// GenAs 生成未导出函数(或方法)的类型安全的 As 辅助代码
+// 每个函数生成: 和原函数签名一致的 mocker 类型、获取 mocker 的 as 函数、用于调用原函数的 origin 变量
+// 无法在其它包中引用的参数类型(包括方法的接收体)会生成内存布局一致的 fake 结构体
+func GenAs(cfg *AsConfig) ([]byte, error) {
+	if cfg.Package == "" {
+		return nil, fmt.Errorf("package name of the generated code is empty")
+	}
+	if len(cfg.Symbols) == 0 {
+		return nil, fmt.Errorf("symbol is empty")
+	}
+	if cfg.Name != "" && len(cfg.Symbols) > 1 {
+		return nil, fmt.Errorf("name can only be specified with one symbol")
+	}
+
+	r := newRenderer()
+	mockerPkg := r.importName("github.com/tencent/goom", "mocker")
+	var body bytes.Buffer
+	for _, symbol := range cfg.Symbols {
+		target, err := lookupFunc(symbol)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Name != "" {
+			target.name = cfg.Name
+		}
+		code, err := r.asHelper(mockerPkg, target)
+		if err != nil {
+			return nil, fmt.Errorf("generate helper of %s error: %w", symbol, err)
+		}
+		body.WriteString(code)
+	}
+	// 为 fake 结构体生成内存布局的检查
+	if len(r.fakes) > 0 {
+		argPkg := r.importName("github.com/tencent/goom/arg", "arg")
+		body.WriteString(r.layoutChecks(argPkg))
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, header, "gen-as "+strings.Join(cfg.Symbols, " "))
+	fmt.Fprintf(&buf, "\npackage %s\n\n%s\n", cfg.Package, r.importDecl())
+	for _, decl := range r.decls {
+		buf.WriteString(decl + "\n")
+	}
+	buf.Write(body.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code error: %w\n%s", err, buf.String())
+	}
+	return src, nil
+}
+
+// asHelper 生成一个函数的 As 辅助代码
+func (r *renderer) asHelper(mockerPkg string, target *asTarget) (string, error) {
+	sig := target.fn.Type().(*types.Signature)
+	funcType, err := r.renderSignature("func", sig)
+	if err != nil {
+		return "", err
+	}
+	if recv := sig.Recv(); recv != nil {
+		// 方法的第一个参数为接收体
+		recvType, err := r.render(recv.Type())
+		if err != nil {
+			return "", fmt.Errorf("receiver: %w", err)
+		}
+		if sig.Params().Len() > 0 {
+			recvType += ", "
+		}
+		funcType = "func(" + recvType + strings.TrimPrefix(funcType, "func(")
+	}
+
+	var (
+		asFunc     = r.uniqueName("as" + upperFirst(target.name))
+		mockerType = r.uniqueName(lowerFirst(target.name) + "Mocker")
+		originVar  = r.uniqueName("origin" + upperFirst(target.name))
+		desc       = target.symbol
+	)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// %s %s 的原函数, Apply 之后可以通过它调用原函数\n"+
+		"var %s %s\n\n", originVar, desc, originVar, funcType)
+	fmt.Fprintf(&buf, "// %s %s 的 mocker, 回调函数的签名和原函数一致\n"+
+		"type %s struct {\n\t%s.ExportedMocker\n}\n\n", mockerType, desc, mockerType, mockerPkg)
+	fmt.Fprintf(&buf, "// %s 将未导出的 %s 转换为导出函数的 mocker\n"+
+		"func %s(b *%s.Builder) *%s {\n"+
+		"\treturn &%s{b.ExportPkgFunc(%q, %q).As((%s)(nil))}\n}\n\n",
+		asFunc, desc, asFunc, mockerPkg, mockerType, mockerType, target.pkgPath, target.funcName, funcType)
+	fmt.Fprintf(&buf, "// Apply 指定 mock 执行的回调函数, 回调函数中可以通过 %s 调用原函数\n"+
+		"func (m *%s) Apply(imp %s) {\n"+
+		"\tm.ExportedMocker.Origin(&%s).Apply(imp)\n}\n\n",
+		originVar, mockerType, funcType, originVar)
+	return buf.String(), nil
+}
+
+// layoutChecks 生成在 init 时检查所有 fake 结构体内存布局的代码
+func (r *renderer) layoutChecks(argPkg string) string {
+	objs := make([]*types.TypeName, 0, len(r.fakes))
+	for obj := range r.fakes {
+		objs = append(objs, obj)
+	}
+	sort.Slice(objs, func(i, j int) bool {
+		return r.fakes[objs[i]] < r.fakes[objs[j]]
+	})
+	var buf bytes.Buffer
+	for _, obj := range objs {
+		buf.WriteString(layoutCheck(r, argPkg, r.fakes[obj], obj, obj.Type().Underlying().(*types.Struct)))
+	}
+	return buf.String()
+}
+
+// lookupFunc 查找包中的函数或方法
+// symbol 完整名称, 格式为: 包路径.函数名 或者 包路径.(*类型名).方法名、包路径.类型名.方法名
+func lookupFunc(symbol string) (*asTarget, error) {
+	pkgPath, name, err := splitSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	target := &asTarget{symbol: symbol, pkgPath: pkgPath, funcName: name}
+	dot := strings.LastIndex(name, ".")
+	if dot < 0 {
+		obj, err := lookup(symbol)
+		if err != nil {
+			return nil, err
+		}
+		fn, ok := obj.(*types.Func)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a func", symbol)
+		}
+		target.fn, target.name = fn, fn.Name()
+		return target, nil
+	}
+
+	recvName, methodName := name[:dot], name[dot+1:]
+	pointer := strings.HasPrefix(recvName, "(*") && strings.HasSuffix(recvName, ")")
+	typeName := strings.TrimSuffix(strings.TrimPrefix(recvName, "(*"), ")")
+	obj, err := lookup(pkgPath + "." + typeName)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := obj.(*types.TypeName); !ok {
+		return nil, fmt.Errorf("%s.%s is not a type", pkgPath, typeName)
+	}
+	found, _, _ := types.LookupFieldOrMethod(obj.Type(), true, obj.Pkg(), methodName)
+	fn, ok := found.(*types.Func)
+	if !ok {
+		return nil, fmt.Errorf("method %s not found in %s.%s", methodName, pkgPath, recvName)
+	}
+	// 嵌入字段的方法和接收体不一致的方法没有对应的函数符号
+	recv := fn.Type().(*types.Signature).Recv().Type()
+	elem, pointerRecv := recv.(*types.Pointer)
+	if pointerRecv {
+		recv = elem.Elem()
+	}
+	if !types.Identical(recv, obj.Type()) || pointerRecv != pointer {
+		return nil, fmt.Errorf("receiver of %s is %s, please check the symbol",
+			symbol, fn.Type().(*types.Signature).Recv().Type())
+	}
+	target.fn, target.name = fn, upperFirst(typeName)+upperFirst(methodName)
+	return target, nil
+}
+
+// lowerFirst 首字母小写
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	runes := []rune(s)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,43 @@
+package gen
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestGenAs 测试生成的 As 辅助代码可以通过类型检查
+func TestGenAs(t *testing.T) {
+	src, err := GenAs(&AsConfig{
+		Symbols: []string{"github.com/tencent/goom/test.foo", "github.com/tencent/goom/test.(*fake).call"},
+		Package: "fake",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := typeCheck(t, src)
+	for _, name := range []string{"asFoo", "originFoo", "fooMocker", "asFakeCall", "originFakeCall", "fakeFake"} {
+		if pkg.Scope().Lookup(name) == nil {
+			t.Fatalf("%s not found:\n%s", name, src)
+		}
+	}
+	if sig := pkg.Scope().Lookup("originFakeCall").Type().String(); sig != "func(*fake.fakeFake, int) int" {
+		t.Fatalf("signature error: %s", sig)
+	}
+	if !strings.Contains(string(src), "arg.CheckFake(fakeFake{}") {
+		t.Fatalf("layout check not found:\n%s", src)
+	}
+}
+
+// TestGenAsError 测试生成 As 辅助代码的异常
+func TestGenAsError(t *testing.T) {
+	for _, symbol := range []string{"github.com/tencent/goom/test.fake", "github.com/tencent/goom/test.notExist",
+		"github.com/tencent/goom/test.(*fake).notExist", "github.com/tencent/goom/test.fake.call"} {
+		if _, err := GenAs(&AsConfig{Symbols: []string{symbol}, Package: "fake"}); err == nil {
+			t.Fatalf("%s must return error", symbol)
+		}
+	}
+	if _, err := GenAs(&AsConfig{Symbols: []string{"github.com/tencent/goom/test.foo",
+		"github.com/tencent/goom/test.(*fake).call"}, Package: "fake", Name: "foo"}); err == nil {
+		t.Fatal("name with multiple symbols must return error")
+	}
+}
@@ -0,0 +1,133 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/build"
+	"go/format"
+	"go/types"
+	"strings"
+)
+
+// FakeConfig fake 结构体的生成配置
+type FakeConfig struct {
+	// Symbol 原结构体的完整名称, 格式为: 包路径.类型名, 比如: github.com/xxx/pkg.struct2
+	Symbol string
+	// Package 生成代码所在的包名
+	Package string
+	// Name fake 结构体名称, 为空时使用 fake + 原结构体名称, 比如: fakeStruct2
+	Name string
+}
+
+// GenFake 生成和原结构体内存布局一致的 fake 结构体代码
+// 无法在其它包中引用的字段类型会递归地生成 fake 结构体, 生成的代码在 init 时检查 fake 结构体的内存布局,
+// 原结构体为未导出类型时 init 无法发现原结构体的变化, 需要在 CI 中使用 goom gen-fake -check 检查
+func GenFake(cfg *FakeConfig) ([]byte, error) {
+	if cfg.Package == "" {
+		return nil, fmt.Errorf("package name of the generated code is empty")
+	}
+	obj, err := lookup(cfg.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	typeName, ok := obj.(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a type", cfg.Symbol)
+	}
+	st, ok := typeName.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct", cfg.Symbol)
+	}
+
+	r := newRenderer()
+	argPkg := r.importName("github.com/tencent/goom/arg", "arg")
+	name, err := r.fakeStruct(typeName, st, cfg.Name)
+	if err != nil {
+		return nil, fmt.Errorf("generate fake of %s error: %w", cfg.Symbol, err)
+	}
+	check := layoutCheck(r, argPkg, name, typeName, st)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, header, "gen-fake "+cfg.Symbol)
+	fmt.Fprintf(&buf, "\npackage %s\n\n%s\n", cfg.Package, r.importDecl())
+	for _, decl := range r.decls {
+		buf.WriteString(decl + "\n")
+	}
+	buf.WriteString(check)
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code error: %w\n%s", err, buf.String())
+	}
+	return src, nil
+}
+
+// layoutArches 生成内存布局常量的 GOARCH, 即 goom 支持的 CPU 架构
+var layoutArches = []string{"amd64", "arm64"}
+
+// layoutCheck 生成在 init 时检查 fake 结构体内存布局的代码
+// 原结构体可以在其它包中引用时(导出类型), 直接和原结构体的内存布局比较, 原结构体变化时 init 会 panic;
+// 否则只能和生成代码时计算的各个 GOARCH 的内存布局常量比较, 原结构体变化时不会被发现,
+// 需要在 CI 中使用 goom gen-fake -check 检查生成的代码是否是最新的
+func layoutCheck(r *renderer, argPkg, name string, obj *types.TypeName, st *types.Struct) string {
+	if obj.Exported() {
+		pkg := r.importName(obj.Pkg().Path(), obj.Pkg().Name())
+		return fmt.Sprintf("func init() {\n"+
+			"\t// 检查和 %s 的内存布局是否一致\n"+
+			"\t%s.CheckFakeOf(%s{}, %s.%s{})\n}\n",
+			qualifiedName(obj), argPkg, name, pkg, obj.Name())
+	}
+
+	arches := layoutArches
+	if !contains(arches, build.Default.GOARCH) {
+		arches = append(arches, build.Default.GOARCH)
+	}
+	var (
+		cases    []string
+		archesOf = make(map[string][]string)
+	)
+	for _, arch := range arches {
+		args := layoutArgs(arch, st)
+		if _, ok := archesOf[args]; !ok {
+			cases = append(cases, args)
+		}
+		archesOf[args] = append(archesOf[args], fmt.Sprintf("%q", arch))
+	}
+
+	runtimePkg := r.importName("runtime", "runtime")
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "func init() {\n"+
+		"\t// %s 在生成代码时的内存布局: size, align, offsets of fields\n"+
+		"\t// 原结构体为未导出类型, 无法在 init 时比较, 请在 CI 中使用 goom gen-fake -check 检查生成的代码是否是最新的\n"+
+		"\tswitch %s.GOARCH {\n", qualifiedName(obj), runtimePkg)
+	for _, args := range cases {
+		fmt.Fprintf(&buf, "\tcase %s:\n\t\t%s.CheckFake(%s{}, %q, %s)\n",
+			strings.Join(archesOf[args], ", "), argPkg, name, qualifiedName(obj), args)
+	}
+	buf.WriteString("\t}\n}\n")
+	return buf.String()
+}
+
+// layoutArgs 结构体在 GOARCH 为 arch 时的大小、对齐和各个字段的偏移
+func layoutArgs(arch string, st *types.Struct) string {
+	sizes := types.SizesFor("gc", arch)
+	fields := make([]*types.Var, st.NumFields())
+	for i := range fields {
+		fields[i] = st.Field(i)
+	}
+	args := []string{fmt.Sprint(sizes.Sizeof(st)), fmt.Sprint(sizes.Alignof(st))}
+	for _, offset := range sizes.Offsetsof(fields) {
+		args = append(args, fmt.Sprint(offset))
+	}
+	return strings.Join(args, ", ")
+}
+
+// contains 字符串列表是否包含 s
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
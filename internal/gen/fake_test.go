@@ -0,0 +1,117 @@
+package gen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+// TestGenFake 测试生成的 fake 结构体和原结构体的内存布局一致
+func TestGenFake(t *testing.T) {
+	src, err := GenFake(&FakeConfig{Symbol: "github.com/tencent/goom/test.record", Package: "fake"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(src), "// Code generated by goom gen-fake") {
+		t.Fatalf("header error:\n%s", src)
+	}
+
+	pkg := typeCheck(t, src)
+	origin, err := lookup("github.com/tencent/goom/test.record")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := pkg.Scope().Lookup("fakeRecord")
+	if fake == nil {
+		t.Fatalf("fakeRecord not found:\n%s", src)
+	}
+	assertLayout(t, origin.Type().Underlying().(*types.Struct), fake.Type().Underlying().(*types.Struct))
+}
+
+// TestGenFakeLayoutCheck 测试生成的内存布局检查: 导出类型和原结构体比较, 未导出类型按 GOARCH 和常量比较
+func TestGenFakeLayoutCheck(t *testing.T) {
+	src, err := GenFake(&FakeConfig{Symbol: "github.com/tencent/goom/test.Account", Package: "fake"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	typeCheck(t, src)
+	if !strings.Contains(string(src), "arg.CheckFakeOf(fakeAccount{}, test.Account{})") {
+		t.Fatalf("exported origin check error:\n%s", src)
+	}
+
+	src, err = GenFake(&FakeConfig{Symbol: "github.com/tencent/goom/test.record", Package: "fake"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, arch := range layoutArches {
+		if !strings.Contains(string(src), fmt.Sprintf("%q", arch)) {
+			t.Fatalf("layout of %s not found:\n%s", arch, src)
+		}
+	}
+	if !strings.Contains(string(src), "switch runtime.GOARCH {") {
+		t.Fatalf("unexported origin check error:\n%s", src)
+	}
+}
+
+// TestGenFakeError 测试生成 fake 结构体的异常
+func TestGenFakeError(t *testing.T) {
+	for _, symbol := range []string{"github.com/tencent/goom/test.Foo", "github.com/tencent/goom/test.notExist",
+		"github.com/tencent/goom/test"} {
+		if _, err := GenFake(&FakeConfig{Symbol: symbol, Package: "fake"}); err == nil {
+			t.Fatalf("%s must return error", symbol)
+		}
+	}
+}
+
+// typeCheck 对生成的代码进行类型检查
+func typeCheck(t *testing.T, src []byte) *types.Package {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "fake_gen.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: sourceImporter}
+	pkg, err := conf.Check("fake", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatalf("type check error: %v\n%s", err, src)
+	}
+	return pkg
+}
+
+// assertLayout 检查两个结构体的内存布局一致
+func assertLayout(t *testing.T, origin, fake *types.Struct) {
+	sizes := types.SizesFor("gc", build.Default.GOARCH)
+	if sizes.Sizeof(origin) != sizes.Sizeof(fake) || sizes.Alignof(origin) != sizes.Alignof(fake) {
+		t.Fatalf("size or align mismatch: %s != %s", origin, fake)
+	}
+	if origin.NumFields() != fake.NumFields() {
+		t.Fatalf("field count mismatch: %s != %s", origin, fake)
+	}
+	originFields, fakeFields := make([]*types.Var, origin.NumFields()), make([]*types.Var, fake.NumFields())
+	for i := range originFields {
+		originFields[i], fakeFields[i] = origin.Field(i), fake.Field(i)
+	}
+	originOffsets, fakeOffsets := sizes.Offsetsof(originFields), sizes.Offsetsof(fakeFields)
+	for i := range originOffsets {
+		if originOffsets[i] != fakeOffsets[i] ||
+			sizes.Sizeof(originFields[i].Type()) != sizes.Sizeof(fakeFields[i].Type()) {
+			t.Fatalf("field %s layout mismatch", originFields[i].Name())
+		}
+	}
+}
+
+// TestSplitSymbol 测试符号名称拆分
+func TestSplitSymbol(t *testing.T) {
+	pkgPath, name, err := splitSymbol("github.com/xxx/pkg.(*struct2).call")
+	if err != nil || pkgPath != "github.com/xxx/pkg" || name != "(*struct2).call" {
+		t.Fatalf("split error: %s %s %v", pkgPath, name, err)
+	}
+	if _, _, err := splitSymbol("github.com/xxx/pkg"); err == nil {
+		t.Fatal("split must return error")
+	}
+}
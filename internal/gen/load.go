@@ -0,0 +1,57 @@
+// Package gen 实现了 goom 命令行工具的代码生成, 基于 go/types 从源码中读取类型和函数签名
+package gen
+
+import (
+	"fmt"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// header 生成代码的文件头, 符合 go 生成代码的约定, 以便 lint 等工具识别
+const header = "// Code generated by goom %s; DO NOT EDIT.\n"
+
+// sourceImporter 从源码加载包的类型信息, 已经加载的包会被缓存
+var sourceImporter = importer.ForCompiler(token.NewFileSet(), "source", nil)
+
+// loadPackage 从源码加载包的类型信息
+func loadPackage(path string) (*types.Package, error) {
+	pkg, err := sourceImporter.Import(path)
+	if err != nil {
+		return nil, fmt.Errorf("load package %s error: %w", path, err)
+	}
+	return pkg, nil
+}
+
+// lookup 查找包中的符号
+// symbol 符号的完整名称, 格式为: 包路径.名称, 比如: github.com/xxx/pkg.struct2
+func lookup(symbol string) (types.Object, error) {
+	pkgPath, name, err := splitSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := loadPackage(pkgPath)
+	if err != nil {
+		return nil, err
+	}
+	obj := pkg.Scope().Lookup(name)
+	if obj == nil {
+		return nil, fmt.Errorf("%s not found in package %s", name, pkgPath)
+	}
+	return obj, nil
+}
+
+// splitSymbol 将符号的完整名称拆分为包路径和名称
+func splitSymbol(symbol string) (pkgPath string, name string, err error) {
+	slash := strings.LastIndex(symbol, "/")
+	dot := strings.Index(symbol[slash+1:], ".")
+	if dot < 0 {
+		return "", "", fmt.Errorf("illegal symbol %s, must be: package path.name", symbol)
+	}
+	dot += slash + 1
+	if dot == 0 || dot == len(symbol)-1 {
+		return "", "", fmt.Errorf("illegal symbol %s, must be: package path.name", symbol)
+	}
+	return symbol[:dot], symbol[dot+1:], nil
+}
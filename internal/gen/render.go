@@ -0,0 +1,295 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// renderer 将 go/types 中的类型渲染为生成代码中的类型表达式, 并记录需要引入的包;
+// 无法在其它包中引用的类型(未导出类型、internal 包中的类型等)会被替换为内存布局一致的 fake 结构体
+type renderer struct {
+	// imports 引入的包, key 为包路径, value 为包名
+	imports map[string]string
+	// fakes 已经生成的 fake 结构体名称, key 为原类型
+	fakes map[*types.TypeName]string
+	// used 已经使用的名称, 包括包名和 fake 结构体名称
+	used map[string]bool
+	// decls 生成的 fake 结构体声明
+	decls []string
+}
+
+// newRenderer 创建 renderer
+func newRenderer() *renderer {
+	return &renderer{
+		imports: make(map[string]string),
+		fakes:   make(map[*types.TypeName]string),
+		used:    make(map[string]bool),
+	}
+}
+
+// importName 引入包, 返回包名; 包名冲突时使用别名
+func (r *renderer) importName(path, name string) string {
+	if alias, ok := r.imports[path]; ok {
+		return alias
+	}
+	alias := name
+	for i := 2; r.used[alias]; i++ {
+		alias = name + strconv.Itoa(i)
+	}
+	r.used[alias] = true
+	r.imports[path] = alias
+	return alias
+}
+
+// importDecl 生成 import 声明
+func (r *renderer) importDecl() string {
+	if len(r.imports) == 0 {
+		return ""
+	}
+	// 标准库的包在前, 其它包在后
+	var std, others []string
+	for path := range r.imports {
+		if strings.Contains(strings.SplitN(path, "/", 2)[0], ".") {
+			others = append(others, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(others)
+
+	var buf bytes.Buffer
+	buf.WriteString("import (\n")
+	for i, paths := range [][]string{std, others} {
+		if i > 0 && len(std) > 0 && len(others) > 0 {
+			buf.WriteString("\n")
+		}
+		for _, path := range paths {
+			alias := r.imports[path]
+			if path == alias || strings.HasSuffix(path, "/"+alias) {
+				fmt.Fprintf(&buf, "\t%q\n", path)
+			} else {
+				fmt.Fprintf(&buf, "\t%s %q\n", alias, path)
+			}
+		}
+	}
+	buf.WriteString(")\n")
+	return buf.String()
+}
+
+// uniqueName 获取未被使用的名称
+func (r *renderer) uniqueName(name string) string {
+	unique := name
+	for i := 2; r.used[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	r.used[unique] = true
+	return unique
+}
+
+// render 渲染类型表达式
+func (r *renderer) render(t types.Type) (string, error) {
+	switch t := t.(type) {
+	case *types.TypeParam:
+		return "", fmt.Errorf("type parameter %s is not supported", t)
+	case *types.Basic:
+		if t.Kind() == types.UnsafePointer {
+			return r.importName("unsafe", "unsafe") + ".Pointer", nil
+		}
+		return t.Name(), nil
+	case interface{ Obj() *types.TypeName }:
+		// *types.Named 或者 *types.Alias
+		return r.renderNamed(t.(types.Type), t.Obj())
+	case *types.Pointer:
+		elem, err := r.render(t.Elem())
+		return "*" + elem, err
+	case *types.Slice:
+		elem, err := r.render(t.Elem())
+		return "[]" + elem, err
+	case *types.Array:
+		elem, err := r.render(t.Elem())
+		return fmt.Sprintf("[%d]%s", t.Len(), elem), err
+	case *types.Map:
+		key, err := r.render(t.Key())
+		if err != nil {
+			return "", err
+		}
+		elem, err := r.render(t.Elem())
+		return fmt.Sprintf("map[%s]%s", key, elem), err
+	case *types.Chan:
+		elem, err := r.render(t.Elem())
+		return chanPrefix(t.Dir()) + elem, err
+	case *types.Signature:
+		return r.renderSignature("func", t)
+	case *types.Struct:
+		return r.renderStruct(t)
+	case *types.Interface:
+		return r.renderInterface(t)
+	default:
+		return "", fmt.Errorf("type %s is not supported", t)
+	}
+}
+
+// renderNamed 渲染命名类型, 无法在其它包中引用时渲染为内存布局一致的类型
+func (r *renderer) renderNamed(t types.Type, obj *types.TypeName) (string, error) {
+	if obj.Pkg() == nil {
+		// 内置类型, 比如 error
+		return obj.Name(), nil
+	}
+	if importable(obj) {
+		return types.TypeString(t, func(p *types.Package) string {
+			return r.importName(p.Path(), p.Name())
+		}), nil
+	}
+	if st, ok := t.Underlying().(*types.Struct); ok {
+		return r.fakeStruct(obj, st, "")
+	}
+	return r.render(t.Underlying())
+}
+
+// fakeStruct 生成和结构体内存布局一致的 fake 结构体声明, 返回 fake 结构体名称
+// name fake 结构体名称, 为空时使用 fake + 原结构体名称
+func (r *renderer) fakeStruct(obj *types.TypeName, st *types.Struct, name string) (string, error) {
+	if fake, ok := r.fakes[obj]; ok {
+		return fake, nil
+	}
+	if name == "" {
+		name = "fake" + upperFirst(obj.Name())
+	}
+	name = r.uniqueName(name)
+	// 先登记名称和声明的位置, 以支持递归引用自身的结构体, 并且外层结构体的声明在前
+	r.fakes[obj] = name
+	index := len(r.decls)
+	r.decls = append(r.decls, "")
+
+	body, err := r.renderStruct(st)
+	if err != nil {
+		return "", err
+	}
+	r.decls[index] = fmt.Sprintf("// %s 和 %s 内存布局一致的 fake 结构体\ntype %s %s\n",
+		name, qualifiedName(obj), name, body)
+	return name, nil
+}
+
+// renderStruct 渲染结构体类型, 嵌入字段渲染为普通字段
+func (r *renderer) renderStruct(st *types.Struct) (string, error) {
+	if st.NumFields() == 0 {
+		return "struct{}", nil
+	}
+	var buf bytes.Buffer
+	buf.WriteString("struct {\n")
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		typ, err := r.render(f.Type())
+		if err != nil {
+			return "", fmt.Errorf("field %s: %w", f.Name(), err)
+		}
+		fmt.Fprintf(&buf, "\t%s %s\n", f.Name(), typ)
+	}
+	buf.WriteString("}")
+	return buf.String(), nil
+}
+
+// renderInterface 渲染接口类型
+// 含有无法引用的方法的接口渲染为两个指针(itab 和 data)的结构体, 和接口的内存布局以及寄存器 ABI 的传参方式一致
+func (r *renderer) renderInterface(it *types.Interface) (string, error) {
+	if it.Empty() {
+		return "interface{}", nil
+	}
+	var buf bytes.Buffer
+	buf.WriteString("interface {\n")
+	for i := 0; i < it.NumMethods(); i++ {
+		m := it.Method(i)
+		if !m.Exported() {
+			return "struct {\n\ttab, data " + r.importName("unsafe", "unsafe") + ".Pointer\n}", nil
+		}
+		sig, err := r.renderSignature(m.Name(), m.Type().(*types.Signature))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&buf, "\t%s\n", sig)
+	}
+	buf.WriteString("}")
+	return buf.String(), nil
+}
+
+// renderSignature 渲染函数签名, prefix 为 func 或方法名
+func (r *renderer) renderSignature(prefix string, sig *types.Signature) (string, error) {
+	params, err := r.renderTuple(sig.Params(), sig.Variadic())
+	if err != nil {
+		return "", err
+	}
+	results, err := r.renderTuple(sig.Results(), false)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case sig.Results().Len() == 0:
+		return fmt.Sprintf("%s(%s)", prefix, params), nil
+	case sig.Results().Len() == 1:
+		return fmt.Sprintf("%s(%s) %s", prefix, params, results), nil
+	default:
+		return fmt.Sprintf("%s(%s) (%s)", prefix, params, results), nil
+	}
+}
+
+// renderTuple 渲染参数列表或者返回值列表, 忽略参数名
+func (r *renderer) renderTuple(tuple *types.Tuple, variadic bool) (string, error) {
+	list := make([]string, 0, tuple.Len())
+	for i := 0; i < tuple.Len(); i++ {
+		t := tuple.At(i).Type()
+		if variadic && i == tuple.Len()-1 {
+			elem, err := r.render(t.(*types.Slice).Elem())
+			if err != nil {
+				return "", err
+			}
+			list = append(list, "..."+elem)
+			continue
+		}
+		typ, err := r.render(t)
+		if err != nil {
+			return "", err
+		}
+		list = append(list, typ)
+	}
+	return strings.Join(list, ", "), nil
+}
+
+// importable 类型是否可以在其它包中引用
+func importable(obj *types.TypeName) bool {
+	path := obj.Pkg().Path()
+	return obj.Exported() && obj.Pkg().Name() != "main" &&
+		!strings.HasPrefix(path, "internal/") && !strings.Contains(path, "/internal/")
+}
+
+// qualifiedName 类型的完整名称
+func qualifiedName(obj types.Object) string {
+	return obj.Pkg().Path() + "." + obj.Name()
+}
+
+// chanPrefix chan 类型的前缀
+func chanPrefix(dir types.ChanDir) string {
+	switch dir {
+	case types.SendOnly:
+		return "chan<- "
+	case types.RecvOnly:
+		return "<-chan "
+	default:
+		return "chan "
+	}
+}
+
+// upperFirst 首字母大写
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	runes := []rune(s)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
@@ -8,8 +8,7 @@ const (
 	MaxMethod = 999
 )
 
-// Iface 接口结构
-// TODO 不同 go 版本兼容
+// Iface 接口结构, 和 runtime.iface 保持一致(go1.11 以来布局未变化)
 type Iface struct {
 	// Tab 为接口类型的方法表
 	Tab *Itab
@@ -17,8 +16,7 @@ type Iface struct {
 	Data unsafe.Pointer
 }
 
-// Itab keeps sync with runtime.itab
-// TODO 不同 go 版本兼容
+// Itab keeps sync with runtime.itab(go1.22 起为 abi.ITab, 字段对齐后布局未变化)
 // 注意: 最多兼容99个方法数量以内的接口
 type Itab struct {
 	// nolint
@@ -1,22 +1,9 @@
-//go:build go1.18
-// +build go1.18
+//go:build go1.18 && !go1.20
+// +build go1.18,!go1.20
 
 // Package hack 对 go 系统包的 hack, 包含一些系统结构体的 copy，需要和不同的 go 版本保持同步
 package hack
 
-import (
-	"unsafe"
-	_ "unsafe" // 匿名引入
-)
-
-// InterceptCallerSkip 拦截器 callerskip
-const InterceptCallerSkip = 5
-
-// Firstmoduledata keep async with runtime.Firstmoduledata
-//
-//go:linkname Firstmoduledata runtime.firstmoduledata
-var Firstmoduledata Moduledata
-
 // nolint
 // Moduledata keep async with runtime.Moduledata
 type Moduledata struct {
@@ -62,42 +49,3 @@ type Moduledata struct {
 
 	Next *Moduledata
 }
-
-// Functab Functab
-type Functab struct {
-	Entry   uint32
-	Funcoff uint32
-}
-
-// nolint
-type textsect struct {
-	// nolint
-	vaddr    uintptr // prelinked section vaddr
-	length   uintptr // section length
-	baseaddr uintptr // relocated section address
-}
-
-// Bitvector Bitvector
-type Bitvector struct {
-	// nolint
-	n int32 // # of bits
-	// nolint
-	bytedata *uint8
-}
-
-// nolint
-type typeOff int32 // offset to an *rtype
-
-// Func convenience struct for modifying the underlying code pointer of a function
-// value. The actual struct has other values, but always starts with a code
-// pointer.
-type Func struct {
-	CodePtr uintptr
-}
-
-// Value reflect.Value
-type Value struct {
-	Typ  *uintptr
-	Ptr  unsafe.Pointer
-	Flag uintptr
-}
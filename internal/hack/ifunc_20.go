@@ -0,0 +1,52 @@
+//go:build go1.20 && !go1.21
+// +build go1.20,!go1.21
+
+// Package hack 对 go 系统包的 hack, 包含一些系统结构体的 copy，需要和不同的 go 版本保持同步
+package hack
+
+// nolint
+// Moduledata keep async with runtime.Moduledata
+type Moduledata struct {
+	pcHeader     *uintptr
+	funcnametab  []byte
+	cutab        []uint32
+	filetab      []byte
+	pctab        []byte
+	Pclntable    []byte
+	Ftab         []Functab
+	findfunctab  uintptr
+	minpc, maxpc uintptr
+
+	text, etext           uintptr
+	noptrdata, enoptrdata uintptr
+	data, edata           uintptr
+	bss, ebss             uintptr
+	noptrbss, enoptrbss   uintptr
+	covctrs, ecovctrs     uintptr
+	end, gcdata, gcbss    uintptr
+	types, etypes         uintptr
+	rodata                uintptr
+	gofunc                uintptr // go.func.*
+
+	textsectmap []textsect
+	typelinks   []int32 // offsets from types
+	itablinks   []*uintptr
+
+	ptab []interface{}
+
+	pluginpath string
+	pkghashes  []interface{}
+
+	modulename   string
+	modulehashes []interface{}
+
+	hasmain uint8 // 1 if module contains the main function, 0 otherwise
+
+	gcdatamask, gcbssmask Bitvector
+
+	_ map[typeOff]*interface{} // typemap: offset to *_rtype in previous module
+
+	_ bool // bad: module failed to load and should be ignored
+
+	Next *Moduledata
+}
@@ -0,0 +1,53 @@
+//go:build go1.21 && !go1.27
+// +build go1.21,!go1.27
+
+// Package hack 对 go 系统包的 hack, 包含一些系统结构体的 copy，需要和不同的 go 版本保持同步
+package hack
+
+// nolint
+// Moduledata keep async with runtime.Moduledata
+type Moduledata struct {
+	pcHeader     *uintptr
+	funcnametab  []byte
+	cutab        []uint32
+	filetab      []byte
+	pctab        []byte
+	Pclntable    []byte
+	Ftab         []Functab
+	findfunctab  uintptr
+	minpc, maxpc uintptr
+
+	text, etext           uintptr
+	noptrdata, enoptrdata uintptr
+	data, edata           uintptr
+	bss, ebss             uintptr
+	noptrbss, enoptrbss   uintptr
+	covctrs, ecovctrs     uintptr
+	end, gcdata, gcbss    uintptr
+	types, etypes         uintptr
+	rodata                uintptr
+	gofunc                uintptr // go.func.*
+
+	textsectmap []textsect
+	typelinks   []int32 // offsets from types
+	itablinks   []*uintptr
+
+	ptab []interface{}
+
+	pluginpath string
+	pkghashes  []interface{}
+
+	inittasks []*uintptr
+
+	modulename   string
+	modulehashes []interface{}
+
+	hasmain uint8 // 1 if module contains the main function, 0 otherwise
+	_       bool  // bad: module failed to load and should be ignored
+
+	gcdatamask, gcbssmask Bitvector
+
+	_ map[typeOff]*interface{} // typemap: offset to *_rtype in previous module
+
+	Next *Moduledata
+}
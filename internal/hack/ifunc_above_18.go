@@ -0,0 +1,85 @@
+//go:build go1.18
+// +build go1.18
+
+// Package hack 对 go 系统包的 hack, 包含一些系统结构体的 copy，需要和不同的 go 版本保持同步
+package hack
+
+import (
+	"unsafe"
+	_ "unsafe" // 匿名引入
+)
+
+// InterceptCallerSkip 拦截器 callerskip
+const InterceptCallerSkip = 5
+
+// Firstmoduledata keep async with runtime.Firstmoduledata
+//
+//go:linkname Firstmoduledata runtime.firstmoduledata
+var Firstmoduledata Moduledata
+
+// Functab Functab
+type Functab struct {
+	Entry   uint32
+	Funcoff uint32
+}
+
+// nolint
+type textsect struct {
+	// nolint
+	vaddr    uintptr // prelinked section vaddr
+	length   uintptr // section length
+	baseaddr uintptr // relocated section address
+}
+
+// Bitvector Bitvector
+type Bitvector struct {
+	// nolint
+	n int32 // # of bits
+	// nolint
+	bytedata *uint8
+}
+
+// nolint
+type typeOff int32 // offset to an *rtype
+
+// Func convenience struct for modifying the underlying code pointer of a function
+// value. The actual struct has other values, but always starts with a code
+// pointer.
+type Func struct {
+	CodePtr uintptr
+}
+
+// Value reflect.Value
+type Value struct {
+	Typ  *uintptr
+	Ptr  unsafe.Pointer
+	Flag uintptr
+}
+
+// FuncName 根据函数名偏移量获取函数的完整名称, 泛型函数的类型参数不会被省略
+func (md *Moduledata) FuncName(nameOff int32) string {
+	if nameOff < 0 || int(nameOff) >= len(md.funcnametab) {
+		return ""
+	}
+	name := md.funcnametab[nameOff:]
+	for i, b := range name {
+		if b == 0 {
+			return string(name[:i])
+		}
+	}
+	return string(name)
+}
+
+// Contains 函数地址是否在当前模块的代码段内
+func (md *Moduledata) Contains(pc uintptr) bool {
+	return md.minpc <= pc && pc < md.maxpc
+}
+
+// FuncNameEquals 函数名偏移量对应的函数名是否和 name 相同
+func (md *Moduledata) FuncNameEquals(nameOff int32, name string) bool {
+	end := int(nameOff) + len(name)
+	if nameOff < 0 || end >= len(md.funcnametab) {
+		return false
+	}
+	return md.funcnametab[end] == 0 && string(md.funcnametab[nameOff:end]) == name
+}
@@ -0,0 +1,92 @@
+//go:build go1.18
+// +build go1.18
+
+package hack
+
+import (
+	"unsafe"
+)
+
+const (
+	// funcNpcdataOffset runtime._func.npcdata 的偏移量
+	funcNpcdataOffset = 28
+	// pcdataInlTreeIndex keep sync with runtime._PCDATA_InlTreeIndex
+	pcdataInlTreeIndex = 2
+	// funcdataInlTree keep sync with runtime._FUNCDATA_InlTree
+	funcdataInlTree = 3
+)
+
+// InlinedCall 函数内联树中的一次内联调用
+type InlinedCall struct {
+	// NameOff 被内联的函数名偏移量
+	NameOff int32
+	// ParentPc 调用位置相对于函数入口的偏移量
+	ParentPc int32
+}
+
+// InlineTree 解析函数的内联树
+// f 为 runtime._func 的地址; 返回内联调用列表
+func (md *Moduledata) InlineTree(f unsafe.Pointer) []InlinedCall {
+	npcdata := *(*uint32)(unsafe.Pointer(uintptr(f) + funcNpcdataOffset))
+	nfuncdata := *(*uint8)(unsafe.Pointer(uintptr(f) + funcNfuncdataOffset))
+	if npcdata <= pcdataInlTreeIndex || nfuncdata <= funcdataInlTree {
+		return nil
+	}
+	pcdataOff := *(*uint32)(unsafe.Pointer(uintptr(f) + funcHeaderSize + pcdataInlTreeIndex*4))
+	funcdataOff := *(*uint32)(unsafe.Pointer(uintptr(f) + funcHeaderSize + uintptr(npcdata)*4 + funcdataInlTree*4))
+	if pcdataOff == 0 || funcdataOff == ^uint32(0) || int(pcdataOff) >= len(md.pctab) {
+		return nil
+	}
+
+	// 内联树没有记录长度, 由 PCDATA_InlTreeIndex 中最大的下标得到
+	maxIndex := maxPcValue(md.pctab[pcdataOff:])
+	if maxIndex < 0 {
+		return nil
+	}
+	gofunc := md.gofunc
+	// gofunc 为只读数据段地址, 不会被 GC 移动
+	tree := unsafe.Add(*(*unsafe.Pointer)(unsafe.Pointer(&gofunc)), funcdataOff)
+	calls := make([]InlinedCall, maxIndex+1)
+	for i := range calls {
+		call := (*inlinedCall)(unsafe.Add(tree, uintptr(i)*unsafe.Sizeof(inlinedCall{})))
+		calls[i] = InlinedCall{NameOff: call.nameOff, ParentPc: call.parentPc}
+	}
+	return calls
+}
+
+// maxPcValue 解析 pcvalue 表, 返回其中最大的值
+// keep sync with runtime.step
+func maxPcValue(p []byte) int32 {
+	var (
+		val      int32 = -1
+		maxIndex int32 = -1
+	)
+	for first := true; len(p) > 0; first = false {
+		n, uvdelta := readVarint(p)
+		if uvdelta == 0 && !first {
+			break
+		}
+		val += int32(-(uvdelta & 1) ^ (uvdelta >> 1))
+		p = p[n:]
+		// 跳过 pc 增量
+		n, _ = readVarint(p)
+		p = p[n:]
+		if val > maxIndex {
+			maxIndex = val
+		}
+	}
+	return maxIndex
+}
+
+// readVarint 读取 varint, 返回读取的字节数和值
+func readVarint(p []byte) (int, uint32) {
+	var v, shift uint32
+	for n, b := range p {
+		v |= uint32(b&0x7F) << (shift & 31)
+		if b&0x80 == 0 {
+			return n + 1, v
+		}
+		shift += 7
+	}
+	return len(p), v
+}
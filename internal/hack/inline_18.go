@@ -0,0 +1,21 @@
+//go:build go1.18 && !go1.20
+// +build go1.18,!go1.20
+
+package hack
+
+// funcHeaderSize runtime._func 的固定长度, 其后为 pcdata 和 funcdata 的偏移量数组
+const funcHeaderSize = 40
+
+// funcNfuncdataOffset runtime._func.nfuncdata 的偏移量
+const funcNfuncdataOffset = 39
+
+// inlinedCall keep sync with runtime.inlinedCall
+type inlinedCall struct {
+	parent   int16 // index of parent in the inltree, or < 0
+	funcID   uint8 // type of the called function
+	_        byte
+	file     int32 // perCU file index for inlined call. See cmd/link:pcln.go
+	line     int32 // line number of the call site
+	nameOff  int32 // offset into pclntab for name of called function
+	parentPc int32 // position of an instruction whose source position is the call site (offset from entry)
+}
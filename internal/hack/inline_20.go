@@ -0,0 +1,19 @@
+//go:build go1.20
+// +build go1.20
+
+package hack
+
+// funcHeaderSize runtime._func 的固定长度, 其后为 pcdata 和 funcdata 的偏移量数组
+const funcHeaderSize = 44
+
+// funcNfuncdataOffset runtime._func.nfuncdata 的偏移量
+const funcNfuncdataOffset = 43
+
+// inlinedCall keep sync with runtime.inlinedCall
+type inlinedCall struct {
+	funcID    uint8 // type of the called function
+	_         [3]byte
+	nameOff   int32 // offset into pclntab for name of called function
+	parentPc  int32 // position of an instruction whose source position is the call site (offset from entry)
+	startLine int32 // line number of start of function (func keyword/TEXT directive)
+}
@@ -0,0 +1,55 @@
+// Package hack 对 go 系统包的 hack, 包含一些系统结构体的 copy，需要和不同的 go 版本保持同步
+package hack
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"unsafe"
+)
+
+// layoutErr 启动时对运行时结构体布局自检的结果
+var layoutErr = checkLayout(&Firstmoduledata)
+
+// CheckLayout 获取运行时结构体布局自检的结果
+// 当前 go 版本的 runtime.moduledata 布局和 hack.Moduledata 不一致时返回错误, 此时不能进行 patch, 否则会破坏内存
+func CheckLayout() error {
+	return layoutErr
+}
+
+// checkLayout 检查 moduledata 的各个字段是否和 runtime 中的值相符
+func checkLayout(md *Moduledata) error {
+	pc := reflect.ValueOf(layoutProbe).Pointer()
+	ptrSize := unsafe.Sizeof(uintptr(0))
+	switch {
+	case len(md.Ftab) == 0 || len(md.Pclntable) == 0:
+		return layoutError("ftab or pclntable is empty")
+	case pc < md.minpc || pc >= md.maxpc:
+		return layoutError(fmt.Sprintf("pc 0x%x out of [minpc 0x%x, maxpc 0x%x)", pc, md.minpc, md.maxpc))
+	case pc < md.text || pc >= md.etext:
+		return layoutError(fmt.Sprintf("pc 0x%x out of [text 0x%x, etext 0x%x)", pc, md.text, md.etext))
+	case md.edata < md.data || md.ebss < md.bss:
+		return layoutError("data or bss section is illegal")
+	case uintptr(md.gcdatamask.n) != (md.edata-md.data)/ptrSize:
+		return layoutError(fmt.Sprintf("gcdatamask %d mismatch data size %d", md.gcdatamask.n, md.edata-md.data))
+	case uintptr(md.gcbssmask.n) != (md.ebss-md.bss)/ptrSize:
+		return layoutError(fmt.Sprintf("gcbssmask %d mismatch bss size %d", md.gcbssmask.n, md.ebss-md.bss))
+	case md.hasmain > 1:
+		return layoutError(fmt.Sprintf("hasmain %d is illegal", md.hasmain))
+	case md.Next != nil:
+		// 启动时还没有加载 plugin, 只有一个 moduledata
+		return layoutError("next moduledata is not nil")
+	}
+	return nil
+}
+
+// layoutError 构造布局自检失败的错误
+func layoutError(reason string) error {
+	return fmt.Errorf("goom does not support the runtime layout of %s: %s, "+
+		"please upgrade goom or report an issue", runtime.Version(), reason)
+}
+
+// layoutProbe 布局自检时用于定位代码段的函数
+//
+//go:noinline
+func layoutProbe() {}
@@ -0,0 +1,22 @@
+package hack
+
+import "testing"
+
+// TestCheckLayout 测试当前 go 版本的运行时结构体布局自检
+func TestCheckLayout(t *testing.T) {
+	if err := CheckLayout(); err != nil {
+		t.Fatalf("check layout fail: %v", err)
+	}
+	if Firstmoduledata.FuncName(-1) != "" {
+		t.Fatal("func name of illegal offset must be empty")
+	}
+}
+
+// TestCheckLayoutMismatch 测试布局不一致时自检失败
+func TestCheckLayoutMismatch(t *testing.T) {
+	md := Firstmoduledata
+	md.gcdatamask.n++
+	if err := checkLayout(&md); err == nil {
+		t.Fatal("check layout must fail when gcdatamask mismatch")
+	}
+}
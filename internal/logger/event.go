@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tencent/goom/logging"
+)
+
+// eventLevel 日志级别-结构化事件级别映射
+var eventLevel = map[int]logging.Level{
+	TraceLevel:    logging.LevelDebug - 4,
+	DebugLevel:    logging.LevelDebug,
+	InfoLevel:     logging.LevelInfo,
+	WarningLevel:  logging.LevelWarn,
+	ErrorLevel:    logging.LevelError,
+	CriticalLevel: logging.LevelError + 4,
+}
+
+// emit 将文本日志同时输出为 logging.Log 类型的结构化事件, 不受 LogLevel 的限制
+func emit(level int, v []interface{}) {
+	if l := eventLevel[level]; logging.Enabled(l) {
+		logging.Emit(l, logging.Log, strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+	}
+}
+
+// emitf 将格式化的文本日志同时输出为 logging.Log 类型的结构化事件, 不受 LogLevel 的限制
+func emitf(level int, format string, a []interface{}) {
+	if l := eventLevel[level]; logging.Enabled(l) {
+		logging.Emit(l, logging.Log, fmt.Sprintf(format, a...))
+	}
+}
@@ -141,6 +141,7 @@ func TraceEnable() bool {
 
 // Trace 打印 trace 日志
 func Trace(v ...interface{}) {
+	emit(TraceLevel, v)
 	if LogLevel >= TraceLevel {
 		_, _ = Logger.Write(layout(TraceLevel, v))
 	}
@@ -148,6 +149,7 @@ func Trace(v ...interface{}) {
 
 // Tracef 打印 trace 日志
 func Tracef(format string, a ...interface{}) {
+	emitf(TraceLevel, format, a)
 	if LogLevel >= TraceLevel {
 		_, _ = Logger.Write(layoutf(TraceLevel, format, nil, a...))
 	}
@@ -160,6 +162,7 @@ func DebugEnable() bool {
 
 // Debug 打印 debug 日志
 func Debug(v ...interface{}) {
+	emit(DebugLevel, v)
 	if LogLevel >= DebugLevel {
 		_, _ = Logger.Write(layout(DebugLevel, v))
 	}
@@ -167,6 +170,7 @@ func Debug(v ...interface{}) {
 
 // Debugf 打印 debug 日志
 func Debugf(format string, a ...interface{}) {
+	emitf(DebugLevel, format, a)
 	if LogLevel >= DebugLevel {
 		_, _ = Logger.Write(layoutf(DebugLevel, format, nil, a...))
 	}
@@ -174,6 +178,7 @@ func Debugf(format string, a ...interface{}) {
 
 // Info 打印 info 日志
 func Info(v ...interface{}) {
+	emit(InfoLevel, v)
 	if LogLevel >= InfoLevel {
 		_, _ = Logger.Write(layout(InfoLevel, v))
 	}
@@ -181,6 +186,7 @@ func Info(v ...interface{}) {
 
 // Infof 打印 info 日志
 func Infof(format string, a ...interface{}) {
+	emitf(InfoLevel, format, a)
 	if LogLevel >= InfoLevel {
 		_, _ = Logger.Write(layoutf(InfoLevel, format, nil, a...))
 	}
@@ -188,6 +194,7 @@ func Infof(format string, a ...interface{}) {
 
 // Warning 打印 warning 日志
 func Warning(v ...interface{}) {
+	emit(WarningLevel, v)
 	if LogLevel >= WarningLevel {
 		line := layout(WarningLevel, v)
 		_, _ = Logger.Write(line)
@@ -197,6 +204,7 @@ func Warning(v ...interface{}) {
 
 // Warningf 打印 warning 日志
 func Warningf(format string, a ...interface{}) {
+	emitf(WarningLevel, format, a)
 	if LogLevel >= WarningLevel {
 		line := layoutf(WarningLevel, format, nil, a...)
 		_, _ = Logger.Write(line)
@@ -206,6 +214,7 @@ func Warningf(format string, a ...interface{}) {
 
 // Important 打印重要的日志
 func Important(v ...interface{}) {
+	emit(InfoLevel, v)
 	line := layout(InfoLevel, v)
 	_, _ = Logger.Write(line)
 	write2Console(line)
@@ -213,6 +222,7 @@ func Important(v ...interface{}) {
 
 // Importantf 打印重要的日志
 func Importantf(format string, a ...interface{}) {
+	emitf(InfoLevel, format, a)
 	line := layoutf(InfoLevel, format, nil, a...)
 	_, _ = Logger.Write(line)
 	write2Console(line)
@@ -220,6 +230,7 @@ func Importantf(format string, a ...interface{}) {
 
 // Error 打印 error 日志
 func Error(v ...interface{}) {
+	emit(ErrorLevel, v)
 	if LogLevel >= ErrorLevel {
 		line := layout(ErrorLevel, v)
 		_, _ = Logger.Write(line)
@@ -229,6 +240,7 @@ func Error(v ...interface{}) {
 
 // Errorf 打印 error 日志
 func Errorf(format string, a ...interface{}) {
+	emitf(ErrorLevel, format, a)
 	if LogLevel >= ErrorLevel {
 		line := layoutf(ErrorLevel, format, nil, a...)
 		_, _ = Logger.Write(line)
@@ -0,0 +1,70 @@
+package patch
+
+import (
+	"reflect"
+
+	"github.com/tencent/goom/internal/bytecode/stub"
+)
+
+// ctxStubs 调用原闭包函数的桩缓存, 由 patchesLock 保护
+var ctxStubs = make(map[ctxStubKey]uintptr)
+
+// ctxStubKey 调用原闭包函数的桩缓存的 key
+type ctxStubKey struct {
+	fixOrigin uintptr
+	ctxReg    int
+}
+
+// CtxTrampoline 对闭包函数进行 patch, 跳板函数通过 Guard.FixOrigin() 分配
+// 跳转到代理函数之前, 将闭包上下文寄存器(即调用方闭包对象的地址)保存到序号为 ctxReg 的整数参数寄存器中,
+// 因此代理函数的参数为闭包函数的参数追加一个 uintptr 类型的上下文参数, 且该参数需要恰好分配到 ctxReg 寄存器
+// originPtr 闭包函数的代码地址
+// replacement 代理函数
+// ctxReg 上下文参数使用的整数参数寄存器序号(按照 ABIInternal 整数参数寄存器的顺序)
+func CtxTrampoline(originPtr uintptr, replacement interface{}, ctxReg int) (*Guard, error) {
+	jump, err := ctxJump(ctxReg)
+	if err != nil {
+		return nil, err
+	}
+	patch := &patch{
+		replacement: replacement,
+
+		replacementValue: reflect.ValueOf(replacement),
+
+		originPtr: originPtr,
+		jump:      jump,
+	}
+
+	if err := patch.unsafePatchPtr(); err != nil {
+		return nil, err
+	}
+	return patch.Guard(), nil
+}
+
+// CtxOriginStub 构造调用原闭包函数的桩函数, 返回桩函数地址
+// 桩函数将序号为 ctxReg 的整数参数寄存器中的上下文恢复到闭包上下文寄存器, 再跳转到跳板函数,
+// 因此桩函数的参数和代理函数一致: 闭包函数的参数追加一个 uintptr 类型的上下文参数
+// fixOrigin 跳板函数地址, 即 Guard.FixOriginFunc()
+// ctxReg 上下文参数使用的整数参数寄存器序号
+func CtxOriginStub(fixOrigin uintptr, ctxReg int) (uintptr, error) {
+	lock()
+	defer unlock()
+
+	key := ctxStubKey{fixOrigin: fixOrigin, ctxReg: ctxReg}
+	if addr, ok := ctxStubs[key]; ok {
+		return addr, nil
+	}
+	data, err := ctxOriginStub(fixOrigin, ctxReg)
+	if err != nil {
+		return 0, err
+	}
+	space, err := stub.Acquire(len(data))
+	if err != nil {
+		return 0, err
+	}
+	if err := stub.Write(space, data); err != nil {
+		return 0, err
+	}
+	ctxStubs[key] = space.Addr
+	return space.Addr, nil
+}
@@ -0,0 +1,52 @@
+package patch
+
+import "fmt"
+
+// intArgRegs ABIInternal 整数参数寄存器的编号, 顺序为: RAX, RBX, RCX, RDI, RSI, R8, R9, R10, R11
+var intArgRegs = []byte{0, 3, 1, 7, 6, 8, 9, 10, 11}
+
+// ctxJump 构造跳转到代理函数的指令: 跳转之前将闭包上下文寄存器 RDX 保存到序号为 ctxReg 的整数参数寄存器
+func ctxJump(ctxReg int) (func(from, to uintptr) []byte, error) {
+	reg, err := intArgReg(ctxReg)
+	if err != nil {
+		return nil, err
+	}
+	// mov reg, rdx
+	save := []byte{0x48 | reg>>3, 0x89, 0xD0 | reg&7}
+	return func(from, to uintptr) []byte {
+		jump := jmpToFunctionValue(from, to)
+		data := make([]byte, 0, len(save)+len(jump))
+		// 第一个字节的 NOP 用于判断是否已经 patch 过, 需要保留在开头
+		data = append(data, jump[0])
+		data = append(data, save...)
+		return append(data, jump[1:]...)
+	}, nil
+}
+
+// ctxOriginStub 构造调用原闭包函数的桩函数指令: 将序号为 ctxReg 的整数参数寄存器恢复到 RDX, 再跳转到跳板函数
+func ctxOriginStub(fixOrigin uintptr, ctxReg int) ([]byte, error) {
+	reg, err := intArgReg(ctxReg)
+	if err != nil {
+		return nil, err
+	}
+	return []byte{
+		0x48 | reg>>3, 0x8B, 0xD0 | reg&7, // mov rdx, reg
+		0xFF, 0x25, 0x00, 0x00, 0x00, 0x00, // jmp QWORD PTR [rip]
+		byte(fixOrigin),
+		byte(fixOrigin >> 8),
+		byte(fixOrigin >> 16),
+		byte(fixOrigin >> 24),
+		byte(fixOrigin >> 32),
+		byte(fixOrigin >> 40),
+		byte(fixOrigin >> 48),
+		byte(fixOrigin >> 56),
+	}, nil
+}
+
+// intArgReg 获取整数参数寄存器序号对应的寄存器编号
+func intArgReg(ctxReg int) (byte, error) {
+	if ctxReg < 0 || ctxReg >= len(intArgRegs) {
+		return 0, fmt.Errorf("illegal int arg register index: %d", ctxReg)
+	}
+	return intArgRegs[ctxReg], nil
+}
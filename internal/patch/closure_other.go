@@ -0,0 +1,19 @@
+//go:build !amd64
+// +build !amd64
+
+package patch
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// ctxJump 构造保存闭包上下文寄存器的跳转指令, 当前架构暂不支持
+func ctxJump(_ int) (func(from, to uintptr) []byte, error) {
+	return nil, fmt.Errorf("closure patch is not supported on %s", runtime.GOARCH)
+}
+
+// ctxOriginStub 构造调用原闭包函数的桩函数指令, 当前架构暂不支持
+func ctxOriginStub(_ uintptr, _ int) ([]byte, error) {
+	return nil, fmt.Errorf("closure patch is not supported on %s", runtime.GOARCH)
+}
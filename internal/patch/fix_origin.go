@@ -1,9 +1,32 @@
 package patch
 
 import (
+	"fmt"
+
+	"github.com/tencent/goom/internal/bytecode/stub"
 	"github.com/tencent/goom/internal/logger"
 )
 
+// autoTrampolineSize 自动分配的跳板函数空间大小
+// 跳板函数只需要容纳被修复的原函数开头的几条指令和跳转回原函数的指令
+const autoTrampolineSize = 64
+
+// AutoTrampoline 作为 trampoline 参数传入时, 表示由 patch 自动分配跳板函数,
+// 不再需要调用方编写占位函数; 分配失败时 patch 返回错误
+// 不确定是否需要调用原函数时, 可以不传递跳板函数, 在需要时通过 Guard.FixOrigin() 再分配
+type AutoTrampoline struct{}
+
+// autoTrampolines 自动分配的跳板函数缓存, key 为原函数地址和跳转指令长度, 由 patchesLock 保护
+// 同一个原函数的跳板函数内容不变, 重复 patch 时复用, 避免重复占用 PlaceHolder 空间;
+// 跳转指令长度不同时需要修复的指令长度不同, 因此不能复用
+var autoTrampolines = make(map[trampolineKey]uintptr)
+
+// trampolineKey 自动分配的跳板函数缓存的 key
+type trampolineKey struct {
+	origin      uintptr
+	jumpDataLen int
+}
+
 // fixOrigin 将原函数拷贝到另外一个内存区段,并且修复
 // trampoline 跳板函数地址, 不传递用0表示
 // jumpDataLen jumpData 字节数组长度
@@ -15,3 +38,35 @@ func fixOrigin(origin, trampoline uintptr, jumpDataLen int) (uintptr, error) {
 	}
 	return r, e
 }
+
+// fixOriginAuto 自动分配跳板函数空间, 将原函数拷贝到跳板函数并修复
+// originBytes 原函数开头被替换之前的指令, 原函数已经被 patch 之后也可以分配
+// 调用方需持有 patchesLock
+func fixOriginAuto(origin uintptr, originBytes []byte, jumpDataLen int) (fixOriginPtr uintptr, err error) {
+	key := trampolineKey{origin: origin, jumpDataLen: jumpDataLen}
+	if trampoline, ok := autoTrampolines[key]; ok {
+		return trampoline, nil
+	}
+
+	// 优先使用 PlaceHolder 区: mmap 分配的空间可能距离代码段超过 2G, 无法修复相对地址
+	space, err := stub.AcquireHolder(autoTrampolineSize)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("fix origin to auto trampoline error: %v", e)
+		}
+		// 修复失败时归还空间, 避免多次失败耗尽 PlaceHolder 区
+		if err != nil {
+			stub.ReleaseHolder(space)
+		}
+	}()
+	logger.Infof("starting fix Origin origin=0x%x auto trampoline=0x%x ...", origin, space.Addr)
+	fixOriginPtr, err = fixOriginFuncToSpace(origin, originBytes, space.Addr, autoTrampolineSize, jumpDataLen)
+	if err != nil {
+		return 0, err
+	}
+	autoTrampolines[key] = fixOriginPtr
+	return fixOriginPtr, nil
+}
@@ -1,7 +1,6 @@
 package patch
 
 import (
-	"errors"
 	"fmt"
 
 	"github.com/tencent/goom/internal/bytecode"
@@ -18,18 +17,28 @@ import (
 // jumpInstSize 跳转指令长度, 用于判断需要修复的最小指令长度
 // return 跳板函数(即原函数调用入口指针)
 func fixOriginFuncToTrampoline(origin uintptr, trampoline uintptr, jumpInstSize int) (uintptr, error) {
-	// get origin func size
-	originFuncSize, err := bytecode.GetFuncSize(defaultArchMod, origin, false)
+	// get trampoline func size
+	trampFuncSize, err := bytecode.GetFuncSize(defaultArchMod, trampoline, false)
 	if err != nil {
-		logger.Error("GetFuncSize error", err)
-		originFuncSize = defaultFuncSize
+		// 无法确定跳板函数的长度时不能写入, 否则可能覆盖相邻的函数
+		return 0, fmt.Errorf("get trampoline func size error: %w", err)
 	}
+	return fixOriginFuncToSpace(origin, nil, trampoline, trampFuncSize, jumpInstSize)
+}
 
-	// get trampoline func size
-	trampFuncSize, err := bytecode.GetFuncSize(defaultArchMod, trampoline, false)
+// fixOriginFuncToSpace 将原始函数 from 的指令修复到 trampoline 指向的地址
+// originBytes 原函数开头被替换之前的指令, 原函数已经被 patch 时使用, 为 nil 时从原函数读取
+// trampoline 跳板函数空间起始位置
+// trampFuncSize 跳板函数空间的长度
+// jumpInstSize 跳转指令长度, 用于判断需要修复的最小指令长度
+// return 跳板函数(即原函数调用入口指针)
+func fixOriginFuncToSpace(origin uintptr, originBytes []byte, trampoline uintptr, trampFuncSize int,
+	jumpInstSize int) (uintptr, error) {
+	// get origin func size
+	originFuncSize, err := bytecode.GetFuncSize(defaultArchMod, origin, false)
 	if err != nil {
 		logger.Error("GetFuncSize error", err)
-		trampFuncSize = 20
+		originFuncSize = defaultFuncSize
 	}
 	logger.Debug("origin func size is", originFuncSize)
 
@@ -43,6 +52,7 @@ func fixOriginFuncToTrampoline(origin uintptr, trampoline uintptr, jumpInstSize
 
 	// copy origin function
 	fixOriginData := memory.RawRead(origin, originFuncSize)
+	copy(fixOriginData, originBytes)
 	bytecode.PrintInstf("origin inst >>>>> ", origin,
 		fixOriginData[:bytecode.MinSize(bytecode.PrintMiddle, fixOriginData)], logger.DebugLevel)
 
@@ -61,21 +71,15 @@ func fixOriginFuncToTrampoline(origin uintptr, trampoline uintptr, jumpInstSize
 		fixOriginData = append(fixedData, jumpBackData...)
 	}
 
-	// get trampoline func size
-	trampolineFuncSize, err := bytecode.GetFuncSize(defaultArchMod, trampoline, false)
-	if err != nil {
-		logger.Error("Get trampoline FuncSize error", err)
-		return 0, errors.New("Get trampoline FuncSize error:" + err.Error())
-	}
-	logger.Debug("trampoline func size is", trampolineFuncSize)
+	logger.Debug("trampoline func size is", trampFuncSize)
 
-	if len(fixOriginData) > trampolineFuncSize {
+	if len(fixOriginData) > trampFuncSize {
 		logger.Errorf("fixOriginSize[%d] is bigger than trampoline FuncSize[%d], please add your "+
-			"trampoline func code", len(fixOriginData), trampolineFuncSize)
+			"trampoline func code", len(fixOriginData), trampFuncSize)
 		bytecode.PrintInst("trampoline inst > ", trampoline, bytecode.PrintLong, logger.InfoLevel)
 
 		return 0, fmt.Errorf("fixOriginSize[%d] is bigger than trampoline FuncSize[%d], "+
-			"please add your trampoline func code", len(fixOriginData), trampolineFuncSize)
+			"please add your trampoline func code", len(fixOriginData), trampFuncSize)
 	}
 	bytecode.PrintInst("trampoline inst > ", trampoline, bytecode.PrintLong, logger.DebugLevel)
 	bytecode.PrintInstf("fixed inst >>>>> ", trampoline, fixOriginData, logger.DebugLevel)
@@ -1,6 +1,13 @@
 package patch
 
+import "errors"
+
 // fixOriginFuncToTrampoline 修复函数偏移量
 func fixOriginFuncToTrampoline(_ uintptr, _ uintptr, _ int) (uintptr, error) {
 	panic("not support yet on M1-MAC or arm CPU!")
 }
+
+// fixOriginFuncToSpace 修复函数偏移量到指定的空间
+func fixOriginFuncToSpace(_ uintptr, _ []byte, _ uintptr, _ int, _ int) (uintptr, error) {
+	return 0, errors.New("auto trampoline is not support yet on M1-MAC or arm CPU")
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/tencent/goom/internal/bytecode"
 	"github.com/tencent/goom/internal/bytecode/memory"
 	"github.com/tencent/goom/internal/logger"
+	"github.com/tencent/goom/logging"
 )
 
 // Guard 代理执行控制句柄, 可通过此对象进行代理还原
@@ -16,6 +17,7 @@ type Guard struct {
 	jumpBytes    []byte  // 跳转指令字节
 	fixOriginPtr uintptr // 修复的函数指针
 	applied      bool    // 是否已经被应用
+	onUnpatch    func()  // 取消代理时执行的回调
 }
 
 // Apply 执行
@@ -29,6 +31,7 @@ func (g *Guard) Apply() {
 		logger.Errorf("Apply to 0x%x error: %s", g.origin, err)
 	}
 	bytecode.PrintInst(fmt.Sprintf("apply copy to 0x%x", g.origin), g.origin, 30, logger.DebugLevel)
+	g.emit(logging.Patch, "apply patch", g.jumpBytes)
 }
 
 // Unpatch 取消代理,还原指令码
@@ -39,9 +42,21 @@ func (g *Guard) Unpatch() {
 			logger.Errorf("Unpatch to 0x%x error: %s", g.origin, err)
 		}
 		bytecode.PrintInst(fmt.Sprintf("unpatch copy to 0x%x", g.origin), g.origin, 20, logger.DebugLevel)
+		g.emit(logging.Unpatch, "unpatch", g.originBytes)
+		if g.onUnpatch != nil {
+			g.onUnpatch()
+			g.onUnpatch = nil
+		}
 	}
 }
 
+// OnUnpatch 设置取消代理时执行一次的回调, 用于释放和代理关联的资源
+func (g *Guard) OnUnpatch(f func()) {
+	lock()
+	defer unlock()
+	g.onUnpatch = f
+}
+
 // UnpatchWithLock 外部调用需要加锁
 func (g *Guard) UnpatchWithLock() {
 	lock()
@@ -58,10 +73,43 @@ func (g *Guard) Restore() {
 			logger.Errorf("Restore to 0x%x error: %s", g.origin, err)
 		}
 		bytecode.PrintInst(fmt.Sprintf("unpatch copy to 0x%x", g.origin), g.origin, 20, logger.DebugLevel)
+		g.emit(logging.Patch, "restore patch", g.jumpBytes)
+	}
+}
+
+// emit 输出指令替换的结构化事件, bytes 为写入的指令
+func (g *Guard) emit(typ logging.Type, msg string, bytes []byte) {
+	if !logging.Enabled(logging.LevelDebug) {
+		return
+	}
+	attrs := []logging.Attr{
+		logging.Any(logging.KeyOrigin, fmt.Sprintf("0x%x", g.origin)),
+		logging.Any(logging.KeyBytes, fmt.Sprintf("%x", bytes)),
 	}
+	if typ == logging.Patch {
+		attrs = append(attrs, logging.Any(logging.KeyOriginBytes, fmt.Sprintf("%x", g.originBytes)))
+	}
+	logging.Emit(logging.LevelDebug, typ, msg, attrs...)
 }
 
 // FixOriginFunc 获取应用代理后的原函数地址(和代理前的原函数地址不一样)
 func (g *Guard) FixOriginFunc() uintptr {
+	lock()
+	defer unlock()
 	return g.fixOriginPtr
 }
+
+// FixOrigin 获取应用代理后的原函数地址, patch 时没有分配跳板函数的, 自动分配跳板函数
+// 分配失败时返回错误, 比如 PlaceHolder 空间已用完
+func (g *Guard) FixOrigin() (uintptr, error) {
+	lock()
+	defer unlock()
+	if g.fixOriginPtr == 0 {
+		fixOriginPtr, err := fixOriginAuto(g.origin, g.originBytes, len(g.jumpBytes))
+		if err != nil {
+			return 0, err
+		}
+		g.fixOriginPtr = fixOriginPtr
+	}
+	return g.fixOriginPtr, nil
+}
@@ -25,7 +25,9 @@ var errAlreadyPatch = errors.New("already patched")
 // replacementInAddr 要跳转到的函数调用地址
 // replacementCode 要跳转到的函数地址, 与 replacementInAddr 的区别详细可以参考:
 // https://docs.google.com/document/d/1bMwCey-gmqZVTpRax-ESeVuZGmjwbocYs1iHplK-cjo/pub
-func genJumpData(origin, replacementInAddr, replacementCode uintptr) (jumpData []byte, err error) {
+// jump 构造跳转指令的函数, 为 nil 时使用 jmpToFunctionValue
+func genJumpData(origin, replacementInAddr, replacementCode uintptr,
+	jump func(from, to uintptr) []byte) (jumpData []byte, err error) {
 	defer func() {
 		if e := recover(); e != nil {
 			logger.Errorf("genJumpData origin=%d replacementInAddr=%d error:%s", origin, replacementInAddr, e)
@@ -50,7 +52,10 @@ func genJumpData(origin, replacementInAddr, replacementCode uintptr) (jumpData [
 	}
 
 	// 构造跳转到代理函数的指令
-	jumpData = jmpToFunctionValue(origin, replacementInAddr)
+	if jump == nil {
+		jump = jmpToFunctionValue
+	}
+	jumpData = jump(origin, replacementInAddr)
 	// 如果需要织入的跳转指令的长度大于原函数指令长度,则任务是无法织入指令
 	if len(jumpData) >= funcSize {
 		bytecode.PrintInst("origin inst > ", origin, bytecode.PrintShort, logger.InfoLevel)
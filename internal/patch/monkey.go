@@ -99,6 +99,19 @@ func InstanceMethod(originType reflect.Type, methodName string, replacement inte
 // Replacement should expect the receiver (of type target) as the first argument
 func InstanceMethodTrampoline(originType reflect.Type, methodName string, replacement interface{},
 	trampoline interface{}) (*Guard, error) {
+	return instanceMethodTrampoline(originType, methodName, replacement, trampoline, true)
+}
+
+// UnsafeInstanceMethodTrampoline 未受类型检查的实例方法 patch
+// 用于接收体或参数为内存布局一致的 fake 类型等无法通过类型检查的场景
+func UnsafeInstanceMethodTrampoline(originType reflect.Type, methodName string, replacement interface{},
+	trampoline interface{}) (*Guard, error) {
+	return instanceMethodTrampoline(originType, methodName, replacement, trampoline, false)
+}
+
+// instanceMethodTrampoline 实例方法 patch, check 是否检查代理函数和原方法的签名
+func instanceMethodTrampoline(originType reflect.Type, methodName string, replacement interface{},
+	trampoline interface{}, check bool) (*Guard, error) {
 	m, ok := originType.MethodByName(methodName)
 	if !ok {
 		return nil, fmt.Errorf("unknown method %s", methodName)
@@ -112,7 +125,12 @@ func InstanceMethodTrampoline(originType reflect.Type, methodName string, replac
 		replacementValue: reflect.ValueOf(replacement),
 	}
 
-	err := patch.patchValue()
+	var err error
+	if check {
+		err = patch.patchValue()
+	} else {
+		err = patch.unsafePatchValue()
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -10,6 +10,7 @@ import (
 	"github.com/tencent/goom/internal/logger"
 	"github.com/tencent/goom/internal/patch"
 	"github.com/tencent/goom/internal/patch/test"
+	"github.com/tencent/goom/internal/unexports"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -136,3 +137,53 @@ func TestNotCompatible(t *testing.T) {
 		g.Apply()
 	})
 }
+
+// TestAutoTrampoline 测试自动分配跳板函数
+func TestAutoTrampoline(t *testing.T) {
+	var origin func() bool
+	guard, err := patch.Trampoline(test.No, func() bool {
+		return !origin()
+	}, patch.AutoTrampoline{})
+	assert.Nil(t, err)
+	assert.NotEqual(t, uintptr(0), guard.FixOriginFunc())
+
+	origin = unexports.NewFuncWithCodePtr(reflect.TypeOf(test.No), guard.FixOriginFunc()).Interface().(func() bool)
+	guard.Apply()
+	assert.True(t, test.No())
+	assert.True(t, patch.Unpatch(test.No))
+	assert.False(t, test.No())
+}
+
+// TestFixOrigin 测试 patch 之后再分配跳板函数
+func TestFixOrigin(t *testing.T) {
+	var origin func() bool
+	guard, err := patch.Trampoline(test.No, func() bool {
+		return !origin()
+	}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uintptr(0), guard.FixOriginFunc())
+	guard.Apply()
+
+	fixOrigin, err := guard.FixOrigin()
+	assert.Nil(t, err)
+	assert.Equal(t, fixOrigin, guard.FixOriginFunc())
+	origin = unexports.NewFuncWithCodePtr(reflect.TypeOf(test.No), fixOrigin).Interface().(func() bool)
+	assert.True(t, test.No())
+	assert.True(t, patch.Unpatch(test.No))
+	assert.False(t, test.No())
+}
+
+// TestOnUnpatch 测试取消代理时执行一次回调
+func TestOnUnpatch(t *testing.T) {
+	guard, err := patch.Patch(test.No, func() bool { return true })
+	assert.Nil(t, err)
+	calls := 0
+	guard.OnUnpatch(func() { calls++ })
+	guard.Apply()
+	assert.True(t, test.No())
+
+	guard.UnpatchWithLock()
+	assert.False(t, test.No())
+	guard.UnpatchWithLock()
+	assert.Equal(t, 1, calls)
+}
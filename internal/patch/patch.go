@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/tencent/goom/internal/bytecode"
+	"github.com/tencent/goom/internal/hack"
 	"github.com/tencent/goom/internal/logger"
 )
 
@@ -31,7 +32,7 @@ func unlock() {
 type patch struct {
 	origin      interface{} // 原始函数,即要mock的目标函数, 相对于代理函数来说叫原始函数
 	replacement interface{} // 代理函数
-	trampoline  interface{} // 跳板函数
+	trampoline  interface{} // 跳板函数, 值为 AutoTrampoline 时自动分配
 
 	originValue      reflect.Value
 	replacementValue reflect.Value
@@ -42,6 +43,11 @@ type patch struct {
 	trampolinePtr  uintptr
 	fixOriginPtr   uintptr
 
+	// autoTrampoline 是否自动分配跳板函数
+	autoTrampoline bool
+	// jump 构造跳转到代理函数的指令, 为 nil 时使用 jmpToFunctionValue
+	jump func(from, to uintptr) []byte
+
 	originBytes []byte
 	jumpBytes   []byte
 
@@ -57,7 +63,11 @@ func (p *patch) patch() error {
 
 // patchValue 对 value 进行应用代理
 func (p *patch) patchValue() error {
-	SignatureEquals(p.originValue.Type(), p.replacementValue.Type())
+	if p.originValue.Kind() == reflect.Func && p.replacementValue.Kind() == reflect.Func {
+		if err := CheckSignature(p.originValue.Type(), p.replacementValue.Type()); err != nil {
+			return err
+		}
+	}
 	return p.unsafePatchValue()
 }
 
@@ -78,7 +88,9 @@ func (p *patch) unsafePatchValue() error {
 func (p *patch) unsafePatchPtr() error {
 	replacementPointer := p.replacementValue.Pointer()
 	p.replacementPtr = replacementPointer
-	if p.trampoline != nil {
+	if _, ok := p.trampoline.(AutoTrampoline); ok {
+		p.autoTrampoline = true
+	} else if p.trampoline != nil {
 		trampolinePtr, err := bytecode.GetTrampolinePtr(p.trampoline)
 		if err != nil {
 			return err
@@ -90,6 +102,11 @@ func (p *patch) unsafePatchPtr() error {
 
 // replaceFunc 替换函数
 func (p *patch) replaceFunc() error {
+	// 运行时结构体布局无法识别时拒绝 patch, 防止破坏内存
+	if err := hack.CheckLayout(); err != nil {
+		return err
+	}
+
 	lock()
 	defer unlock()
 
@@ -99,7 +116,7 @@ func (p *patch) replaceFunc() error {
 	patches[p.originPtr] = p
 
 	replacementInAddr := (uintptr)(bytecode.GetPtr(p.replacementValue))
-	jumpData, err := genJumpData(p.originPtr, replacementInAddr, p.replacementPtr)
+	jumpData, err := genJumpData(p.originPtr, replacementInAddr, p.replacementPtr, p.jump)
 	if err != nil {
 		if errors.Unwrap(err) == errAlreadyPatch {
 			if pc, ok := patches[p.originPtr]; ok {
@@ -117,7 +134,13 @@ func (p *patch) replaceFunc() error {
 	p.originBytes = originBytes
 
 	// 是否修复指令
-	if p.trampolinePtr > 0 {
+	if p.autoTrampoline {
+		fixOriginPtr, err := fixOriginAuto(p.originPtr, originBytes, len(jumpData))
+		if err != nil {
+			return err
+		}
+		p.fixOriginPtr = fixOriginPtr
+	} else if p.trampolinePtr > 0 {
 		fixOriginPtr, err := fixOrigin(p.originPtr, p.trampolinePtr, len(jumpData))
 		if err != nil {
 			return err
@@ -3,30 +3,194 @@ package patch
 import (
 	"fmt"
 	"reflect"
+	"unsafe"
+
+	"github.com/tencent/goom/erro"
+)
+
+// 参数在寄存器 ABI 中的分类
+const (
+	// classInt 使用整数寄存器传递
+	classInt = iota
+	// classFloat 使用浮点寄存器传递
+	classFloat
+	// classPointer 使用整数寄存器传递, 并且是 GC 需要扫描的指针
+	classPointer
 )
 
-// SignatureEquals 检测两个函数类型的参数的内存区段是否一致
+// classNames 参数分类的名称
+var classNames = []string{"integer", "float", "pointer"}
+
+// unsafePointerType unsafe.Pointer 类型
+var unsafePointerType = reflect.TypeOf(unsafe.Pointer(nil))
+
+// word 参数按照寄存器 ABI 展开后的基本单元
+type word struct {
+	offset uintptr
+	size   uintptr
+	class  int
+	// typ 指针、map、chan、func、interface 等需要进一步比较的类型, 其它为 nil
+	typ reflect.Type
+	// inArray 是否在长度大于 1 的数组中, 这样的参数不使用寄存器传递
+	inArray bool
+}
+
+// SignatureEquals 检测两个函数类型的参数是否兼容, 不兼容时 panic
 func SignatureEquals(typeA reflect.Type, typeB reflect.Type) bool {
-	// 检测参数对齐
+	if err := CheckSignature(typeA, typeB); err != nil {
+		panic(err)
+	}
+	return true
+}
+
+// CheckSignature 检测两个函数类型的参数是否兼容, 即代理函数能否正确地解析原函数的参数和返回值
+// 除了参数大小之外, 还会检查参数的寄存器分类(整数或浮点)、指针的位置(GC 需要扫描)、
+// 结构体字段的内存布局以及指针指向的类型, 不兼容时返回 *erro.SignatureNotMatch
+func CheckSignature(typeA reflect.Type, typeB reflect.Type) error {
 	if typeA.NumIn() != typeB.NumIn() {
-		panic(fmt.Sprintf("func signature mismatch, args len must:%d, actual:%d",
-			typeA.NumIn(), typeB.NumIn()))
+		return erro.NewSignatureNotMatchError(typeA, typeB, "args", -1,
+			fmt.Sprintf("must: %d, actual: %d", typeA.NumIn(), typeB.NumIn()))
 	}
 	if typeA.NumOut() != typeB.NumOut() {
-		panic(fmt.Sprintf("func signature mismatch, returns len must:%d, actual:%d",
-			typeA.NumOut(), typeB.NumOut()))
+		return erro.NewSignatureNotMatchError(typeA, typeB, "returns", -1,
+			fmt.Sprintf("must: %d, actual: %d", typeA.NumOut(), typeB.NumOut()))
 	}
+	c := &compatibility{seen: make(map[[2]reflect.Type]bool)}
 	for i := 0; i < typeA.NumIn(); i++ {
-		if typeA.In(i).Size() != typeB.In(i).Size() {
-			panic(fmt.Sprintf("func signature mismatch, args %d's size must:%d, actual:%d",
-				i, typeA.In(i).Size(), typeB.In(i).Size()))
+		if reason := c.compare(typeA.In(i), typeB.In(i), true); reason != "" {
+			return erro.NewSignatureNotMatchError(typeA, typeB, "args", i, reason)
 		}
 	}
 	for i := 0; i < typeA.NumOut(); i++ {
-		if typeA.Out(i).Size() != typeB.Out(i).Size() {
-			panic(fmt.Sprintf("func signature mismatch, returns %d's size must:%d, actual:%d",
-				i, typeA.Out(i).Size(), typeB.Out(i).Size()))
+		if reason := c.compare(typeA.Out(i), typeB.Out(i), true); reason != "" {
+			return erro.NewSignatureNotMatchError(typeA, typeB, "returns", i, reason)
 		}
 	}
-	return true
+	return nil
+}
+
+// compatibility 比较两个类型的兼容性
+type compatibility struct {
+	// seen 已经比较过或者正在比较的类型, 用于支持递归引用自身的类型
+	seen map[[2]reflect.Type]bool
 }
+
+// compare 比较两个类型是否兼容, 兼容时返回空字符串, 否则返回不兼容的原因
+// top 是否为函数的参数或返回值, 只有参数和返回值需要考虑寄存器传递的规则
+func (c *compatibility) compare(a, b reflect.Type, top bool) string {
+	if a == b || c.seen[[2]reflect.Type{a, b}] {
+		return ""
+	}
+	c.seen[[2]reflect.Type{a, b}] = true
+
+	prefix := fmt.Sprintf("must: %v, actual: %v", a, b)
+	if a.Size() != b.Size() {
+		return fmt.Sprintf("%s, size must: %d, actual: %d", prefix, a.Size(), b.Size())
+	}
+	wordsA, wordsB := flatten(a, 0, false, nil), flatten(b, 0, false, nil)
+	for i := 0; i < len(wordsA) || i < len(wordsB); i++ {
+		if i >= len(wordsA) || i >= len(wordsB) {
+			return fmt.Sprintf("%s, memory layout mismatch", prefix)
+		}
+		wa, wb := wordsA[i], wordsB[i]
+		if wa.offset != wb.offset || wa.size != wb.size {
+			return fmt.Sprintf("%s, memory layout mismatch at offset %d", prefix, wa.offset)
+		}
+		if wa.class != wb.class {
+			return fmt.Sprintf("%s, offset %d must be %s, actual: %s",
+				prefix, wa.offset, classNames[wa.class], classNames[wb.class])
+		}
+		if top && wa.inArray != wb.inArray {
+			return fmt.Sprintf("%s, arrays and structs are passed in different ways", prefix)
+		}
+		if reason := c.compareRef(wa.typ, wb.typ); reason != "" {
+			return fmt.Sprintf("%s, offset %d %s", prefix, wa.offset, reason)
+		}
+	}
+	return ""
+}
+
+// compareRef 比较指针、map、chan、func、interface 等引用的类型是否兼容
+func (c *compatibility) compareRef(a, b reflect.Type) string {
+	if a == nil || b == nil || a == b || a == unsafePointerType || b == unsafePointerType {
+		return ""
+	}
+	if a.Kind() == reflect.Interface || b.Kind() == reflect.Interface {
+		// 空接口和非空接口的第一个字段分别为类型和 itab, 不能互相转换
+		if a.Kind() == b.Kind() && a.NumMethod() == 0 && b.NumMethod() == 0 {
+			return ""
+		}
+		if a.Kind() == b.Kind() {
+			return fmt.Sprintf("must be %v, actual: %v", a, b)
+		}
+		return ""
+	}
+	if a.Kind() != b.Kind() {
+		return fmt.Sprintf("must be %v, actual: %v", a.Kind(), b.Kind())
+	}
+
+	var reason string
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Chan:
+		reason = c.compare(a.Elem(), b.Elem(), false)
+	case reflect.Map:
+		if reason = c.compare(a.Key(), b.Key(), false); reason == "" {
+			reason = c.compare(a.Elem(), b.Elem(), false)
+		}
+	case reflect.Func:
+		if err := CheckSignature(a, b); err != nil {
+			reason = err.Error()
+		}
+	}
+	if reason != "" {
+		return fmt.Sprintf("points to incompatible type (%s)", reason)
+	}
+	return ""
+}
+
+// flatten 将类型按照寄存器 ABI 展开为基本单元
+func flatten(t reflect.Type, offset uintptr, inArray bool, words []word) []word {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return append(words, word{offset: offset, size: t.Size(), class: classInt, inArray: inArray})
+	case reflect.Float32, reflect.Float64:
+		return append(words, word{offset: offset, size: t.Size(), class: classFloat, inArray: inArray})
+	case reflect.Complex64, reflect.Complex128:
+		half := t.Size() / 2
+		return append(words,
+			word{offset: offset, size: half, class: classFloat, inArray: inArray},
+			word{offset: offset + half, size: half, class: classFloat, inArray: inArray})
+	case reflect.Ptr, reflect.UnsafePointer, reflect.Map, reflect.Chan, reflect.Func:
+		return append(words, word{offset: offset, size: t.Size(), class: classPointer, typ: t, inArray: inArray})
+	case reflect.String:
+		return append(words,
+			word{offset: offset, size: ptrSize, class: classPointer, inArray: inArray},
+			word{offset: offset + ptrSize, size: ptrSize, class: classInt, inArray: inArray})
+	case reflect.Slice:
+		return append(words,
+			word{offset: offset, size: ptrSize, class: classPointer, typ: reflect.PtrTo(t.Elem()), inArray: inArray},
+			word{offset: offset + ptrSize, size: ptrSize, class: classInt, inArray: inArray},
+			word{offset: offset + 2*ptrSize, size: ptrSize, class: classInt, inArray: inArray})
+	case reflect.Interface:
+		return append(words,
+			word{offset: offset, size: ptrSize, class: classPointer, typ: t, inArray: inArray},
+			word{offset: offset + ptrSize, size: ptrSize, class: classPointer, inArray: inArray})
+	case reflect.Array:
+		for i := 0; i < t.Len(); i++ {
+			words = flatten(t.Elem(), offset+uintptr(i)*t.Elem().Size(), inArray || t.Len() > 1, words)
+		}
+		return words
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			words = flatten(f.Type, offset+f.Offset, inArray, words)
+		}
+		return words
+	default:
+		return words
+	}
+}
+
+// ptrSize 指针的大小
+const ptrSize = unsafe.Sizeof(uintptr(0))
@@ -0,0 +1,77 @@
+package patch_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tencent/goom/erro"
+	"github.com/tencent/goom/internal/patch"
+)
+
+// point 结构体参数
+type point struct {
+	x, y int
+}
+
+// fakePoint 和 point 内存布局一致的 fake 结构体
+type fakePoint struct {
+	a, b int
+}
+
+// floatPoint 和 point 大小一致但是使用浮点寄存器传递的结构体
+type floatPoint struct {
+	x, y float64
+}
+
+// TestCheckSignature 测试签名兼容性检查
+func TestCheckSignature(t *testing.T) {
+	compatible := [][2]interface{}{
+		{func(int) int { return 0 }, func(int) int { return 0 }},
+		{func(int64) {}, func(uint64) {}},
+		{func(point) {}, func(fakePoint) {}},
+		{func(*point) {}, func(*fakePoint) {}},
+		{func(*point) {}, func(unsafe.Pointer) {}},
+		{func(error) {}, func(struct{ tab, data unsafe.Pointer }) {}},
+		{func(interface{}) {}, func(interface{}) {}},
+	}
+	for _, c := range compatible {
+		assert.Nil(t, patch.CheckSignature(reflect.TypeOf(c[0]), reflect.TypeOf(c[1])),
+			"%T and %T must be compatible", c[0], c[1])
+	}
+
+	incompatible := [][2]interface{}{
+		{func(int) {}, func(int, int) {}},
+		{func() int { return 0 }, func() {}},
+		{func(int64) {}, func(float64) {}},
+		{func(int64) {}, func(*int) {}},
+		{func(point) {}, func(floatPoint) {}},
+		{func(*point) {}, func(*floatPoint) {}},
+		{func(point) {}, func([2]int) {}},
+		{func(error) {}, func(interface{}) {}},
+		{func(error) {}, func([2]unsafe.Pointer) {}},
+		{func(map[int]int) {}, func(chan int) {}},
+		{func(func(int)) {}, func(func(float64)) {}},
+	}
+	for _, c := range incompatible {
+		err := patch.CheckSignature(reflect.TypeOf(c[0]), reflect.TypeOf(c[1]))
+		var target *erro.SignatureNotMatch
+		assert.True(t, errors.As(err, &target), "%T and %T must be incompatible", c[0], c[1])
+	}
+}
+
+// TestCheckSignatureError 测试签名不兼容时的错误信息
+func TestCheckSignatureError(t *testing.T) {
+	err := patch.CheckSignature(reflect.TypeOf(func(string, int64) {}), reflect.TypeOf(func(string, float64) {}))
+	target, ok := err.(*erro.SignatureNotMatch)
+	assert.True(t, ok)
+	assert.Equal(t, "args", target.Position())
+	assert.Equal(t, 1, target.Index())
+	assert.Contains(t, err.Error(), "must be integer, actual: float")
+
+	_, err = patch.Patch(func(i int64) int64 { return i }, func(f float64) int64 { return 0 })
+	assert.IsType(t, &erro.SignatureNotMatch{}, err)
+}
@@ -0,0 +1,152 @@
+package proxy
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/tencent/goom/internal/logger"
+	"github.com/tencent/goom/internal/patch"
+	"github.com/tencent/goom/internal/unexports"
+)
+
+const (
+	// intArgRegs ABIInternal 整数参数寄存器的数量(amd64)
+	intArgRegs = 9
+	// floatArgRegs ABIInternal 浮点参数寄存器的数量(amd64)
+	floatArgRegs = 15
+)
+
+// ClosureProxy 闭包函数的代理函数
+// ctx 调用方闭包对象的地址(闭包上下文), args 闭包函数的参数, 可变参数的最后一个参数为 slice
+type ClosureProxy func(ctx uintptr, args []reflect.Value) []reflect.Value
+
+// Closure 对闭包函数的代码进行代理, 同一个函数字面量创建的所有闭包对象的调用都会进入代理函数
+// codePtr 闭包函数的代码地址
+// typ 闭包函数的类型
+// proxyFunc 代理函数
+// 返回 patch 守卫, 以及可调用的原函数: 原函数的参数为闭包函数的参数追加 uintptr 类型的闭包上下文, 没有跳板函数时为零值
+func Closure(codePtr uintptr, typ reflect.Type, proxyFunc ClosureProxy) (*patch.Guard, reflect.Value, error) {
+	ctxReg, err := ctxRegOf(typ)
+	if err != nil {
+		return nil, reflect.Value{}, err
+	}
+	if err := checkInlined(codePtr); err != nil {
+		return nil, reflect.Value{}, err
+	}
+
+	ctxTyp := withCtx(typ)
+	proxyValue := reflect.MakeFunc(ctxTyp, func(args []reflect.Value) []reflect.Value {
+		last := len(args) - 1
+		return proxyFunc(uintptr(args[last].Uint()), args[:last])
+	})
+
+	name := unexports.FuncNameForPC(codePtr)
+	logger.Info("start closure proxy func=", name)
+	patchGuard, err := patch.CtxTrampoline(codePtr, proxyValue.Interface(), ctxReg)
+	if err != nil {
+		logger.Error("closure proxy fail func=", name, ":", err)
+		return nil, reflect.Value{}, err
+	}
+
+	// 闭包被取消之后仍在执行中的调用需要转发到原函数, 因此总是分配跳板函数
+	var origin reflect.Value
+	fixOrigin, err := patchGuard.FixOrigin()
+	if err == nil {
+		var stub uintptr
+		if stub, err = patch.CtxOriginStub(fixOrigin, ctxReg); err == nil {
+			origin = unexports.NewFuncWithCodePtr(ctxTyp, stub)
+		}
+	}
+	if err != nil {
+		// 无法构造原函数不影响 patch, 只是无法调用原函数
+		logger.Infof("closure origin is not available, func=%s: %v", name, err)
+	}
+	logger.Debug("closure proxy ok func=", name)
+	return patchGuard, origin, nil
+}
+
+// withCtx 在函数类型的参数末尾追加 uintptr 类型的闭包上下文参数, 可变参数转换为 slice 参数
+func withCtx(typ reflect.Type) reflect.Type {
+	in := make([]reflect.Type, 0, typ.NumIn()+1)
+	for i := 0; i < typ.NumIn(); i++ {
+		in = append(in, typ.In(i))
+	}
+	in = append(in, reflect.TypeOf(uintptr(0)))
+	out := make([]reflect.Type, typ.NumOut())
+	for i := range out {
+		out[i] = typ.Out(i)
+	}
+	return reflect.FuncOf(in, out, false)
+}
+
+// ctxRegOf 按照 ABIInternal 的寄存器分配规则计算闭包上下文参数分配到的整数参数寄存器序号
+// 追加的上下文参数为 uintptr 类型, 只要还有空闲的整数参数寄存器就会被分配到下一个整数参数寄存器,
+// 且不会影响其它参数的分配
+func ctxRegOf(typ reflect.Type) (int, error) {
+	if !regABI {
+		return 0, fmt.Errorf("closure mock requires the register-based calling convention(go1.17+)")
+	}
+	a := &abiAssign{}
+	for i := 0; i < typ.NumIn(); i++ {
+		saved := *a
+		if !a.assign(typ.In(i)) {
+			// 无法分配到寄存器的参数整体通过栈传递, 不占用寄存器
+			*a = saved
+		}
+	}
+	if a.ints >= intArgRegs {
+		return 0, fmt.Errorf("too many int args of closure %s, no register left for closure context", typ)
+	}
+	return a.ints, nil
+}
+
+// abiAssign 参数寄存器分配状态
+type abiAssign struct {
+	ints   int
+	floats int
+}
+
+// assign 为类型分配寄存器, 寄存器不足或者类型无法通过寄存器传递时返回 false
+func (a *abiAssign) assign(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return a.useFloat(1)
+	case reflect.Complex64, reflect.Complex128:
+		return a.useFloat(2)
+	case reflect.String, reflect.Interface:
+		return a.useInt(2)
+	case reflect.Slice:
+		return a.useInt(3)
+	case reflect.Array:
+		switch t.Len() {
+		case 0:
+			return true
+		case 1:
+			return a.assign(t.Elem())
+		default:
+			return false
+		}
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !a.assign(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	default:
+		// bool、整数、指针、chan、map、func 等
+		return a.useInt(1)
+	}
+}
+
+// useInt 占用整数参数寄存器
+func (a *abiAssign) useInt(n int) bool {
+	a.ints += n
+	return a.ints <= intArgRegs
+}
+
+// useFloat 占用浮点参数寄存器
+func (a *abiAssign) useFloat(n int) bool {
+	a.floats += n
+	return a.floats <= floatArgRegs
+}
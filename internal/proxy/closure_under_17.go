@@ -0,0 +1,7 @@
+//go:build !go1.17
+// +build !go1.17
+
+package proxy
+
+// regABI 是否使用基于寄存器的调用约定(ABIInternal)
+const regABI = false
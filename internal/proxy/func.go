@@ -16,16 +16,41 @@ import (
 // Func 通过函数生成代理函数
 // @param funcDef 原始函数定义
 // @param proxyFunc 代理函数实现
-// @param originFunc 跳板函数即代理后的原始函数定义(值为 nil 时,使用公共的跳板函数, 不为 nil 时使用指定的跳板函数)
+// @param originFunc 跳板函数即代理后的原始函数定义(值为 nil 时,使用公共的跳板函数, 不为 nil 时使用指定的跳板函数;
+// 值为 patch.AutoTrampoline{} 时自动分配跳板函数, 可通过 Guard.FixOriginFunc() 获取原函数地址;
+// 值为空函数变量的指针时, 自动分配跳板函数并回填到该变量)
 func Func(funcDef interface{}, proxyFunc, trampolineFunc interface{}) (*patch.Guard, error) {
+	return funcProxy(funcDef, proxyFunc, trampolineFunc, patch.Trampoline)
+}
+
+// UnsafeFunc 通过函数生成代理函数, 不检查代理函数和原函数的签名是否兼容
+// 用于参数为内存布局一致的 fake 类型等无法通过签名检查的场景, 参数同 Func
+func UnsafeFunc(funcDef interface{}, proxyFunc, trampolineFunc interface{}) (*patch.Guard, error) {
+	return funcProxy(funcDef, proxyFunc, trampolineFunc, patch.UnsafePatchTrampoline)
+}
+
+// funcProxy 通过函数生成代理函数, trampoline 为 patch 的实现
+func funcProxy(funcDef interface{}, proxyFunc, trampolineFunc interface{},
+	trampoline func(origin, replacement, trampoline interface{}) (*patch.Guard, error)) (*patch.Guard, error) {
 	if e := checkTrampolineFunc(trampolineFunc); e != nil {
 		return nil, e
 	}
 
+	funcValue := reflect.Indirect(reflect.ValueOf(funcDef))
+	if g, err := genericOf(funcValue.Pointer(), funcValue.Type()); err != nil || g != nil {
+		if err != nil {
+			return nil, err
+		}
+		return genericProxy(g, proxyFunc, trampolineFunc)
+	}
+	if err := checkInlined(funcValue.Pointer()); err != nil {
+		return nil, err
+	}
+
 	logger.Info("start func proxy funcDef=", funcDef)
 	// 添加函数 hook
-	patchGuard, err := patch.Trampoline(
-		reflect.Indirect(reflect.ValueOf(funcDef)).Interface(), proxyFunc, trampolineFunc)
+	patchGuard, err := trampoline(
+		reflect.Indirect(reflect.ValueOf(funcDef)).Interface(), proxyFunc, trampolineOf(trampolineFunc))
 	if err != nil {
 		logger.Error("func proxy fail funcDef=", funcDef, ":", err)
 		return nil, err
@@ -33,13 +58,10 @@ func Func(funcDef interface{}, proxyFunc, trampolineFunc interface{}) (*patch.Gu
 
 	// 构造原先方法实例值
 	logger.Debug("origin ptr is:", fmt.Sprintf("0x%x", patchGuard.FixOriginFunc()))
-	if bytecode.IsValidPtr(trampolineFunc) {
-		_, err = unexports.CreateFuncForCodePtr(trampolineFunc, patchGuard.FixOriginFunc())
-		if err != nil {
-			logger.Error("func proxy fail funcDef=", funcDef, ":", err)
-			patchGuard.Unpatch()
-			return nil, err
-		}
+	if err = fillOrigin(trampolineFunc, patchGuard); err != nil {
+		logger.Error("func proxy fail funcDef=", funcDef, ":", err)
+		patchGuard.Unpatch()
+		return nil, err
 	}
 
 	logger.Debug("func proxy ok funcDef=", funcDef)
@@ -49,7 +71,8 @@ func Func(funcDef interface{}, proxyFunc, trampolineFunc interface{}) (*patch.Gu
 // FuncName 通过函数名生成代理函数
 // @param genCallableMethod 函数名称
 // @param proxyFunc 代理函数实现
-// @param trampolineFunc 跳板函数,即代理后的原始函数定义;跳板函数的签名必须和原函数一致,值不能为空
+// @param trampolineFunc 跳板函数,即代理后的原始函数定义;跳板函数的签名必须和原函数一致
+// (值为空函数变量的指针时, 自动分配跳板函数并回填到该变量)
 func FuncName(funcName string, proxyFunc interface{}, trampolineFunc interface{}) (*patch.Guard, error) {
 	if e := checkTrampolineFunc(trampolineFunc); e != nil {
 		return nil, e
@@ -58,10 +81,19 @@ func FuncName(funcName string, proxyFunc interface{}, trampolineFunc interface{}
 	if err != nil {
 		return nil, err
 	}
+	if g, err := genericOf(originFuncPtr, reflect.TypeOf(proxyFunc)); err != nil || g != nil {
+		if err != nil {
+			return nil, err
+		}
+		return genericProxy(g, proxyFunc, trampolineFunc)
+	}
+	if err := checkInlined(originFuncPtr); err != nil {
+		return nil, err
+	}
 
 	logger.Info("start funcName proxy genCallableMethod=", funcName)
 	// 添加函数 hook
-	patchGuard, err := patch.PtrTrampoline(originFuncPtr, proxyFunc, trampolineFunc)
+	patchGuard, err := patch.PtrTrampoline(originFuncPtr, proxyFunc, trampolineOf(trampolineFunc))
 	if err != nil {
 		logger.Error("funcName proxy fail genCallableMethod=", funcName, ":", err)
 		return nil, err
@@ -69,6 +101,11 @@ func FuncName(funcName string, proxyFunc interface{}, trampolineFunc interface{}
 
 	// 构造原先方法实例值
 	logger.Debug("origin ptr is:", fmt.Sprintf("0x%x", patchGuard.FixOriginFunc()))
+	if err = fillOrigin(trampolineFunc, patchGuard); err != nil {
+		logger.Error("funcName proxy fail genCallableMethod=", funcName, ":", err)
+		patchGuard.Unpatch()
+		return nil, err
+	}
 	logger.Info("funcName proxy[trampoline] ok, genCallableMethod=", funcName)
 	return patchGuard, nil
 }
@@ -77,16 +114,42 @@ func FuncName(funcName string, proxyFunc interface{}, trampolineFunc interface{}
 // @param target 类型
 // @param methodName 方法名
 // @param proxyFunc 代理函数实现
-// @param trampolineFunc 跳板函数即代理后的原始方法定义(值为 nil 时,使用公共的跳板函数, 不为 nil 时使用指定的跳板函数)
+// @param trampolineFunc 跳板函数即代理后的原始方法定义(值为 nil 时,使用公共的跳板函数, 不为 nil 时使用指定的跳板函数;
+// 值为 patch.AutoTrampoline{} 时自动分配跳板函数; 值为空函数变量的指针时, 自动分配跳板函数并回填到该变量)
 func Method(target reflect.Type, methodName string, proxyFunc,
 	trampolineFunc interface{}) (*patch.Guard, error) {
+	return methodProxy(target, methodName, proxyFunc, trampolineFunc, patch.InstanceMethodTrampoline)
+}
+
+// UnsafeMethod 通过方法生成代理方法, 不检查代理函数和原方法的签名是否兼容
+// 用于接收体或参数为内存布局一致的 fake 类型等无法通过签名检查的场景, 参数同 Method
+func UnsafeMethod(target reflect.Type, methodName string, proxyFunc,
+	trampolineFunc interface{}) (*patch.Guard, error) {
+	return methodProxy(target, methodName, proxyFunc, trampolineFunc, patch.UnsafeInstanceMethodTrampoline)
+}
+
+// methodProxy 通过方法生成代理方法, trampoline 为 patch 的实现
+func methodProxy(target reflect.Type, methodName string, proxyFunc, trampolineFunc interface{},
+	trampoline func(reflect.Type, string, interface{}, interface{}) (*patch.Guard, error)) (*patch.Guard, error) {
 	if e := checkTrampolineFunc(trampolineFunc); e != nil {
 		return nil, e
 	}
 
+	if m, ok := target.MethodByName(methodName); ok {
+		if g, err := genericOf(m.Func.Pointer(), m.Type); err != nil || g != nil {
+			if err != nil {
+				return nil, err
+			}
+			return genericProxy(g, proxyFunc, trampolineFunc)
+		}
+		if err := checkInlined(m.Func.Pointer()); err != nil {
+			return nil, err
+		}
+	}
+
 	logger.Info("start method proxy genCallableMethod=", target, ".", methodName)
 	// 添加函数 hook
-	patchGuard, err := patch.InstanceMethodTrampoline(target, methodName, proxyFunc, trampolineFunc)
+	patchGuard, err := trampoline(target, methodName, proxyFunc, trampolineOf(trampolineFunc))
 	if err != nil {
 		logger.Error("method proxy fail type=", target, "methodName=", methodName, ":", err)
 		return nil, err
@@ -94,13 +157,10 @@ func Method(target reflect.Type, methodName string, proxyFunc,
 
 	// 构造原先方法实例值
 	logger.Debug("origin ptr is:", fmt.Sprintf("0x%x", patchGuard.FixOriginFunc()))
-	if bytecode.IsValidPtr(trampolineFunc) {
-		_, err = unexports.CreateFuncForCodePtr(trampolineFunc, patchGuard.FixOriginFunc())
-		if err != nil {
-			logger.Error("method proxy fail method=", target, ".", methodName, ":", err)
-			patchGuard.Unpatch()
-			return nil, err
-		}
+	if err = fillOrigin(trampolineFunc, patchGuard); err != nil {
+		logger.Error("method proxy fail method=", target, ".", methodName, ":", err)
+		patchGuard.Unpatch()
+		return nil, err
 	}
 
 	logger.Debug("method proxy ok genCallableMethod=", target, ".", methodName)
@@ -109,6 +169,9 @@ func Method(target reflect.Type, methodName string, proxyFunc,
 
 // checkTrampolineFunc 检测 TrampolineFunc 类型
 func checkTrampolineFunc(trampolineFunc interface{}) error {
+	if _, ok := trampolineFunc.(patch.AutoTrampoline); ok {
+		return nil
+	}
 	if trampolineFunc != nil {
 		if reflect.ValueOf(trampolineFunc).Kind() != reflect.Func &&
 			reflect.ValueOf(trampolineFunc).Elem().Kind() != reflect.Func {
@@ -117,3 +180,24 @@ func checkTrampolineFunc(trampolineFunc interface{}) error {
 	}
 	return nil
 }
+
+// trampolineOf 获取传递给 patch 的跳板函数
+// 跳板函数为空函数变量的指针时(比如 var origin func(int) int, 传递&origin), 自动分配跳板函数
+func trampolineOf(trampolineFunc interface{}) interface{} {
+	if bytecode.IsValidPtr(trampolineFunc) && reflect.ValueOf(trampolineFunc).Elem().IsNil() {
+		return patch.AutoTrampoline{}
+	}
+	return trampolineFunc
+}
+
+// fillOrigin 将代理后的原函数回填到跳板函数变量
+func fillOrigin(trampolineFunc interface{}, patchGuard *patch.Guard) error {
+	if !bytecode.IsValidPtr(trampolineFunc) {
+		return nil
+	}
+	if patchGuard.FixOriginFunc() == 0 {
+		return errors.New("trampoline is not available, origin func can not be called")
+	}
+	_, err := unexports.CreateFuncForCodePtr(trampolineFunc, patchGuard.FixOriginFunc())
+	return err
+}
@@ -0,0 +1,214 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/tencent/goom/internal/bytecode"
+	"github.com/tencent/goom/internal/logger"
+	"github.com/tencent/goom/internal/patch"
+	"github.com/tencent/goom/internal/unexports"
+)
+
+// defaultArchMod 指令解码模式
+const defaultArchMod = 64
+
+// genericNameMark runtime.Func.Name() 对泛型函数名中的类型参数的省略标记
+const genericNameMark = "[...]"
+
+// shapeNameMark 泛型 shape 函数名中的类型参数标记, 比如: pkg.Map[go.shape.int,go.shape.string]
+const shapeNameMark = "go.shape."
+
+// generics 泛型函数 mock 的原函数构造信息, key 为 patch.Guard, 取消代理时删除
+var generics sync.Map
+
+// genericFunc 泛型函数的实例化信息
+// go 编译器对泛型函数按 GC shape 生成代码(shape 函数), 并通过隐藏的字典参数区分不同的实例化类型;
+// 函数的字典参数为第一个参数, 方法的字典参数在接收体之后
+type genericFunc struct {
+	// name 实例化的函数名
+	name string
+	// shape shape 函数地址
+	shape uintptr
+	// dict 实例化类型的字典地址, 为0时表示未知(直接指定了 shape 函数)
+	dict uintptr
+	// dictIndex 字典参数的位置
+	dictIndex int
+	// typ 用户可见的函数类型(不包含字典参数)
+	typ reflect.Type
+	// shapeTyp shape 函数的类型(包含字典参数)
+	shapeTyp reflect.Type
+	// origin 调用 shape 原函数(跳板函数), 没有跳板函数时为零值
+	origin reflect.Value
+}
+
+// genericOf 获取泛型函数的实例化信息, funcPtr 不是泛型函数时返回 nil
+// funcPtr 泛型函数实例化的包装函数地址(比如 pkg.Map[int,string]), 或 shape 函数地址
+// typ 用户可见的函数类型, 方法的第一个参数为接收体
+func genericOf(funcPtr uintptr, typ reflect.Type) (*genericFunc, error) {
+	f := runtime.FuncForPC(funcPtr)
+	if f == nil || !strings.Contains(f.Name(), genericNameMark) {
+		return nil, nil
+	}
+
+	name := unexports.FuncNameForPC(funcPtr)
+	g := &genericFunc{
+		name:      name,
+		shape:     funcPtr,
+		dictIndex: dictIndexOf(name),
+		typ:       typ,
+	}
+	if !strings.Contains(name, shapeNameMark) {
+		shape, dict, err := bytecode.GetGenericShape(defaultArchMod, funcPtr)
+		if err != nil {
+			return nil, err
+		}
+		if shapeName := unexports.FuncNameForPC(shape); !strings.Contains(shapeName, shapeNameMark) {
+			return nil, fmt.Errorf("generic shape func of %s not found, found: %s", name, shapeName)
+		}
+		g.shape, g.dict = shape, dict
+	}
+	if g.dictIndex > typ.NumIn() {
+		return nil, fmt.Errorf("generic method %s must have a receiver: %s", name, typ)
+	}
+	g.shapeTyp = withDict(typ, g.dictIndex)
+	logger.Debugf("generic func %s shape: 0x%x dict: 0x%x", name, g.shape, g.dict)
+	return g, nil
+}
+
+// dictIndexOf 根据函数名获取字典参数的位置, 方法的函数名比如 pkg.(*Cache[...]).Get 或 pkg.Cache[...].Get
+func dictIndexOf(name string) int {
+	if strings.HasSuffix(name, "]") {
+		return 0
+	}
+	return 1
+}
+
+// withDict 在函数类型中插入字典参数
+func withDict(typ reflect.Type, dictIndex int) reflect.Type {
+	in := make([]reflect.Type, 0, typ.NumIn()+1)
+	for i := 0; i < typ.NumIn(); i++ {
+		if i == dictIndex {
+			in = append(in, reflect.TypeOf(uintptr(0)))
+		}
+		in = append(in, typ.In(i))
+	}
+	if dictIndex == typ.NumIn() {
+		in = append(in, reflect.TypeOf(uintptr(0)))
+	}
+	out := make([]reflect.Type, typ.NumOut())
+	for i := range out {
+		out[i] = typ.Out(i)
+	}
+	return reflect.FuncOf(in, out, typ.IsVariadic())
+}
+
+// proxy 生成 shape 函数的代理函数, 调用时去掉字典参数后再调用 proxyFunc;
+// 其它实例化类型(字典不一致)的调用转发到原函数
+func (g *genericFunc) proxy(proxyFunc interface{}) interface{} {
+	proxyValue := reflect.ValueOf(proxyFunc)
+	return reflect.MakeFunc(g.shapeTyp, func(args []reflect.Value) []reflect.Value {
+		if g.dict != 0 && uintptr(args[g.dictIndex].Uint()) != g.dict {
+			return call(g.origin, args)
+		}
+		userArgs := make([]reflect.Value, 0, len(args)-1)
+		userArgs = append(userArgs, args[:g.dictIndex]...)
+		userArgs = append(userArgs, args[g.dictIndex+1:]...)
+		return call(proxyValue, userArgs)
+	}).Interface()
+}
+
+// originFunc 构造用户可见类型的原函数, 调用时补充字典参数; 字典未知时返回零值
+func (g *genericFunc) originFunc(typ reflect.Type) reflect.Value {
+	if !g.origin.IsValid() || g.dict == 0 {
+		return reflect.Value{}
+	}
+	dict := reflect.ValueOf(g.dict)
+	return reflect.MakeFunc(typ, func(args []reflect.Value) []reflect.Value {
+		shapeArgs := make([]reflect.Value, 0, len(args)+1)
+		shapeArgs = append(shapeArgs, args[:g.dictIndex]...)
+		shapeArgs = append(shapeArgs, dict)
+		shapeArgs = append(shapeArgs, args[g.dictIndex:]...)
+		return call(g.origin, shapeArgs)
+	})
+}
+
+// call 调用函数, 可变参数的最后一个参数为 slice
+func call(f reflect.Value, args []reflect.Value) []reflect.Value {
+	if f.Type().IsVariadic() {
+		return f.CallSlice(args)
+	}
+	return f.Call(args)
+}
+
+// genericProxy 对泛型函数的 shape 函数生成代理
+// 泛型函数只支持自动分配的跳板函数
+func genericProxy(g *genericFunc, proxyFunc interface{}, trampolineFunc interface{}) (*patch.Guard, error) {
+	if reflect.TypeOf(proxyFunc) != g.typ {
+		return nil, fmt.Errorf("proxy func type %s mismatch generic func %s: %s",
+			reflect.TypeOf(proxyFunc), g.name, g.typ)
+	}
+	if trampolineFunc != nil && !isAutoTrampoline(trampolineFunc) {
+		return nil, errors.New("generic func only support automatic trampoline, origin func must be a nil func var")
+	}
+
+	if err := checkInlined(g.shape); err != nil {
+		return nil, err
+	}
+
+	logger.Info("start generic func proxy func=", g.name)
+	patchGuard, err := patch.PtrTrampoline(g.shape, g.proxy(proxyFunc), nil)
+	if err != nil {
+		logger.Error("generic func proxy fail func=", g.name, ":", err)
+		return nil, err
+	}
+	// shape 函数被同一个 shape 的所有实例共享, 其它类型实参的调用需要转发到原函数, 因此必须分配跳板函数
+	fixOrigin, err := patchGuard.FixOrigin()
+	if err != nil {
+		patchGuard.Unpatch()
+		logger.Error("generic func origin is not available func=", g.name, ":", err)
+		return nil, fmt.Errorf("generic func %s origin is not available, "+
+			"calls of other instantiations can not be forwarded: %w", g.name, err)
+	}
+	g.origin = unexports.NewFuncWithCodePtr(g.shapeTyp, fixOrigin)
+	generics.Store(patchGuard, g)
+	patchGuard.OnUnpatch(func() {
+		generics.Delete(patchGuard)
+	})
+
+	if bytecode.IsValidPtr(trampolineFunc) {
+		origin := g.originFunc(g.typ)
+		if !origin.IsValid() {
+			patchGuard.Unpatch()
+			generics.Delete(patchGuard)
+			return nil, errors.New("trampoline is not available, origin func can not be called")
+		}
+		reflect.ValueOf(trampolineFunc).Elem().Set(origin)
+	}
+	logger.Debug("generic func proxy ok func=", g.name)
+	return patchGuard, nil
+}
+
+// isAutoTrampoline 是否自动分配跳板函数
+func isAutoTrampoline(trampolineFunc interface{}) bool {
+	if _, ok := trampolineFunc.(patch.AutoTrampoline); ok {
+		return true
+	}
+	return bytecode.IsValidPtr(trampolineFunc) && reflect.ValueOf(trampolineFunc).Elem().IsNil()
+}
+
+// OriginFunc 根据代理句柄构造可调用的原函数, 没有跳板函数时返回零值
+// 泛型函数的原函数调用时会自动补充字典参数
+func OriginFunc(typ reflect.Type, patchGuard *patch.Guard) reflect.Value {
+	if g, ok := generics.Load(patchGuard); ok {
+		return g.(*genericFunc).originFunc(typ)
+	}
+	if patchGuard.FixOriginFunc() == 0 {
+		return reflect.Value{}
+	}
+	return unexports.NewFuncWithCodePtr(typ, patchGuard.FixOriginFunc())
+}
@@ -0,0 +1,114 @@
+package proxy
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tencent/goom/erro"
+	"github.com/tencent/goom/internal/logger"
+	"github.com/tencent/goom/internal/unexports"
+)
+
+const (
+	// InlineWarn 目标函数被内联时在控制台输出被内联的调用位置(默认)
+	InlineWarn = iota
+	// InlineReport 目标函数被内联时记录被内联的调用位置, 可以通过 InlinedReport 获取
+	InlineReport
+	// InlineStrict 目标函数被内联时返回 erro.FuncInlined 错误, mock 失败
+	InlineStrict
+)
+
+var (
+	// inlineMode 目标函数被内联时的处理模式
+	inlineMode int32 = InlineWarn
+	// reportLock 保护 report 和 reported
+	reportLock sync.Mutex
+	// report 记录的被内联的调用位置
+	report []*InlinedSite
+	// reported 已经记录过的函数名
+	reported = make(map[string]bool)
+)
+
+// InlinedSite 被 mock 的函数被内联的调用位置
+type InlinedSite struct {
+	*unexports.InlinedSite
+	// Func 被内联的函数名
+	Func string
+}
+
+// SetInlineMode 设置目标函数被内联时的处理模式
+func SetInlineMode(mode int) {
+	atomic.StoreInt32(&inlineMode, int32(mode))
+}
+
+// InlinedReport 获取 InlineReport 模式下记录的被内联的调用位置
+func InlinedReport() []*InlinedSite {
+	reportLock.Lock()
+	defer reportLock.Unlock()
+	return append([]*InlinedSite(nil), report...)
+}
+
+// InlinedError 检查函数是否被内联到其它函数中, 被内联时返回 erro.FuncInlined 错误, 否则返回 nil
+// funcPtr 函数地址
+func InlinedError(funcPtr uintptr) error {
+	name, sites := inlinedSites(funcPtr)
+	if len(sites) == 0 {
+		return nil
+	}
+	return newInlinedError(name, sites)
+}
+
+// inlinedSites 获取函数被内联的调用位置
+func inlinedSites(funcPtr uintptr) (string, []*unexports.InlinedSite) {
+	name := unexports.FuncNameForPC(funcPtr)
+	sites, err := unexports.FindInlinedSites(name)
+	if err != nil {
+		logger.Debugf("find inlined sites of %s error: %v", name, err)
+		return name, nil
+	}
+	return name, sites
+}
+
+// newInlinedError 构造函数被内联的错误
+func newInlinedError(name string, sites []*unexports.InlinedSite) error {
+	callers := make([]string, 0, len(sites))
+	for _, site := range sites {
+		callers = append(callers, fmt.Sprintf("%s (%s:%d)", site.Caller, site.File, site.Line))
+	}
+	return erro.NewFuncInlinedError(name, callers)
+}
+
+// checkInlined 检查函数是否被内联, 被内联的调用位置 mock 不会生效, 按照 inlineMode 进行处理
+// 只有 InlineStrict 模式下会返回错误
+func checkInlined(funcPtr uintptr) error {
+	name, sites := inlinedSites(funcPtr)
+	if len(sites) == 0 {
+		return nil
+	}
+	err := newInlinedError(name, sites)
+	switch atomic.LoadInt32(&inlineMode) {
+	case InlineReport:
+		record(name, sites)
+		logger.Debug(err)
+	case InlineStrict:
+		return err
+	default:
+		logger.Error(err)
+		logger.Consolef(logger.ErrorLevel, "%v", err)
+	}
+	return nil
+}
+
+// record 记录被内联的调用位置, 同一个函数只记录一次
+func record(name string, sites []*unexports.InlinedSite) {
+	reportLock.Lock()
+	defer reportLock.Unlock()
+	if reported[name] {
+		return
+	}
+	reported[name] = true
+	for _, site := range sites {
+		report = append(report, &InlinedSite{InlinedSite: site, Func: name})
+	}
+}
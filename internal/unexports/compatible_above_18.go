@@ -5,9 +5,52 @@
 // 基于 github.com/alangpierce/go-forceexport 进行了修改和扩展。
 package unexports
 
-import "github.com/tencent/goom/internal/hack"
+import (
+	"runtime"
+	"unsafe"
+
+	"github.com/tencent/goom/internal/hack"
+)
 
 // checkOverflow 检查 hack ftab 数据的是否正确, 一般溢出则不正确
 func checkOverflow(ftab hack.Functab, moduleData *hack.Moduledata) bool {
 	return ftab.Funcoff >= uint32(len(moduleData.Pclntable))
 }
+
+// rawFuncName 获取函数的完整名称, 泛型函数的类型参数不会被省略(runtime.Func.Name 会省略为[...])
+func rawFuncName(f *runtime.Func, moduleData *hack.Moduledata) string {
+	// runtime._func 的结构为: entryOff uint32, nameOff int32, ...
+	nameOff := *(*int32)(unsafe.Pointer(uintptr(unsafe.Pointer(f)) + 4))
+	if name := moduleData.FuncName(nameOff); name != "" {
+		return name
+	}
+	return funcName(f)
+}
+
+// FuncArgsSize 获取函数地址对应的函数参数和返回值在栈上的大小(按 ABI0 布局), 未知时返回 false
+func FuncArgsSize(entry uintptr) (int32, bool) {
+	f := runtime.FuncForPC(entry)
+	if f == nil || f.Entry() != entry {
+		return 0, false
+	}
+	// runtime._func 的结构为: entryOff uint32, nameOff int32, args int32, ...
+	args := *(*int32)(unsafe.Pointer(uintptr(unsafe.Pointer(f)) + 8))
+	return args, args >= 0
+}
+
+// FuncNameForPC 获取函数地址对应的完整函数名称, 泛型函数的类型参数不会被省略
+func FuncNameForPC(pc uintptr) string {
+	f := runtime.FuncForPC(pc)
+	if f == nil {
+		return ""
+	}
+	if hack.CheckLayout() != nil {
+		return funcName(f)
+	}
+	for moduleData := &hack.Firstmoduledata; moduleData != nil; moduleData = moduleData.Next {
+		if moduleData.Contains(pc) {
+			return rawFuncName(f, moduleData)
+		}
+	}
+	return funcName(f)
+}
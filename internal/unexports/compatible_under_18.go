@@ -3,9 +3,39 @@
 
 package unexports
 
-import "github.com/tencent/goom/internal/hack"
+import (
+	"runtime"
+	"unsafe"
+
+	"github.com/tencent/goom/internal/hack"
+)
 
 // checkOverflow 检查 hack ftab 数据的是否正确, 一般溢出则不正确
 func checkOverflow(ftab hack.Functab, moduleData *hack.Moduledata) bool {
 	return ftab.Funcoff >= uintptr(len(moduleData.Pclntable))
 }
+
+// rawFuncName 获取函数的完整名称, go1.18 以下没有泛型函数, 和 runtime.Func.Name 一致
+func rawFuncName(f *runtime.Func, _ *hack.Moduledata) string {
+	return funcName(f)
+}
+
+// FuncArgsSize 获取函数地址对应的函数参数和返回值在栈上的大小, 未知时返回 false
+func FuncArgsSize(entry uintptr) (int32, bool) {
+	f := runtime.FuncForPC(entry)
+	if f == nil || f.Entry() != entry {
+		return 0, false
+	}
+	// runtime._func 的结构为: entry uintptr, nameoff int32, args int32, ...
+	args := *(*int32)(unsafe.Pointer(uintptr(unsafe.Pointer(f)) + unsafe.Sizeof(uintptr(0)) + 4))
+	return args, args >= 0
+}
+
+// FuncNameForPC 获取函数地址对应的完整函数名称
+func FuncNameForPC(pc uintptr) string {
+	f := runtime.FuncForPC(pc)
+	if f == nil {
+		return ""
+	}
+	return funcName(f)
+}
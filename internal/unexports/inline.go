@@ -0,0 +1,70 @@
+//go:build go1.18
+// +build go1.18
+
+package unexports
+
+import (
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"github.com/tencent/goom/internal/hack"
+)
+
+// inlinedSitesCache 函数被内联的位置缓存, key 为函数名
+var inlinedSitesCache sync.Map
+
+// InlinedSite 函数被内联到调用方函数中的位置
+type InlinedSite struct {
+	// Caller 调用方函数名
+	Caller string
+	// CallerEntry 调用方函数入口地址
+	CallerEntry uintptr
+	// CallPC 调用位置的指令地址
+	CallPC uintptr
+	// File 调用位置的源码文件
+	File string
+	// Line 调用位置的源码行号
+	Line int
+}
+
+// FindInlinedSites 根据函数运行时内联树(FUNCDATA_InlTree)查找函数被内联到其它函数中的位置
+// name 函数的完整名称, 比如: github.com/tencent/goom/test.Foo
+func FindInlinedSites(name string) ([]*InlinedSite, error) {
+	if err := hack.CheckLayout(); err != nil {
+		return nil, err
+	}
+	if sites, ok := inlinedSitesCache.Load(name); ok {
+		return sites.([]*InlinedSite), nil
+	}
+
+	var sites []*InlinedSite
+	for moduleData := &hack.Firstmoduledata; moduleData != nil; moduleData = moduleData.Next {
+		for _, ftab := range moduleData.Ftab {
+			if checkOverflow(ftab, moduleData) {
+				break
+			}
+			fp := unsafe.Pointer(&moduleData.Pclntable[ftab.Funcoff])
+			for _, call := range moduleData.InlineTree(fp) {
+				if !moduleData.FuncNameEquals(call.NameOff, name) {
+					continue
+				}
+				sites = append(sites, newInlinedSite((*runtime.Func)(fp), moduleData, call))
+			}
+		}
+	}
+	inlinedSitesCache.Store(name, sites)
+	return sites, nil
+}
+
+// newInlinedSite 构造被内联的位置
+func newInlinedSite(f *runtime.Func, moduleData *hack.Moduledata, call hack.InlinedCall) *InlinedSite {
+	entry := f.Entry()
+	site := &InlinedSite{
+		Caller:      rawFuncName(f, moduleData),
+		CallerEntry: entry,
+		CallPC:      entry + uintptr(call.ParentPc),
+	}
+	site.File, site.Line = f.FileLine(site.CallPC)
+	return site
+}
@@ -0,0 +1,54 @@
+//go:build go1.18
+// +build go1.18
+
+package unexports
+
+import (
+	"strings"
+	"testing"
+)
+
+// inlineSum 可以被内联的函数
+func inlineSum(m map[string]int) int {
+	sum := 0
+	for _, v := range m {
+		sum += v
+	}
+	return sum
+}
+
+// TestFindInlinedSites 测试查找函数被内联的位置
+func TestFindInlinedSites(t *testing.T) {
+	if inlineSum(map[string]int{"a": 1, "b": 2}) != 3 {
+		t.Fatal("inlineSum result error")
+	}
+	sites, err := FindInlinedSites("github.com/tencent/goom/internal/unexports.inlineSum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sites) == 0 {
+		t.Skip("inlining is disabled")
+	}
+	for _, site := range sites {
+		if site.Caller != "github.com/tencent/goom/internal/unexports.TestFindInlinedSites" {
+			t.Fatalf("caller error: %s", site.Caller)
+		}
+		if !strings.HasSuffix(site.File, "inline_test.go") || site.Line == 0 {
+			t.Fatalf("call site error: %s:%d", site.File, site.Line)
+		}
+		if site.CallPC <= site.CallerEntry {
+			t.Fatalf("call pc error: 0x%x", site.CallPC)
+		}
+	}
+}
+
+// TestFindInlinedSitesNotFound 测试没有被内联的函数
+func TestFindInlinedSitesNotFound(t *testing.T) {
+	sites, err := FindInlinedSites("github.com/tencent/goom/internal/unexports.notExistFunc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sites) != 0 {
+		t.Fatalf("sites must be empty: %d", len(sites))
+	}
+}
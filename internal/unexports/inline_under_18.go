@@ -0,0 +1,23 @@
+//go:build !go1.18
+// +build !go1.18
+
+package unexports
+
+// InlinedSite 函数被内联到调用方函数中的位置
+type InlinedSite struct {
+	// Caller 调用方函数名
+	Caller string
+	// CallerEntry 调用方函数入口地址
+	CallerEntry uintptr
+	// CallPC 调用位置的指令地址
+	CallPC uintptr
+	// File 调用位置的源码文件
+	File string
+	// Line 调用位置的源码行号
+	Line int
+}
+
+// FindInlinedSites 查找函数被内联到其它函数中的位置, go1.18 以下暂不支持, 总是返回空
+func FindInlinedSites(_ string) ([]*InlinedSite, error) {
+	return nil, nil
+}
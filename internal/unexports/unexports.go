@@ -25,6 +25,9 @@ func FindFuncByName(name string) (uintptr, error) {
 	if len(name) == 0 {
 		return 0, errors.New("FindFuncByName error: func name is empty")
 	}
+	if err := hack.CheckLayout(); err != nil {
+		return 0, err
+	}
 
 	suggester := newSuggester(name)
 	for moduleData := &hack.Firstmoduledata; moduleData != nil; moduleData = moduleData.Next {
@@ -41,7 +44,8 @@ func FindFuncByName(name string) (uintptr, error) {
 				continue
 			}
 
-			fName := funcName(f)
+			// 泛型函数需要使用完整名称匹配, 比如: pkg.Map[int,string] 或 pkg.Map[go.shape.int,go.shape.string]
+			fName := rawFuncName(f, moduleData)
 			if fName == name {
 				return f.Entry(), nil
 			}
@@ -70,6 +74,9 @@ func funcName(f *runtime.Func) string {
 // 返回: *runtime.Func 函数信息结构体
 // string 函数名
 func FindFuncByPtr(ptr uintptr) (*runtime.Func, string, error) {
+	if err := hack.CheckLayout(); err != nil {
+		return nil, "", err
+	}
 	for moduleData := &hack.Firstmoduledata; moduleData != nil; moduleData = moduleData.Next {
 		for _, ftab := range moduleData.Ftab {
 			if checkOverflow(ftab, moduleData) {
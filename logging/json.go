@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// jsonHandler 以 JSON Lines 的格式输出事件
+type jsonHandler struct {
+	lock  sync.Mutex
+	w     io.Writer
+	level Level
+}
+
+// NewJSONHandler 创建以 JSON Lines 格式输出事件的 Handler, 只输出不低于 level 的事件
+// 每个事件输出一行, 格式同 slog.JSONHandler, 比如:
+// {"time":"...","level":"DEBUG","msg":"mocker apply","type":"apply","mocker":"pkg.Foo"}
+func NewJSONHandler(w io.Writer, level Level) Handler {
+	return &jsonHandler{w: w, level: level}
+}
+
+// Enabled 是否输出该级别的事件
+func (h *jsonHandler) Enabled(level Level) bool {
+	return level >= h.level
+}
+
+// Handle 输出事件, 字段值不能序列化为 JSON 时使用 %v 格式化
+func (h *jsonHandler) Handle(e *Event) {
+	buf := make([]byte, 0, 256)
+	buf = append(buf, '{')
+	buf = appendField(buf, "time", e.Time.Format(time.RFC3339Nano), true)
+	buf = appendField(buf, "level", e.Level.String(), false)
+	buf = appendField(buf, "msg", e.Message, false)
+	buf = appendField(buf, "type", string(e.Type), false)
+	for _, a := range e.Attrs {
+		buf = appendField(buf, a.Key, a.Value, false)
+	}
+	buf = append(buf, '}', '\n')
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	_, _ = h.w.Write(buf)
+}
+
+// appendField 追加 JSON 字段
+func appendField(buf []byte, key string, value interface{}, first bool) []byte {
+	if !first {
+		buf = append(buf, ',')
+	}
+	k, _ := json.Marshal(key)
+	buf = append(append(buf, k...), ':')
+	v, err := json.Marshal(value)
+	if err != nil {
+		v, _ = json.Marshal(fmt.Sprintf("%v", value))
+	}
+	return append(buf, v...)
+}
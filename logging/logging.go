@@ -0,0 +1,139 @@
+// Package logging 定义了 goom 的结构化日志: mock 的应用、取消、调用、条件匹配、指令替换等事件以类型和字段的形式
+// 输出到自定义的 Handler, 方便接入测试中已有的结构化日志; go1.21 及以上版本可以通过 SlogHandler 直接输出到 log/slog。
+// 没有设置 Handler 时, 事件只有一次原子读取的开销。
+package logging
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Level 事件的日志级别, 取值和 log/slog 的 slog.Level 一致
+type Level int
+
+// 日志级别定义
+const (
+	LevelDebug Level = -4 // 调试信息, 比如 mock 的调用、条件匹配
+	LevelInfo  Level = 0  // 日常使用关键信息
+	LevelWarn  Level = 4  // 警告级别信息
+	LevelError Level = 8  // 错误级别信息
+)
+
+// String 日志级别的名称, 同 slog.Level
+func (l Level) String() string {
+	switch {
+	case l >= LevelError:
+		return "ERROR"
+	case l >= LevelWarn:
+		return "WARN"
+	case l >= LevelInfo:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
+
+// Type 事件类型
+type Type string
+
+// 事件类型定义
+const (
+	Log     Type = "log"     // goom 内部的文本日志
+	Apply   Type = "apply"   // mocker 应用, 字段: mocker
+	Cancel  Type = "cancel"  // mocker 被取消(Cancel 或 Builder.Reset), 字段: mocker
+	Call    Type = "call"    // mock 被调用, 字段: mocker、args、results
+	Match   Type = "match"   // 参数匹配了 When 条件, 字段: mocker、condition、args
+	Miss    Type = "miss"    // 参数没有匹配任何 When 条件, 字段: mocker、args
+	Patch   Type = "patch"   // 替换函数的指令, 字段: origin、bytes、originBytes
+	Unpatch Type = "unpatch" // 还原函数的指令, 字段: origin、bytes
+)
+
+// 事件字段名称定义
+const (
+	KeyMocker      = "mocker"      // mocker 的名称
+	KeyArgs        = "args"        // 调用的参数
+	KeyResults     = "results"     // 调用的返回值
+	KeyCondition   = "condition"   // 匹配的条件
+	KeyOrigin      = "origin"      // 被替换指令的函数地址
+	KeyBytes       = "bytes"       // 写入的指令
+	KeyOriginBytes = "originBytes" // 被替换的原始指令
+)
+
+// Attr 事件字段
+type Attr struct {
+	Key   string
+	Value interface{}
+}
+
+// Any 创建事件字段, 同 slog.Any
+func Any(key string, value interface{}) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// Event 结构化日志事件
+type Event struct {
+	Time    time.Time
+	Level   Level
+	Type    Type
+	Message string
+	Attrs   []Attr
+}
+
+// Handler 事件的输出, 方法的语义同 slog.Handler
+type Handler interface {
+	// Enabled 是否输出该级别的事件, 返回 false 时不会构造事件
+	Enabled(level Level) bool
+	// Handle 输出事件, 可能在被 mock 函数的调用中并发执行
+	Handle(e *Event)
+}
+
+// holder atomic.Value 要求存储相同的具体类型
+type holder struct {
+	handler Handler
+}
+
+var (
+	// enabled 是否设置了 Handler
+	enabled int32
+	handler atomic.Value
+)
+
+// SetHandler 设置事件的输出, 为 nil 时关闭结构化日志; 之前设置的 Handler 会被覆盖
+// 注意: mock 的调用事件(Call)在 Apply 时决定是否拦截, 需要在 mock 之前设置
+func SetHandler(h Handler) {
+	handler.Store(holder{handler: h})
+	if h != nil {
+		atomic.StoreInt32(&enabled, 1)
+	} else {
+		atomic.StoreInt32(&enabled, 0)
+	}
+}
+
+// Enabled 是否输出该级别的事件
+func Enabled(level Level) bool {
+	if atomic.LoadInt32(&enabled) == 0 {
+		return false
+	}
+	h := current()
+	return h != nil && h.Enabled(level)
+}
+
+// Emit 输出事件, 没有设置 Handler 或者级别未开启时忽略
+func Emit(level Level, typ Type, msg string, attrs ...Attr) {
+	if atomic.LoadInt32(&enabled) == 0 {
+		return
+	}
+	h := current()
+	if h == nil || !h.Enabled(level) {
+		return
+	}
+	h.Handle(&Event{Time: time.Now(), Level: level, Type: typ, Message: msg, Attrs: attrs})
+}
+
+// current 获取当前的 Handler
+func current() Handler {
+	if h, ok := handler.Load().(holder); ok {
+		return h.handler
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+//go:build go1.21
+// +build go1.21
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+// slogHandler 将事件输出到 slog.Handler
+type slogHandler struct {
+	h slog.Handler
+}
+
+// SlogHandler 将事件输出到 log/slog 的 Handler, 事件类型输出为 type 字段, 比如:
+// logging.SetHandler(logging.SlogHandler(slog.Default().Handler()))
+func SlogHandler(h slog.Handler) Handler {
+	return &slogHandler{h: h}
+}
+
+// Enabled 是否输出该级别的事件
+func (s *slogHandler) Enabled(level Level) bool {
+	return s.h.Enabled(context.Background(), slog.Level(level))
+}
+
+// Handle 输出事件
+func (s *slogHandler) Handle(e *Event) {
+	r := slog.NewRecord(e.Time, slog.Level(e.Level), e.Message, 0)
+	r.AddAttrs(slog.String("type", string(e.Type)))
+	for _, a := range e.Attrs {
+		r.AddAttrs(slog.Any(a.Key, a.Value))
+	}
+	_ = s.h.Handle(context.Background(), r)
+}
@@ -0,0 +1,43 @@
+//go:build go1.21
+// +build go1.21
+
+// Package mocker_test 对 mocker 包的测试
+// 当前文件实现了结构化日志输出到 log/slog 的单测
+package mocker_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	mocker "github.com/tencent/goom"
+	"github.com/tencent/goom/logging"
+	"github.com/tencent/goom/test"
+)
+
+// TestUnitSlogTestSuite 测试入口
+func TestUnitSlogTestSuite(t *testing.T) {
+	suite.Run(t, new(slogTestSuite))
+}
+
+// slogTestSuite 输出到 log/slog 的测试套件
+type slogTestSuite struct {
+	suite.Suite
+}
+
+// TestSlogHandler 测试事件输出到 slog.Handler
+func (s *slogTestSuite) TestSlogHandler() {
+	var buf bytes.Buffer
+	logging.SetHandler(logging.SlogHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer logging.SetHandler(nil)
+
+	mock := mocker.Create()
+	defer mock.Reset()
+	mock.Func(test.Divide).Return(0, nil).When(1, 2).Return(10, nil)
+	_, _ = test.Divide(1, 2)
+
+	s.Contains(buf.String(), `level=DEBUG msg="condition matched" type=match mocker=github.com/tencent/goom/test.Divide`+
+		` args=1,2 condition="When(1, 2)"`)
+}
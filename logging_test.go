@@ -0,0 +1,152 @@
+// Package mocker_test 对 mocker 包的测试
+// 当前文件实现了对 logging 包结构化日志事件的单测
+package mocker_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	mocker "github.com/tencent/goom"
+	"github.com/tencent/goom/logging"
+	"github.com/tencent/goom/test"
+)
+
+// TestUnitLoggingTestSuite 测试入口
+func TestUnitLoggingTestSuite(t *testing.T) {
+	suite.Run(t, new(loggingTestSuite))
+}
+
+// loggingTestSuite 结构化日志测试套件
+type loggingTestSuite struct {
+	suite.Suite
+}
+
+// recordHandler 记录事件的 Handler
+type recordHandler struct {
+	lock   sync.Mutex
+	events []*logging.Event
+}
+
+// Enabled 输出所有级别的事件
+func (h *recordHandler) Enabled(logging.Level) bool {
+	return true
+}
+
+// Handle 记录事件
+func (h *recordHandler) Handle(e *logging.Event) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.events = append(h.events, e)
+}
+
+// find 获取指定类型和 mocker 的第一个事件的字段
+func (h *recordHandler) find(typ logging.Type, mocker string) map[string]interface{} {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for _, e := range h.events {
+		attrs := make(map[string]interface{}, len(e.Attrs))
+		for _, a := range e.Attrs {
+			attrs[a.Key] = a.Value
+		}
+		if e.Type == typ && (mocker == "" || attrs[logging.KeyMocker] == mocker) {
+			return attrs
+		}
+	}
+	return nil
+}
+
+// TestEvents 测试 mock 的应用、匹配、调用、取消和指令替换事件
+func (s *loggingTestSuite) TestEvents() {
+	h := &recordHandler{}
+	logging.SetHandler(h)
+	defer logging.SetHandler(nil)
+
+	const name = "github.com/tencent/goom/test.Divide"
+	mock := mocker.Create()
+	mock.Func(test.Divide).Return(0, nil).When(1, 2).Return(10, nil)
+	_, _ = test.Divide(1, 2)
+	_, _ = test.Divide(3, 4)
+	mock.Reset()
+
+	s.NotNil(h.find(logging.Apply, name))
+	s.Equal(map[string]interface{}{
+		logging.KeyMocker: name, logging.KeyArgs: "1,2", logging.KeyCondition: "When(1, 2)",
+	}, h.find(logging.Match, name))
+	s.Equal(map[string]interface{}{logging.KeyMocker: name, logging.KeyArgs: "3,4"}, h.find(logging.Miss, name))
+	s.Equal(map[string]interface{}{
+		logging.KeyMocker: name, logging.KeyArgs: "1,2", logging.KeyResults: "10,nil",
+	}, h.find(logging.Call, name))
+	s.NotNil(h.find(logging.Cancel, name))
+
+	patch := h.find(logging.Patch, "")
+	s.Require().NotNil(patch)
+	s.True(strings.HasPrefix(patch[logging.KeyOrigin].(string), "0x"))
+	s.NotEmpty(patch[logging.KeyBytes])
+	s.NotNil(h.find(logging.Unpatch, ""))
+}
+
+// TestCancel 测试直接取消 mocker 时输出取消事件, 并且只输出一次
+func (s *loggingTestSuite) TestCancel() {
+	h := &recordHandler{}
+	logging.SetHandler(h)
+	defer logging.SetHandler(nil)
+
+	const name = "github.com/tencent/goom/test.Join"
+	mock := mocker.Create()
+	m := mock.Func(test.Join)
+	m.Return("x")
+	m.Cancel()
+	s.NotNil(h.find(logging.Cancel, name))
+	mock.Reset()
+
+	var cancels int
+	for _, e := range h.events {
+		if e.Type == logging.Cancel {
+			cancels++
+		}
+	}
+	s.Equal(1, cancels)
+}
+
+// TestJSONHandler 测试以 JSON Lines 格式输出事件
+func (s *loggingTestSuite) TestJSONHandler() {
+	var buf bytes.Buffer
+	logging.SetHandler(logging.NewJSONHandler(&buf, logging.LevelDebug))
+	defer logging.SetHandler(nil)
+
+	mock := mocker.Create()
+	mock.Func(test.Join).Return("x")
+	s.Equal("x", test.Join(nil, ","))
+	mock.Reset()
+
+	var types []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e map[string]interface{}
+		s.Require().NoError(json.Unmarshal([]byte(line), &e), line)
+		s.NotEmpty(e["time"])
+		s.NotEmpty(e["level"])
+		if e["mocker"] == "github.com/tencent/goom/test.Join" {
+			types = append(types, e["type"].(string))
+		}
+	}
+	s.Equal([]string{"apply", "call", "cancel"}, types)
+}
+
+// TestLevel 测试未开启的级别不输出
+func (s *loggingTestSuite) TestLevel() {
+	var buf bytes.Buffer
+	logging.SetHandler(logging.NewJSONHandler(&buf, logging.LevelError))
+	defer logging.SetHandler(nil)
+
+	s.False(logging.Enabled(logging.LevelDebug))
+	s.True(logging.Enabled(logging.LevelError))
+	logging.Emit(logging.LevelInfo, logging.Log, "ignored")
+	logging.Emit(logging.LevelError, logging.Log, "failed", logging.Any("code", 1))
+	s.Equal(1, strings.Count(buf.String(), "\n"))
+	s.Contains(buf.String(), `"level":"ERROR","msg":"failed","type":"log","code":1}`)
+}
@@ -3,6 +3,8 @@ package mocker
 import (
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 	"sync/atomic"
 
 	"github.com/tencent/goom/arg"
@@ -10,11 +12,21 @@ import (
 
 // BaseMatcher 参数匹配基类
 type BaseMatcher struct {
+	// lock 保护 results, 使得追加返回值和读取返回值可以并发执行
+	lock    sync.RWMutex
 	results [][]reflect.Value
 	curNum  int32
 	funTyp  reflect.Type
 	// resultsPtr 持有参数指针, 防止被回收
 	resultsPtr []interface{}
+	// callOrigin 匹配成功时是否调用原函数
+	callOrigin bool
+	// argSetters 匹配成功时修改参数
+	argSetters []*argSetter
+	// imp 匹配成功时调用的回调函数
+	imp reflect.Value
+	// hits 匹配成功的次数
+	hits int32
 }
 
 // newBaseMatcher 创建新参数匹配基类
@@ -34,23 +46,101 @@ func newBaseMatcher(results []interface{}, funTyp reflect.Type) *BaseMatcher {
 
 // Result 回参
 func (c *BaseMatcher) Result() []reflect.Value {
-	if len(c.results) <= 1 {
-		return c.results[c.curNum]
+	c.lock.RLock()
+	results := c.results
+	c.lock.RUnlock()
+
+	if len(results) <= 1 {
+		return results[0]
 	}
 
 	curNum := atomic.LoadInt32(&c.curNum)
-	if length := len(c.results); curNum >= int32(length) {
-		return c.results[length-1]
+	if length := len(results); curNum >= int32(length) {
+		return results[length-1]
 	}
 
 	atomic.AddInt32(&c.curNum, 1)
-	return c.results[curNum]
+	return results[curNum]
+}
+
+// hit 记录一次匹配成功
+func (c *BaseMatcher) hit() {
+	atomic.AddInt32(&c.hits, 1)
+}
+
+// hitCount 匹配成功的次数
+func (c *BaseMatcher) hitCount() int {
+	return int(atomic.LoadInt32(&c.hits))
+}
+
+// unusedResults 按顺序返回的返回值中没有被使用过的个数, 只有一个返回值时返回 0
+func (c *BaseMatcher) unusedResults() (unused int, total int) {
+	c.lock.RLock()
+	total = len(c.results)
+	c.lock.RUnlock()
+	if total <= 1 {
+		return 0, total
+	}
+	used := int(atomic.LoadInt32(&c.curNum))
+	if used > total {
+		used = total
+	}
+	return total - used, total
+}
+
+// setCallOrigin 设置匹配成功时调用原函数
+func (c *BaseMatcher) setCallOrigin() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.callOrigin = true
+}
+
+// shouldCallOrigin 匹配成功时是否调用原函数
+func (c *BaseMatcher) shouldCallOrigin() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.callOrigin
+}
+
+// setImp 设置匹配成功时调用的回调函数
+func (c *BaseMatcher) setImp(imp reflect.Value) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.imp = imp
+}
+
+// matchedImp 匹配成功时调用的回调函数, 没有设置时返回零值
+func (c *BaseMatcher) matchedImp() reflect.Value {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.imp
+}
+
+// addArgSetter 添加参数修改器
+func (c *BaseMatcher) addArgSetter(setter *argSetter) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.argSetters = append(c.argSetters, setter)
+}
+
+// setArgs 修改调用参数
+func (c *BaseMatcher) setArgs(args []reflect.Value) {
+	c.lock.RLock()
+	setters := c.argSetters
+	c.lock.RUnlock()
+
+	for _, setter := range setters {
+		setter.set(args)
+	}
 }
 
 // AddResult 添加结果
 func (c *BaseMatcher) AddResult(results []interface{}) {
 	// TODO results check
-	c.results = append(c.results, arg.I2V(results, outTypes(c.funTyp)))
+	resultVs := arg.I2V(results, outTypes(c.funTyp))
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.results = append(c.results, resultVs)
 }
 
 // EmptyMatch 没有返回参数的匹配器
@@ -59,8 +149,12 @@ type EmptyMatch struct {
 }
 
 // newEmptyMatch 创建无参数匹配器
-func newEmptyMatch() *EmptyMatch {
-	return &EmptyMatch{}
+func newEmptyMatch(funTyp reflect.Type) *EmptyMatch {
+	return &EmptyMatch{
+		AlwaysMatcher: &AlwaysMatcher{
+			BaseMatcher: newBaseMatcher(nil, funTyp),
+		},
+	}
 }
 
 // Result 返回参数
@@ -115,6 +209,15 @@ func (c *DefaultMatcher) Match(args []reflect.Value) bool {
 	return true
 }
 
+// String 条件的描述
+func (c *DefaultMatcher) String() string {
+	s := make([]string, len(c.exprs))
+	for i, expr := range c.exprs {
+		s[i] = arg.Sprint(expr)
+	}
+	return "When(" + strings.Join(s, ", ") + ")"
+}
+
 // ContainsMatcher 包含类型的参数匹配
 // 当参数为多个时, In 的每个条件各使用一个数组表示:
 // .In([]interface{}{3, Any()}, []interface{}{4, Any()})
@@ -153,6 +256,11 @@ func (c *ContainsMatcher) Match(args []reflect.Value) bool {
 	return v
 }
 
+// String 条件的描述
+func (c *ContainsMatcher) String() string {
+	return "In(" + strings.TrimSuffix(strings.TrimPrefix(c.expr.String(), "arg.In("), ")") + ")"
+}
+
 // AlwaysMatcher 默认匹配
 type AlwaysMatcher struct {
 	*BaseMatcher
@@ -172,3 +280,8 @@ func newAlwaysMatch(results []interface{}, funTyp reflect.Type) *AlwaysMatcher {
 func (c *AlwaysMatcher) Match(_ []reflect.Value) bool {
 	return true
 }
+
+// String 条件的描述
+func (c *AlwaysMatcher) String() string {
+	return "default"
+}
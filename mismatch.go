@@ -0,0 +1,133 @@
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件实现了参数没有匹配任何 When 条件时的诊断信息: 输出 mocker、实际的参数,
+// 以及每个条件中匹配失败的参数位置、期望值和实际值, 最接近的条件排在最前面。
+package mocker
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/tencent/goom/arg"
+)
+
+// argMismatch 一个参数位置的匹配失败
+type argMismatch struct {
+	// pos 参数位置, 不包括方法的接收体; 为 -1 时表示所有参数
+	pos      int
+	expected string
+	actual   string
+}
+
+// String 匹配失败的描述
+func (m *argMismatch) String() string {
+	if m.pos < 0 {
+		return fmt.Sprintf("args expected %s, actual (%s)", m.expected, m.actual)
+	}
+	return fmt.Sprintf("arg[%d] expected %s, actual %s", m.pos, m.expected, m.actual)
+}
+
+// mismatchMatcher 可以诊断匹配失败原因的参数匹配
+type mismatchMatcher interface {
+	// mismatches 参数中匹配失败的位置, 匹配成功时返回空
+	mismatches(args []reflect.Value) []*argMismatch
+}
+
+// mismatches 参数中匹配失败的位置
+func (c *DefaultMatcher) mismatches(args []reflect.Value) []*argMismatch {
+	if c.isMethod {
+		args = args[1:]
+	}
+	if len(args) != len(c.exprs) {
+		return []*argMismatch{{pos: -1, expected: fmt.Sprintf("%d args", len(c.exprs)), actual: arg.SprintV(args)}}
+	}
+	var result []*argMismatch
+	for i, expr := range c.exprs {
+		actual := arg.SprintV(args[i : i+1])
+		v, err := expr.Eval([]reflect.Value{args[i]})
+		if err != nil {
+			result = append(result, &argMismatch{pos: i, expected: arg.Sprint(expr), actual: actual + ", error: " + err.Error()})
+		} else if !v {
+			result = append(result, &argMismatch{pos: i, expected: arg.Sprint(expr), actual: actual})
+		}
+	}
+	return result
+}
+
+// mismatches 参数中匹配失败的位置, In 条件作为整体比较所有参数
+func (c *ContainsMatcher) mismatches(args []reflect.Value) []*argMismatch {
+	if c.isMethod {
+		args = args[1:]
+	}
+	if ok, err := c.expr.Eval(args); err == nil && ok {
+		return nil
+	}
+	return []*argMismatch{{pos: -1, expected: c.expr.String(), actual: arg.SprintV(args)}}
+}
+
+// condMismatch 一个条件的匹配失败
+type condMismatch struct {
+	cond string
+	args []*argMismatch
+	// distance 和参数的差距, 即匹配失败的参数个数
+	distance int
+}
+
+// mismatchMessage 参数没有匹配任何条件并且没有默认返回值时的诊断信息
+func (w *When) mismatchMessage(args []reflect.Value, matches []Matcher) string {
+	var b strings.Builder
+	b.WriteString(noMatchMessage(w.name(), args))
+	b.WriteString("\n")
+
+	nArgs := len(args)
+	if w.isMethod && nArgs > 0 {
+		nArgs--
+	}
+	var (
+		conds []*condMismatch
+		seen  = make(map[Matcher]bool, len(matches))
+	)
+	for _, c := range matches {
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		m, ok := c.(mismatchMatcher)
+		if !ok {
+			continue
+		}
+		mismatches := m.mismatches(args)
+		distance := len(mismatches)
+		if len(mismatches) == 1 && mismatches[0].pos < 0 {
+			distance = nArgs
+		}
+		conds = append(conds, &condMismatch{cond: describe(c), args: mismatches, distance: distance})
+	}
+	sort.SliceStable(conds, func(i, j int) bool {
+		return conds[i].distance < conds[j].distance
+	})
+	for _, c := range conds {
+		s := make([]string, len(c.args))
+		for i, m := range c.args {
+			s[i] = m.String()
+		}
+		fmt.Fprintf(&b, "  %s: %s\n", c.cond, strings.Join(s, "; "))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// noMatchMessage 参数没有匹配任何条件时诊断信息的开头: mocker 的名称和实际的参数
+func noMatchMessage(name string, args []reflect.Value) string {
+	return fmt.Sprintf("mocker [%s]: there is no suitable condition matched, "+
+		"or set default return with: mocker.Return(...)\n  actual args: (%s)", name, arg.SprintV(args))
+}
+
+// name 条件所属 mocker 的名称, 没有 mocker 时使用函数的类型
+func (w *When) name() string {
+	if w.ExportedMocker != nil {
+		return w.ExportedMocker.String()
+	}
+	return w.funcTyp.String()
+}
@@ -0,0 +1,64 @@
+// Package mocker_test 对 mocker 包的测试
+// 当前文件实现了对 mismatch.go 的单测
+package mocker_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	mocker "github.com/tencent/goom"
+	"github.com/tencent/goom/arg"
+	"github.com/tencent/goom/test"
+)
+
+// TestUnitMismatchTestSuite 测试入口
+func TestUnitMismatchTestSuite(t *testing.T) {
+	suite.Run(t, new(mismatchTestSuite))
+}
+
+// mismatchTestSuite 条件匹配失败诊断测试套件
+type mismatchTestSuite struct {
+	suite.Suite
+}
+
+// TestFunc 测试函数的参数没有匹配任何条件时的诊断信息, 最接近的条件排在最前面
+func (s *mismatchTestSuite) TestFunc() {
+	mock := mocker.Create()
+	defer mock.Reset()
+
+	mock.Func(test.Divide).
+		When(1, arg.In(1, 2)).Return(1, nil).
+		When(3, arg.In(1, 2)).Return(2, nil).
+		In([]interface{}{4, 4}, []interface{}{5, 5}).Return(3, nil)
+
+	s.Equal(`mocker [github.com/tencent/goom/test.Divide]: there is no suitable condition matched, `+
+		`or set default return with: mocker.Return(...)
+  actual args: (3,3)
+  When(3, arg.In(1, 2)): arg[1] expected arg.In(1, 2), actual 3
+  When(1, arg.In(1, 2)): arg[0] expected 1, actual 3; arg[1] expected arg.In(1, 2), actual 3
+  In([4, 4], [5, 5]): args expected arg.In([4, 4], [5, 5]), actual (3,3)`,
+		panicMessage(func() { _, _ = test.Divide(3, 3) }))
+}
+
+// TestMethod 测试方法的参数位置不包括接收体
+func (s *mismatchTestSuite) TestMethod() {
+	mock := mocker.Create()
+	defer mock.Reset()
+
+	mock.Struct(&test.Fake{}).Method("Call").When(1).Return(5)
+
+	msg := panicMessage(func() { (&test.Fake{}).Call(2) })
+	s.Contains(msg, "there is no suitable condition matched")
+	s.Contains(msg, "When(1): arg[0] expected 1, actual 2")
+}
+
+// panicMessage 获取 f 执行时 panic 的信息
+func panicMessage(f func()) (msg string) {
+	defer func() {
+		msg = fmt.Sprint(recover())
+	}()
+	f()
+	return ""
+}
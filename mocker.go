@@ -4,14 +4,17 @@
 package mocker
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 
+	"github.com/tencent/goom/arg"
 	"github.com/tencent/goom/erro"
 	"github.com/tencent/goom/internal/iface"
-	"github.com/tencent/goom/internal/logger"
+	"github.com/tencent/goom/internal/patch"
 	"github.com/tencent/goom/internal/proxy"
 	"github.com/tencent/goom/internal/unexports"
 )
@@ -20,7 +23,7 @@ import (
 type Mocker interface {
 	// Apply 代理方法实现
 	// 注意: Apply 会覆盖之前设定的 When 条件和 Return
-	// 注意: 不支持在多个协程中并发地 Apply 不同的 imp 函数
+	// 注意: 可以在被 mock 函数被其它协程并发调用时 Apply, 并发地 Apply 不同的 imp 函数时以最后一次为准
 	Apply(imp interface{})
 	// Cancel 取消代理
 	Cancel()
@@ -40,7 +43,20 @@ type ExportedMocker interface {
 	// Returns 依次按顺序返回值, 如果是多参可使用[]interface{}
 	Returns(rets ...interface{}) *When
 	// Origin 指定 Mock 之后的原函数, origin 签名和 mock 的函数一致
+	// origin 可以传递空的函数变量的指针(比如 var origin func(int) int, 传递&origin), Apply 之后会自动回填为原函数
 	Origin(origin interface{}) ExportedMocker
+	// CallOrigin 调用原函数, 可以在 Apply 的回调函数中使用, 无需通过 Origin 指定占位函数
+	// 方法的第一个参数为接收体
+	CallOrigin(args ...interface{}) []interface{}
+}
+
+// SpyMocker 支持 Spy 模式的 Mocker, 函数、结构体方法和闭包的 Mocker 实现了该接口
+// 比如: mock.Struct(&Struct{}).Method("Div").Spy()
+type SpyMocker interface {
+	ExportedMocker
+	// Spy 监视函数的调用: 调用总是转发到原函数, 同时记录调用的参数和返回值
+	// Spy 会自动分配跳板函数, 无需通过 Origin 指定占位函数
+	Spy() *Spy
 }
 
 // UnExportedMocker 未导出函数 mock 接口
@@ -50,20 +66,42 @@ type UnExportedMocker interface {
 	// As 调用之后,请使用 Return 或 When API 的方式来指定 mock 返回。
 	As(funcDef interface{}) ExportedMocker
 	// Origin 指定 Mock 之后的原函数, origin 签名和 mock 的函数一致
+	// origin 可以传递空的函数变量的指针, Apply 之后会自动回填为原函数
 	Origin(origin interface{}) UnExportedMocker
+	// CallOrigin 调用原函数, 可以在 Apply 的回调函数中使用
+	CallOrigin(args ...interface{}) []interface{}
 }
 
 // baseMocker mocker 基础类型
+// lock 保护 mock 的状态(origin、guard、imp、when、canceled 等),
+// 使得 mock 的设置、取消和被 mock 函数的调用(callback)可以在不同的协程中并发执行
 type baseMocker struct {
-	pkgName string
-	origin  interface{}
-	guard   MockGuard
-	funcDef interface{}
-	imp     interface{}
+	lock sync.RWMutex
+	// configLock 串行化 When、Return、Returns: 读取当前的 When、创建 When 和应用 mock 需要作为整体执行,
+	// 否则并发的第一次配置会各自创建 When 并重复应用, 先应用的 patch 不会被 Cancel 还原
+	configLock sync.Mutex
+	pkgName    string
+	origin     interface{}
+	guard      MockGuard
+	funcDef    interface{}
+	imp        interface{}
+	// originFunc 自动分配跳板函数之后, 可调用的原函数, 第一次需要调用原函数时才分配跳板函数
+	originFunc reflect.Value
+	// originGuard 分配跳板函数使用的 patch 句柄, originTyp 为原函数的类型
+	originGuard *patch.Guard
+	originTyp   reflect.Type
+	// spy 调用记录器, 不为 nil 时处于 Spy 模式
+	spy *Spy
+	// recorder golden 文件的调用记录器, 不为 nil 时处于录制模式, 取消时写入 golden 文件
+	recorder *recorder
 
 	when *When
 	// canceled 是否被取消
 	canceled bool
+	// unchecked 是否跳过回调函数和原函数的签名兼容性检查
+	unchecked bool
+	// self 嵌入 baseMocker 的 mocker, 用于输出事件中 mocker 的名称
+	self fmt.Stringer
 }
 
 // newBaseMocker 新增基础类型 mocker
@@ -75,40 +113,57 @@ func newBaseMocker(pkgName string) *baseMocker {
 
 // applyByName 根据函数名称应用 mock
 func (m *baseMocker) applyByName(funcName string, imp interface{}) {
-	guard, err := proxy.FuncName(funcName, imp, m.origin)
+	guard, err := proxy.FuncName(funcName, imp, m.trampoline())
 	if err != nil {
 		panic(fmt.Sprintf("proxy func name error: %v", err))
 	}
 
+	m.lock.Lock()
 	m.guard = newPatchMockGuard(guard)
-	m.guard.Apply()
 	m.imp = imp
+	m.resetOrigin(imp, guard)
+	m.lock.Unlock()
+	guard.Apply()
 }
 
 // applyByFunc 根据函数应用 mock
 func (m *baseMocker) applyByFunc(funcDef interface{}, imp interface{}) {
-	guard, err := proxy.Func(funcDef, imp, m.origin)
+	funcProxy := proxy.Func
+	if m.isUnchecked() {
+		funcProxy = proxy.UnsafeFunc
+	}
+	guard, err := funcProxy(funcDef, imp, m.trampoline())
 	if err != nil {
 		panic(fmt.Sprintf("proxy func definition error: %v", err))
 	}
 
+	m.lock.Lock()
 	m.guard = newPatchMockGuard(guard)
-	m.guard.Apply()
 	m.imp = imp
+	m.resetOrigin(imp, guard)
 	m.funcDef = funcDef
+	m.lock.Unlock()
+	guard.Apply()
 }
 
 // applyByMethod 根据函数名应用 mock
 func (m *baseMocker) applyByMethod(structDef interface{}, method string, imp interface{}) {
-	guard, err := proxy.Method(reflect.TypeOf(structDef), method, imp, m.origin)
+	methodProxy := proxy.Method
+	if m.isUnchecked() {
+		methodProxy = proxy.UnsafeMethod
+	}
+	guard, err := methodProxy(reflect.TypeOf(structDef), method, imp, m.trampoline())
 	if err != nil {
 		panic(fmt.Sprintf("proxy method error: %v", err))
 	}
 
+	m.lock.Lock()
 	m.guard = newPatchMockGuard(guard)
-	m.guard.Apply()
 	m.imp = imp
+	m.resetOrigin(imp, guard)
 	m.funcDef = reflect.ValueOf(structDef).MethodByName(method).Interface()
+	m.lock.Unlock()
+	guard.Apply()
 }
 
 // applyByIFaceMethod 根据接口方法应用 mock
@@ -125,44 +180,214 @@ func (m *baseMocker) applyByIFaceMethod(ctx *iface.IContext, iFace interface{},
 		panic(erro.NewTraceableErrorf("interface mock apply error", err))
 	}
 
-	m.guard = newIFaceMockGuard(ctx)
-	m.guard.Apply()
+	guard := newIFaceMockGuard(ctx)
+	m.lock.Lock()
+	m.guard = guard
 	m.imp = imp
+	m.lock.Unlock()
+	guard.Apply()
 }
 
 // whens 指定的返回值
 func (m *baseMocker) whens(when *When) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	m.imp = reflect.MakeFunc(when.funcTyp, m.callback).Interface()
 	m.when = when
 	return nil
 }
 
+// setWhen 设置 When 条件
+func (m *baseMocker) setWhen(when *When) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.when = when
+}
+
+// currentWhen 获取当前的 When 条件, 未设置时返回 nil
+func (m *baseMocker) currentWhen() *When {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.when
+}
+
+// currentImp 获取当前的代理函数实现
+func (m *baseMocker) currentImp() interface{} {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.imp
+}
+
+// trampoline 获取跳板函数: 指定了原函数时使用指定的原函数, 否则不分配跳板函数,
+// 在第一次需要调用原函数时(Spy、CallOrigin 等)再分配, 避免占用有限的 PlaceHolder 空间
+func (m *baseMocker) trampoline() interface{} {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.origin
+}
+
+// setUnchecked 设置是否跳过签名兼容性检查
+func (m *baseMocker) setUnchecked(unchecked bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.unchecked = unchecked
+}
+
+// isUnchecked 是否跳过签名兼容性检查
+func (m *baseMocker) isUnchecked() bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.unchecked
+}
+
+// setOrigin 设置原函数
+func (m *baseMocker) setOrigin(origin interface{}) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.origin = origin
+}
+
+// getSpy 获取调用记录器, 不存在时创建
+func (m *baseMocker) getSpy() *Spy {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.spy == nil {
+		m.spy = newSpy()
+	}
+	return m.spy
+}
+
+// spyImp 生成 Spy 模式的代理函数: 调用原函数并记录调用
+func (m *baseMocker) spyImp(funcTyp reflect.Type, spy *Spy) interface{} {
+	return reflect.MakeFunc(funcTyp, func(args []reflect.Value) []reflect.Value {
+		results := m.callOrigin(args)
+		spy.record(funcTyp, args, results)
+		return results
+	}).Interface()
+}
+
+// CallOrigin 调用原函数, 可以在 Apply 的回调函数中使用
+// args 原函数的参数列表, 方法的第一个参数为接收体; 返回原函数的返回值列表
+func (m *baseMocker) CallOrigin(args ...interface{}) []interface{} {
+	origin, err := m.loadOrigin()
+	if err != nil {
+		panic(erro.NewIllegalStatusError("CallOrigin", "origin func is not available, "+err.Error()))
+	}
+	typ := origin.Type()
+	results := m.callOrigin(arg.I2V(args, inTypes(false, typ)))
+	return arg.V2I(results, outTypes(typ))
+}
+
+// prepareOrigin 提前分配跳板函数, 分配失败时返回错误; 还没有应用 mock 时, 在调用原函数时再分配
+func (m *baseMocker) prepareOrigin() error {
+	m.lock.RLock()
+	applied := m.guard != nil
+	m.lock.RUnlock()
+	if !applied {
+		return nil
+	}
+	_, err := m.loadOrigin()
+	return err
+}
+
+// loadOrigin 获取可调用的原函数, 跳板函数没有分配时自动分配
+func (m *baseMocker) loadOrigin() (reflect.Value, error) {
+	m.lock.RLock()
+	origin := m.originFunc
+	m.lock.RUnlock()
+	if origin.IsValid() {
+		return origin, nil
+	}
+
+	// 只有第一次分配跳板函数时需要写锁
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.originFunc.IsValid() {
+		return m.originFunc, nil
+	}
+	if m.originGuard == nil {
+		return reflect.Value{}, errors.New("the trampoline is not allocated")
+	}
+	if _, err := m.originGuard.FixOrigin(); err != nil {
+		return reflect.Value{}, fmt.Errorf("the trampoline is not allocated: %w", err)
+	}
+	m.originFunc = proxy.OriginFunc(m.originTyp, m.originGuard)
+	if !m.originFunc.IsValid() {
+		return reflect.Value{}, errors.New("the trampoline is not allocated")
+	}
+	return m.originFunc, nil
+}
+
+// callOrigin 调用原函数, 跳板函数没有分配时自动分配
+func (m *baseMocker) callOrigin(args []reflect.Value) []reflect.Value {
+	origin, err := m.loadOrigin()
+	if err != nil {
+		panic(erro.NewIllegalStatusError("CallOrigin", "origin func is not available, "+err.Error()))
+	}
+	if origin.Type().IsVariadic() {
+		return origin.CallSlice(args)
+	}
+	return origin.Call(args)
+}
+
+// resetOrigin 重新应用 mock 之后, 丢弃之前的原函数, 调用方需持有 lock
+func (m *baseMocker) resetOrigin(imp interface{}, guard *patch.Guard) {
+	m.originFunc = reflect.Value{}
+	m.originGuard = guard
+	m.originTyp = reflect.TypeOf(imp)
+}
+
 // callback 通用的 MakeFunc callback
 func (m *baseMocker) callback(args []reflect.Value) (results []reflect.Value) {
-	if m.canceled && m.funcDef != nil {
-		return reflect.ValueOf(m.funcDef).Call(args)
+	// 取状态快照, 避免在调用过程中持有锁, 以及和 Cancel 并发执行时读到不一致的状态
+	m.lock.RLock()
+	canceled, funcDef, when := m.canceled, m.funcDef, m.when
+	m.lock.RUnlock()
+
+	if canceled && funcDef != nil {
+		return reflect.ValueOf(funcDef).Call(args)
 	}
-	if m.when != nil {
-		results = m.when.invoke(args)
+	if when != nil {
+		results = when.invoke(args)
 		if results != nil {
 			return results
 		}
 	}
-	panic("there is no suitable condition matched, or set default return with: mocker.Return(...)")
+	name := m.pkgName
+	if m.self != nil {
+		name = m.self.String()
+	}
+	panic(noMatchMessage(name, args))
 }
 
 // Cancel 取消 Mock
 func (m *baseMocker) Cancel() {
-	if m.guard != nil {
-		m.guard.Cancel()
+	m.lock.RLock()
+	guard := m.guard
+	m.lock.RUnlock()
+
+	// 先还原指令, 再标记取消状态, 保证取消之后的调用不再进入 callback
+	if guard != nil {
+		guard.Cancel()
 	}
+
+	m.lock.Lock()
+	canceled := m.canceled
 	m.when = nil
 	m.origin = nil
+	// 保留已经分配的原函数: 取消之前进入 callback 的调用仍可能调用原函数, 还原指令之后跳板函数仍然可用
 	m.canceled = true
+	m.lock.Unlock()
+	m.flushRecorder()
+	if !canceled && m.self != nil {
+		logCancel(m.self)
+	}
 }
 
 // Canceled 是否被取消
 func (m *baseMocker) Canceled() bool {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
 	return m.canceled
 }
 
@@ -179,10 +404,12 @@ type MethodMocker struct {
 // pkgName 包路径
 // structDef 结构体变量定义, 不能为 nil
 func NewMethodMocker(pkgName string, structDef interface{}) *MethodMocker {
-	return &MethodMocker{
+	m := &MethodMocker{
 		baseMocker: newBaseMocker(pkgName),
 		structDef:  structDef,
 	}
+	m.self = m
+	return m
 }
 
 // String mock 的名称或描述, 方便调试和问题排查
@@ -195,7 +422,7 @@ func (m *MethodMocker) String() string {
 }
 
 // Method 设置结构体的方法名
-func (m *MethodMocker) Method(name string) ExportedMocker {
+func (m *MethodMocker) Method(name string) SpyMocker {
 	if name == "" {
 		panic("method is empty")
 	}
@@ -245,7 +472,7 @@ func (m *MethodMocker) doApply(imp interface{}) {
 	}
 	imp, _ = interceptDebugInfo(imp, nil, m)
 	m.applyByMethod(m.structDef, m.method, imp)
-	logger.Consolefc(logger.DebugLevel, "mocker [%s] apply.", logger.Caller(6), m.String())
+	logApply(m, 6)
 }
 
 // When 指定条件匹配
@@ -253,8 +480,10 @@ func (m *MethodMocker) When(args ...interface{}) *When {
 	if m.method == "" {
 		panic("method is empty")
 	}
-	if m.when != nil {
-		return m.when.When(args...)
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+	if when := m.currentWhen(); when != nil {
+		return when.When(args...)
 	}
 
 	sTyp := reflect.TypeOf(m.structDef)
@@ -274,7 +503,7 @@ func (m *MethodMocker) When(args ...interface{}) *When {
 		panic(err)
 	}
 
-	m.doApply(m.imp)
+	m.doApply(m.currentImp())
 	return when
 }
 
@@ -283,8 +512,10 @@ func (m *MethodMocker) Return(ret ...interface{}) *When {
 	if m.method == "" {
 		panic("method is empty")
 	}
-	if m.when != nil {
-		return m.when.Return(ret...)
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+	if when := m.currentWhen(); when != nil {
+		return when.Return(ret...)
 	}
 
 	var (
@@ -297,7 +528,7 @@ func (m *MethodMocker) Return(ret ...interface{}) *When {
 	if err := m.whens(when); err != nil {
 		panic(err)
 	}
-	m.doApply(m.imp)
+	m.doApply(m.currentImp())
 	return when
 }
 
@@ -306,8 +537,10 @@ func (m *MethodMocker) Returns(rets ...interface{}) *When {
 	if m.method == "" {
 		panic("method is empty")
 	}
-	if m.when != nil {
-		return m.when.Returns(rets...)
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+	if when := m.currentWhen(); when != nil {
+		return when.Returns(rets...)
 	}
 
 	var (
@@ -320,17 +553,32 @@ func (m *MethodMocker) Returns(rets ...interface{}) *When {
 	if err := m.whens(when); err != nil {
 		panic(err)
 	}
-	m.when.Returns(rets...)
-	m.doApply(m.imp)
+	when.Returns(rets...)
+	m.doApply(m.currentImp())
 	return when
 }
 
 // Origin 指定调用的原函数
 func (m *MethodMocker) Origin(origin interface{}) ExportedMocker {
-	m.origin = origin
+	m.setOrigin(origin)
 	return m
 }
 
+// Spy 监视方法的调用, 调用总是转发到原方法, 同时记录调用的参数和返回值
+// 记录的参数中第一个参数为接收体
+func (m *MethodMocker) Spy() *Spy {
+	if m.method == "" {
+		panic("method is empty")
+	}
+	spy := m.getSpy()
+	m.doApply(m.spyImp(reflect.TypeOf(m.methodIns), spy))
+	if err := m.prepareOrigin(); err != nil {
+		m.Cancel()
+		panic(erro.NewIllegalStatusError("Spy", m.String()+": "+err.Error()))
+	}
+	return spy
+}
+
 // UnexportedMethodMocker 对结构体函数或方法进行 mock
 // 能支持到未导出类型、未导出类型的方法的 Mock
 type UnexportedMethodMocker struct {
@@ -343,10 +591,12 @@ type UnexportedMethodMocker struct {
 // pkgName 包路径
 // structName 结构体名称
 func NewUnexportedMethodMocker(pkgName string, structName string) *UnexportedMethodMocker {
-	return &UnexportedMethodMocker{
+	m := &UnexportedMethodMocker{
 		baseMocker: newBaseMocker(pkgName),
 		structName: structName,
 	}
+	m.self = m
+	return m
 }
 
 // String mock 的名称或描述, 方便调试和问题排查
@@ -380,12 +630,12 @@ func (m *UnexportedMethodMocker) Apply(imp interface{}) {
 
 	imp, _ = interceptDebugInfo(imp, nil, m)
 	m.applyByName(name, imp)
-	logger.Consolefc(logger.DebugLevel, "mocker [%s] apply.", logger.Caller(5), m.String())
+	logApply(m, 5)
 }
 
 // Origin 调用原函数
 func (m *UnexportedMethodMocker) Origin(origin interface{}) UnExportedMocker {
-	m.origin = origin
+	m.setOrigin(origin)
 	return m
 }
 
@@ -422,10 +672,12 @@ type UnexportedFuncMocker struct {
 // pkgName 包路径
 // funcName 函数名称
 func NewUnexportedFuncMocker(pkgName, funcName string) *UnexportedFuncMocker {
-	return &UnexportedFuncMocker{
+	m := &UnexportedFuncMocker{
 		baseMocker: newBaseMocker(pkgName),
 		funcName:   funcName,
 	}
+	m.self = m
+	return m
 }
 
 // String mock 的名称或描述, 方便调试和问题排查
@@ -444,12 +696,12 @@ func (m *UnexportedFuncMocker) objName() string {
 func (m *UnexportedFuncMocker) Apply(imp interface{}) {
 	imp, _ = interceptDebugInfo(imp, nil, m)
 	m.applyByName(m.objName(), imp)
-	logger.Consolefc(logger.DebugLevel, "mocker [%s] apply.", logger.Caller(5), m.String())
+	logApply(m, 5)
 }
 
 // Origin 调用原函数
 func (m *UnexportedFuncMocker) Origin(origin interface{}) UnExportedMocker {
-	m.origin = origin
+	m.setOrigin(origin)
 	return m
 }
 
@@ -482,10 +734,20 @@ func (m *DefMocker) String() string {
 // pkgName 包路径
 // funcDef 函数变量定义
 func NewDefMocker(pkgName string, funcDef interface{}) *DefMocker {
-	return &DefMocker{
+	m := &DefMocker{
 		baseMocker: newBaseMocker(pkgName),
 		funcDef:    funcDef,
 	}
+	m.self = m
+	return m
+}
+
+// Unsafe 跳过回调函数和原函数的签名兼容性检查
+// 默认会检查参数的寄存器分类、指针位置和结构体内存布局, 回调函数的参数使用内存布局一致的 fake 类型等场景下可以跳过检查,
+// 此时需要自行保证参数能被正确地解析
+func (m *DefMocker) Unsafe() *DefMocker {
+	m.setUnchecked(true)
+	return m
 }
 
 // Apply 代理方法实现
@@ -505,13 +767,15 @@ func (m *DefMocker) doApply(imp interface{}) {
 	} else {
 		m.applyByFunc(m.funcDef, imp)
 	}
-	logger.Consolefc(logger.DebugLevel, "mocker [%s] apply.", logger.Caller(6), m.String())
+	logApply(m, 6)
 }
 
 // When 指定条件匹配
 func (m *DefMocker) When(args ...interface{}) *When {
-	if m.when != nil {
-		return m.when.When(args...)
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+	if when := m.currentWhen(); when != nil {
+		return when.When(args...)
 	}
 	var (
 		when *When
@@ -523,14 +787,16 @@ func (m *DefMocker) When(args ...interface{}) *When {
 	if err := m.whens(when); err != nil {
 		panic(err)
 	}
-	m.doApply(m.imp)
+	m.doApply(m.currentImp())
 	return when
 }
 
 // Return 代理方法返回
 func (m *DefMocker) Return(returns ...interface{}) *When {
-	if m.when != nil {
-		return m.when.Return(returns...)
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+	if when := m.currentWhen(); when != nil {
+		return when.Return(returns...)
 	}
 	var (
 		when *When
@@ -542,14 +808,16 @@ func (m *DefMocker) Return(returns ...interface{}) *When {
 	if err := m.whens(when); err != nil {
 		panic(err)
 	}
-	m.doApply(m.imp)
+	m.doApply(m.currentImp())
 	return when
 }
 
 // Returns 依次按顺序返回值, 如果是多参可使用[]interface{}
 func (m *DefMocker) Returns(rets ...interface{}) *When {
-	if m.when != nil {
-		return m.when.Returns(rets...)
+	m.configLock.Lock()
+	defer m.configLock.Unlock()
+	if when := m.currentWhen(); when != nil {
+		return when.Returns(rets...)
 	}
 	var (
 		when *When
@@ -561,14 +829,28 @@ func (m *DefMocker) Returns(rets ...interface{}) *When {
 	if err := m.whens(when); err != nil {
 		panic(err)
 	}
-	m.when.Returns(rets...)
-	m.doApply(m.imp)
+	when.Returns(rets...)
+	m.doApply(m.currentImp())
 	return when
 }
 
 // Origin 调用原函数
 // origin 需要和原函数的参数列表保持一致
 func (m *DefMocker) Origin(origin interface{}) ExportedMocker {
-	m.origin = origin
+	m.setOrigin(origin)
 	return m
 }
+
+// Spy 监视函数的调用, 调用总是转发到原函数, 同时记录调用的参数和返回值
+func (m *DefMocker) Spy() *Spy {
+	if m.funcDef == nil {
+		panic("funcDef is empty")
+	}
+	spy := m.getSpy()
+	m.doApply(m.spyImp(reflect.TypeOf(m.funcDef), spy))
+	if err := m.prepareOrigin(); err != nil {
+		m.Cancel()
+		panic(erro.NewIllegalStatusError("Spy", m.String()+": "+err.Error()))
+	}
+	return spy
+}
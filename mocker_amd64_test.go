@@ -3,6 +3,7 @@
 package mocker_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -10,6 +11,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/suite"
+	mocker "github.com/tencent/goom"
+	"github.com/tencent/goom/arg"
 	"github.com/tencent/goom/test"
 )
 
@@ -55,6 +58,121 @@ func (s *mockerTestAmd64Suite) TestCallOrigin() {
 	})
 }
 
+// TestAutoOrigin 测试自动分配跳板函数, 无需编写占位函数
+func (s *mockerTestAmd64Suite) TestAutoOrigin() {
+	s.Run("success", func() {
+		var origin func(i int) int
+
+		mock := mocker.Create()
+		mock.Func(test.Foo).Origin(&origin).Apply(func(i int) int {
+			return origin(i) + 100
+		})
+		s.Equal(101, test.Foo(1), "foo mock check")
+
+		mock.Reset()
+		s.Equal(1, test.Foo(1), "foo mock reset check")
+	})
+}
+
+// TestCallOriginAPI 测试在回调函数中通过 CallOrigin 调用原函数
+func (s *mockerTestAmd64Suite) TestCallOriginAPI() {
+	s.Run("func", func() {
+		mock := mocker.Create()
+		m := mock.Func(test.Foo)
+		m.Apply(func(i int) int {
+			return m.CallOrigin(i)[0].(int) + 100
+		})
+		s.Equal(101, test.Foo(1), "foo mock check")
+
+		mock.Reset()
+		s.Equal(1, test.Foo(1), "foo mock reset check")
+	})
+	s.Run("method", func() {
+		mock := mocker.Create()
+		m := mock.Struct(&test.Fake{}).Method("Call")
+		m.Apply(func(f *test.Fake, i int) int {
+			return m.CallOrigin(f, i)[0].(int) * 10
+		})
+		f := &test.Fake{}
+		s.Equal(20, f.Call(2), "call mock check")
+
+		mock.Reset()
+		s.Equal(2, f.Call(2), "call mock reset check")
+	})
+	s.Run("cancel in flight", func() {
+		mock := mocker.Create()
+		m := mock.Func(test.Foo)
+		entered, release := make(chan struct{}), make(chan struct{})
+		m.Apply(func(i int) int {
+			close(entered)
+			<-release
+			return m.CallOrigin(i)[0].(int) + 100
+		})
+		result := make(chan int)
+		go func() {
+			result <- test.Foo(1)
+		}()
+		<-entered
+		// 取消之前进入回调的调用仍然可以调用原函数
+		mock.Reset()
+		close(release)
+		s.Equal(101, <-result, "foo in flight call origin check")
+		s.Equal(1, test.Foo(1), "foo mock reset check")
+	})
+}
+
+// TestWhenCallOrigin 测试部分条件调用原函数
+func (s *mockerTestAmd64Suite) TestWhenCallOrigin() {
+	s.Run("call origin when matched", func() {
+		mock := mocker.Create()
+		mock.Func(test.Foo).When(1).Return(100).When(2).CallOrigin().Return(-1)
+		s.Equal(100, test.Foo(1), "foo mock check")
+		s.Equal(2, test.Foo(2), "foo call origin check")
+		s.Equal(-1, test.Foo(3), "foo default return check")
+
+		mock.Reset()
+		s.Equal(1, test.Foo(1), "foo mock reset check")
+	})
+	s.Run("default call origin", func() {
+		mock := mocker.Create()
+		mock.Func(test.Foo).When(42).Return(100).DefaultCallOrigin()
+		s.Equal(100, test.Foo(42), "foo mock check")
+		s.Equal(3, test.Foo(3), "foo call origin check")
+
+		mock.Reset()
+		s.Equal(42, test.Foo(42), "foo mock reset check")
+	})
+	s.Run("method default call origin", func() {
+		mock := mocker.Create()
+		mock.Struct(&test.Fake{}).Method("Call").When(1).Return(5).DefaultCallOrigin()
+
+		f := &test.Fake{}
+		s.Equal(5, f.Call(1), "call mock check")
+		s.Equal(2, f.Call(2), "call origin check")
+
+		mock.Reset()
+		s.Equal(1, f.Call(1), "call mock reset check")
+	})
+}
+
+// TestSetArg 测试通过 SetArg 修改指针类型的参数
+func (s *mockerTestAmd64Suite) TestSetArg() {
+	s.Run("interface arg", func() {
+		type user struct {
+			Name string
+		}
+		mock := mocker.Create()
+		mock.Func(json.Unmarshal).When([]byte("fake"), arg.Any()).SetArg(1, &user{Name: "fake"}).Return(nil)
+
+		u := &user{}
+		s.Nil(json.Unmarshal([]byte("fake"), u), "unmarshal mock check")
+		s.Equal("fake", u.Name, "unmarshal set arg check")
+
+		mock.Reset()
+		s.NotNil(json.Unmarshal([]byte("fake"), u), "unmarshal mock reset check")
+	})
+}
+
 // TestUnitSystemFuncApply 测试系统函数的 mock
 // 需要加上 -gcflags="-l"
 // 在bazel 构建环境下, 因为系统库不支持开启 gcflags=-l ,所以暂不支持系统库中的短函数 mock
@@ -5,6 +5,7 @@ package mocker_test
 import (
 	"errors"
 	"os"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
@@ -198,6 +199,73 @@ func (s *mockerTestSuite) TestUnitUnExportStruct() {
 	})
 }
 
+// TestUnitGeneratedFake 测试使用 goom gen-fake 生成的 fake 结构体 mock 未导出结构体的方法
+//
+//go:generate go run ./cmd/goom gen-fake -o fake_gen_test.go github.com/tencent/goom/test.fake
+func (s *mockerTestSuite) TestUnitGeneratedFake() {
+	s.Run("success", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+
+		mock.Pkg("github.com/tencent/goom/test").ExportStruct("*fake").
+			Method("call").Apply(func(f *fakeFake, i int) int {
+			return len(f.field1) + f.field2 + i
+		})
+
+		f := test.NewUnexportedFake()
+		s.Equal(9, f.Invokecall(1), "fake fields check")
+	})
+}
+
+// TestUnitGeneratedAs 测试使用 goom gen-as 生成的辅助代码 mock 未导出函数和方法
+//
+//go:generate go run ./cmd/goom gen-as -o as_gen_test.go github.com/tencent/goom/test.foo github.com/tencent/goom/test.(*Fake).call
+func (s *mockerTestSuite) TestUnitGeneratedAs() {
+	s.Run("success", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+
+		asFoo(mock).Apply(func(i int) int {
+			return originFoo(i) * 3
+		})
+		s.Equal(6, test.Invokefoo(2), "foo mock check")
+
+		asFakeCall(mock).Return(7)
+		s.Equal(7, (&test.Fake{}).Invokecall(1), "call mock check")
+
+		mock.Reset()
+		s.Equal(2, test.Invokefoo(2), "foo mock reset check")
+	})
+}
+
+// TestUnitSignatureCheck 测试回调函数和原函数签名不兼容时的检查和 Unsafe 跳过检查
+func (s *mockerTestSuite) TestUnitSignatureCheck() {
+	s.Run("mismatch", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+
+		s.PanicsWithValue("proxy func definition error: "+
+			"func signature mismatch, args 0 must: int, actual: float64, offset 0 must be integer, actual: float, "+
+			"origin: func(int) int, replacement: func(float64) int; "+
+			"if the replacement is a fake, please use Unsafe() to skip the check", func() {
+			mock.Func(test.Foo).Apply(func(f float64) int { return 0 })
+		}, "signature mismatch check")
+		s.Panics(func() {
+			mock.Struct(&test.Fake{}).Method("Call").Apply(func(_ *fakeFake, i int) int { return 0 })
+		}, "receiver mismatch check")
+	})
+	s.Run("unsafe", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+
+		// 接收体为指针, 以 uintptr 接收时无法通过指针位置的检查
+		mock.Struct(&test.Fake{}).Unsafe().Method("Call").Apply(func(_ uintptr, i int) int {
+			return i + 1
+		})
+		s.Equal(3, (&test.Fake{}).Call(2), "unsafe method mock check")
+	})
+}
+
 // TestMultiReturn 测试调用原函数多返回
 func (s *mockerTestSuite) TestMultiReturn() {
 	s.Run("success", func() {
@@ -315,3 +383,73 @@ func (s *mockerTestSuite) TestVarApply() {
 		s.Equal(1, test.GlobalVar)
 	})
 }
+
+// TestConcurrentReconfigure 测试在其它协程调用被 mock 函数的同时修改 mock 规则
+func (s *mockerTestSuite) TestConcurrentReconfigure() {
+	s.Run("success", func() {
+		mock := mocker.Create()
+		mock.Func(test.Foo).Return(3)
+
+		var wg sync.WaitGroup
+		stop := make(chan struct{})
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+						if r := test.Foo(1); r != 3 && r != 4 {
+							s.Failf("unexpected result", "test.Foo returns %d", r)
+							return
+						}
+					}
+				}
+			}()
+		}
+
+		var bg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			bg.Add(1)
+			go func(i int) {
+				defer bg.Done()
+				for j := 0; j < 100; j++ {
+					mock.Func(test.Foo).When(1).Return(3 + (i+j)%2)
+				}
+			}(i)
+		}
+		bg.Wait()
+		close(stop)
+		wg.Wait()
+
+		mock.Reset()
+		s.Equal(1, test.Foo(1), "test.Foo mock reset check")
+	})
+}
+
+// TestConcurrentFirstConfigure 测试多个协程同时第一次配置同一个函数时, 所有协程的条件都生效, Reset 之后还原
+func (s *mockerTestSuite) TestConcurrentFirstConfigure() {
+	for round := 0; round < 100; round++ {
+		mock := mocker.Create()
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				mock.Func(test.Foo).When(i).Return(100 + i)
+			}(i)
+		}
+		close(start)
+		wg.Wait()
+		for i := 0; i < 8; i++ {
+			s.Require().Equal(100+i, test.Foo(i), "round %d", round)
+		}
+
+		mock.Reset()
+		s.Require().Equal(1, test.Foo(1), "test.Foo mock reset check, round %d", round)
+	}
+}
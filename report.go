@@ -0,0 +1,296 @@
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件实现了 mock 的覆盖率报告: 统计 When 中从未匹配过的条件、从未使用过的按顺序返回值以及没有匹配任何条件的调用,
+// 在 Builder.Reset 时输出到日志, 也可以通过 Builder.Report 获取。
+package mocker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tencent/goom/arg"
+	"github.com/tencent/goom/internal/logger"
+)
+
+// maxMisses 每个 When 最多记录的不同参数的未匹配调用数量
+const maxMisses = 10
+
+// omittedArgs 记录满之后其它参数的未匹配调用在报告中的参数描述
+const omittedArgs = "..."
+
+// Report mock 的覆盖率报告
+type Report struct {
+	// Mockers 存在未使用的条件、返回值或者未匹配调用的 mocker
+	Mockers []*MockerReport `json:"mockers"`
+}
+
+// MockerReport 一个 mocker 的覆盖率报告
+type MockerReport struct {
+	// Mocker mocker 的名称
+	Mocker string `json:"mocker"`
+	// UnusedConditions 从未匹配过的条件, 比如 When(1, arg.Any())
+	UnusedConditions []string `json:"unusedConditions,omitempty"`
+	// UnusedReturns 没有被使用过的按顺序返回值, 比如 When(1): 2 of 3 returns are unused
+	UnusedReturns []string `json:"unusedReturns,omitempty"`
+	// DefaultCalls 没有匹配任何条件, 使用了默认返回值或者调用了原函数的调用
+	DefaultCalls []*DefaultCall `json:"defaultCalls,omitempty"`
+}
+
+// DefaultCall 没有匹配任何条件的调用
+type DefaultCall struct {
+	// Args 调用的参数
+	Args string `json:"args"`
+	// Count 相同参数的调用次数
+	Count int `json:"count"`
+}
+
+// reportOutput 以 JSON 格式输出 Reset 时生成的报告, 为 nil 时不输出
+var (
+	reportLock   sync.Mutex
+	reportOutput io.Writer
+)
+
+// SetReportOutput 设置覆盖率报告的 JSON 输出, 比如 CI 中的报告文件
+// 每次 Builder.Reset 时, 非空的报告以一行 JSON 的格式写入 w; w 为 nil 时不输出
+func SetReportOutput(w io.Writer) {
+	reportLock.Lock()
+	defer reportLock.Unlock()
+	reportOutput = w
+}
+
+// Report 获取当前 builder 中所有 mock 的覆盖率报告
+func (b *Builder) Report() *Report {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.report()
+}
+
+// report 生成覆盖率报告, 调用方需持有 b.lock
+func (b *Builder) report() *Report {
+	report := &Report{Mockers: make([]*MockerReport, 0)}
+	for _, m := range b.mockers {
+		for _, r := range reportOf(m) {
+			if !r.empty() {
+				report.Mockers = append(report.Mockers, r)
+			}
+		}
+	}
+	sort.Slice(report.Mockers, func(i, j int) bool {
+		return report.Mockers[i].Mocker < report.Mockers[j].Mocker
+	})
+	return report
+}
+
+// emitReport 将 Reset 时的覆盖率报告输出到日志和 JSON 输出
+func emitReport(report *Report) {
+	if report.Empty() {
+		return
+	}
+	logger.Infof("mock coverage report:\n%s", report)
+
+	reportLock.Lock()
+	defer reportLock.Unlock()
+	if reportOutput == nil {
+		return
+	}
+	data, err := report.JSON()
+	if err != nil {
+		logger.Errorf("marshal mock coverage report error: %v", err)
+		return
+	}
+	if _, err := reportOutput.Write(append(data, '\n')); err != nil {
+		logger.Errorf("write mock coverage report error: %v", err)
+	}
+}
+
+// Empty 报告是否为空, 即所有的条件和返回值都被使用过, 并且没有未匹配的调用
+func (r *Report) Empty() bool {
+	return len(r.Mockers) == 0
+}
+
+// JSON 获取 JSON 格式的报告
+func (r *Report) JSON() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// String 获取文本格式的报告
+func (r *Report) String() string {
+	var b strings.Builder
+	for _, m := range r.Mockers {
+		fmt.Fprintf(&b, "mocker [%s]:\n", m.Mocker)
+		for _, c := range m.UnusedConditions {
+			fmt.Fprintf(&b, "  unused condition: %s\n", c)
+		}
+		for _, ret := range m.UnusedReturns {
+			fmt.Fprintf(&b, "  unused returns: %s\n", ret)
+		}
+		for _, call := range m.DefaultCalls {
+			fmt.Fprintf(&b, "  unmatched call: (%s) x%d\n", call.Args, call.Count)
+		}
+	}
+	return b.String()
+}
+
+// empty 报告是否为空
+func (r *MockerReport) empty() bool {
+	return len(r.UnusedConditions) == 0 && len(r.UnusedReturns) == 0 && len(r.DefaultCalls) == 0
+}
+
+// reportOf 获取 mocker 的覆盖率报告, 带缓存的 mocker 包含多个方法的报告
+func reportOf(m Mocker) []*MockerReport {
+	if c, ok := m.(interface{ children() []Mocker }); ok {
+		var reports []*MockerReport
+		for _, child := range c.children() {
+			reports = append(reports, reportOf(child)...)
+		}
+		return reports
+	}
+	if w, ok := m.(interface{ currentWhen() *When }); ok {
+		if when := w.currentWhen(); when != nil {
+			return []*MockerReport{when.report(m.String())}
+		}
+	}
+	return nil
+}
+
+// report 生成 When 的覆盖率报告
+func (w *When) report(name string) *MockerReport {
+	w.lock.RLock()
+	matches, defaultReturns := w.matches, w.defaultReturns
+	w.lock.RUnlock()
+
+	// 同一个条件多次指定 Return 时会重复出现在 matches 中
+	var (
+		conds = make([]Matcher, 0, len(matches)+1)
+		seen  = make(map[Matcher]bool, len(matches)+1)
+	)
+	for _, c := range append(append(conds, matches...), defaultReturns) {
+		if !isNil(c) && !seen[c] {
+			seen[c] = true
+			conds = append(conds, c)
+		}
+	}
+
+	r := &MockerReport{Mocker: name}
+	for _, c := range conds {
+		if c == defaultReturns {
+			continue
+		}
+		if hits, ok := hitCount(c); ok && hits == 0 {
+			r.UnusedConditions = append(r.UnusedConditions, describe(c))
+		}
+	}
+	for _, c := range conds {
+		if unused, total := unusedResults(c); unused > 0 {
+			r.UnusedReturns = append(r.UnusedReturns,
+				fmt.Sprintf("%s: %d of %d returns are unused", describe(c), unused, total))
+		}
+	}
+	if w.misses != nil {
+		r.DefaultCalls = w.misses.calls()
+	}
+	return r
+}
+
+// coverageMatcher 记录匹配次数的参数匹配
+type coverageMatcher interface {
+	// hit 记录一次匹配成功
+	hit()
+	// hitCount 匹配成功的次数
+	hitCount() int
+	// unusedResults 按顺序返回的返回值中没有被使用过的个数
+	unusedResults() (unused int, total int)
+}
+
+// hit 记录条件匹配成功
+func hit(c Matcher) {
+	if m, ok := c.(coverageMatcher); ok && !isNil(c) {
+		m.hit()
+	}
+}
+
+// hitCount 条件匹配成功的次数, 不支持统计时返回 false
+func hitCount(c Matcher) (int, bool) {
+	if m, ok := c.(coverageMatcher); ok && !isNil(c) {
+		return m.hitCount(), true
+	}
+	return 0, false
+}
+
+// unusedResults 条件按顺序返回的返回值中没有被使用过的个数
+func unusedResults(c Matcher) (int, int) {
+	if m, ok := c.(coverageMatcher); ok && !isNil(c) {
+		return m.unusedResults()
+	}
+	return 0, 0
+}
+
+// isNil 条件是否为 nil 指针, 比如没有默认返回值时的 *AlwaysMatcher(nil)
+func isNil(c Matcher) bool {
+	if c == nil {
+		return true
+	}
+	v := reflect.ValueOf(c)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// describe 获取条件的描述
+func describe(c Matcher) string {
+	if s, ok := c.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", c)
+}
+
+// missRecorder 记录没有匹配任何条件的调用
+type missRecorder struct {
+	lock sync.Mutex
+	// args 不同参数的调用, 按照第一次调用的顺序排列, 最多记录 maxMisses 个
+	args   []string
+	counts map[string]int
+	// omitted 记录满之后其它参数的调用次数
+	omitted int
+}
+
+// newMissRecorder 创建未匹配调用的记录器
+func newMissRecorder() *missRecorder {
+	return &missRecorder{counts: make(map[string]int)}
+}
+
+// record 记录一次未匹配的调用
+// 记录满 maxMisses 个不同参数之后, 已经记录的参数继续计数, 其它参数的调用只记录次数
+func (r *missRecorder) record(args []reflect.Value) {
+	s := arg.SprintV(args)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.counts[s]; !ok {
+		if len(r.args) >= maxMisses {
+			r.omitted++
+			return
+		}
+		r.args = append(r.args, s)
+	}
+	r.counts[s]++
+}
+
+// calls 获取未匹配的调用
+func (r *missRecorder) calls() []*DefaultCall {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	calls := make([]*DefaultCall, 0, len(r.args)+1)
+	for _, s := range r.args {
+		calls = append(calls, &DefaultCall{Args: s, Count: r.counts[s]})
+	}
+	if r.omitted > 0 {
+		calls = append(calls, &DefaultCall{Args: omittedArgs, Count: r.omitted})
+	}
+	if len(calls) == 0 {
+		return nil
+	}
+	return calls
+}
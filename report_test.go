@@ -0,0 +1,109 @@
+// Package mocker_test 对 mocker 包的测试
+// 当前文件实现了对 report.go 的单测
+package mocker_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	mocker "github.com/tencent/goom"
+	"github.com/tencent/goom/arg"
+	"github.com/tencent/goom/test"
+)
+
+// TestUnitReportTestSuite 测试入口
+func TestUnitReportTestSuite(t *testing.T) {
+	suite.Run(t, new(reportTestSuite))
+}
+
+// reportTestSuite 覆盖率报告测试套件
+type reportTestSuite struct {
+	suite.Suite
+}
+
+// TestReport 测试未使用的条件、返回值和未匹配的调用
+func (s *reportTestSuite) TestReport() {
+	mock := mocker.Create()
+	defer mock.Reset()
+
+	mock.Func(test.Divide).Return(0, nil).
+		When(1, 1).Return(10, nil).
+		When(2, arg.In(2, 3)).Return(20, nil).
+		When(3, 3).Return(1, nil).AndReturn(2, nil).AndReturn(3, nil)
+	mock.Struct(&test.Fake{}).Method("Call").When(1).Return(5)
+
+	_, _ = test.Divide(1, 1)
+	_, _ = test.Divide(3, 3)
+	_, _ = test.Divide(5, 5)
+	_, _ = test.Divide(5, 5)
+	_, _ = test.Divide(6, 5)
+
+	report := mock.Report()
+	s.Require().Equal(2, len(report.Mockers), report.String())
+
+	divide := report.Mockers[1]
+	s.Equal("github.com/tencent/goom/test.Divide", divide.Mocker)
+	s.Equal([]string{"When(2, arg.In(2, 3))"}, divide.UnusedConditions)
+	s.Equal([]string{"When(3, 3): 2 of 3 returns are unused"}, divide.UnusedReturns)
+	s.Equal([]*mocker.DefaultCall{{Args: "5,5", Count: 2}, {Args: "6,5", Count: 1}}, divide.DefaultCalls)
+
+	call := report.Mockers[0]
+	s.Contains(call.Mocker, "Call")
+	s.Equal([]string{"When(1)"}, call.UnusedConditions)
+	s.Empty(call.DefaultCalls)
+}
+
+// TestMaxMisses 测试最多记录 10 个不同参数的未匹配调用
+func (s *reportTestSuite) TestMaxMisses() {
+	mock := mocker.Create()
+	defer mock.Reset()
+
+	mock.Func(test.Divide).Return(0, nil).When(1, 1).Return(10, nil)
+	for i := 2; i < 15; i++ {
+		_, _ = test.Divide(i, 1)
+	}
+	// 记录满之后已经记录的参数继续计数
+	_, _ = test.Divide(2, 1)
+
+	calls := mock.Report().Mockers[0].DefaultCalls
+	s.Require().Equal(11, len(calls))
+	s.Equal(&mocker.DefaultCall{Args: "2,1", Count: 2}, calls[0])
+	s.Equal("11,1", calls[9].Args)
+	// 其它参数的调用只记录次数
+	s.Equal(&mocker.DefaultCall{Args: "...", Count: 3}, calls[10])
+}
+
+// TestEmpty 测试所有条件都被使用时报告为空
+func (s *reportTestSuite) TestEmpty() {
+	mock := mocker.Create()
+	defer mock.Reset()
+
+	mock.Func(test.Join).Return("y").When([]string{"a"}, ",").Return("x")
+	// 没有条件时, 默认返回值不算作未匹配的调用
+	mock.Func(test.Divide).Return(1, nil)
+	s.Equal("x", test.Join([]string{"a"}, ","))
+	_, _ = test.Divide(1, 2)
+	s.True(mock.Report().Empty(), mock.Report().String())
+}
+
+// TestResetOutput 测试 Reset 时以 JSON 格式输出报告
+func (s *reportTestSuite) TestResetOutput() {
+	var buf bytes.Buffer
+	mocker.SetReportOutput(&buf)
+	defer mocker.SetReportOutput(nil)
+
+	mock := mocker.Create()
+	mock.Func(test.Join).When([]string{"a"}, ",").Return("x")
+	mock.Reset()
+
+	report := &mocker.Report{}
+	s.Require().NoError(json.Unmarshal(buf.Bytes(), report))
+	s.Equal(1, len(report.Mockers))
+	s.Equal([]string{`When([a], ",")`}, report.Mockers[0].UnusedConditions)
+
+	// 取消之后报告为空
+	s.True(mock.Report().Empty())
+}
@@ -0,0 +1,245 @@
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件实现了声明式的 mock 规则: 规则中的参数条件、返回值等从 YAML 或 JSON 文件中解码, 再按照函数的真实类型转换。
+package mocker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tencent/goom/arg"
+)
+
+// rule 声明式的 mock 规则: 参数满足条件时, 延迟之后返回指定的返回值
+type rule struct {
+	// exprs 参数条件, 为 nil 时匹配任意参数
+	exprs []arg.Expr
+	// results 返回值, 为 nil 时调用原函数
+	results []reflect.Value
+	// delay 返回之前的延迟
+	delay time.Duration
+}
+
+// ruleError 创建规则时的错误, 记录了出错的字段
+type ruleError struct {
+	// field 出错的字段, 比如 when、return、error、delay
+	field string
+	err   error
+}
+
+// Error 错误信息
+func (e *ruleError) Error() string {
+	return e.field + ": " + e.err.Error()
+}
+
+// Unwrap 获取原始错误
+func (e *ruleError) Unwrap() error {
+	return e.err
+}
+
+// newRule 根据声明的内容创建规则
+// when 参数条件, 为空时匹配任意参数; returns 不包括最后一个 error 类型的返回值;
+// errMsg 最后一个 error 类型的返回值的错误信息; returns 和 errMsg 都为空时调用原函数
+// delay 延迟, 格式同 time.ParseDuration
+// 返回的错误为 *ruleError
+func newRule(funcTyp reflect.Type, when, returns []interface{}, errMsg, delay string) (*rule, error) {
+	r := &rule{}
+	if delay != "" {
+		d, err := time.ParseDuration(delay)
+		if err != nil {
+			return nil, &ruleError{field: "delay", err: fmt.Errorf("illegal delay %q: %w", delay, err)}
+		}
+		r.delay = d
+	}
+
+	in := inTypes(false, funcTyp)
+	if len(when) > 0 {
+		values, err := convertWhen(when, in)
+		if err != nil {
+			return nil, &ruleError{field: "when", err: err}
+		}
+		if r.exprs, err = arg.ToExpr(values, in); err != nil {
+			return nil, &ruleError{field: "when", err: err}
+		}
+	}
+
+	if len(returns) == 0 && errMsg == "" {
+		return r, nil
+	}
+	out := outTypes(funcTyp)
+	if errMsg != "" {
+		if len(out) == 0 || out[len(out)-1] != errorType {
+			return nil, &ruleError{field: "error",
+				err: fmt.Errorf("error is declared, but the last result of %v is not error", funcTyp)}
+		}
+	}
+	results, err := convertResults(returns, errMsg, out)
+	if err != nil {
+		return nil, &ruleError{field: "return", err: err}
+	}
+	r.results = arg.I2V(results, out)
+	return r, nil
+}
+
+// match 参数是否满足规则的条件
+func (r *rule) match(args []reflect.Value) bool {
+	for i, expr := range r.exprs {
+		ok, err := expr.Eval([]reflect.Value{args[i]})
+		if err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// applyRules 应用按规则返回的代理函数, 并提前分配没有满足条件的规则时调用原函数需要的跳板函数
+func (m *DefMocker) applyRules(funcTyp reflect.Type, rules []*rule) error {
+	m.Apply(m.rulesImp(funcTyp, rules))
+	return m.prepareOrigin()
+}
+
+// rulesImp 生成按规则返回的代理函数: 使用第一个满足条件的规则, 没有满足条件的规则时调用原函数
+func (m *baseMocker) rulesImp(funcTyp reflect.Type, rules []*rule) interface{} {
+	return reflect.MakeFunc(funcTyp, func(args []reflect.Value) []reflect.Value {
+		for _, r := range rules {
+			if !r.match(args) {
+				continue
+			}
+			if r.delay > 0 {
+				time.Sleep(r.delay)
+			}
+			if r.results != nil {
+				return r.results
+			}
+			break
+		}
+		return m.callOrigin(args)
+	}).Interface()
+}
+
+// convertResults 将声明的返回值转换为返回值类型的值
+// returns 不包括最后一个 error 类型的返回值, 只声明了 errMsg 时其它返回值为零值; errMsg 为 error 类型的返回值的错误信息
+func convertResults(returns []interface{}, errMsg string, out []reflect.Type) ([]interface{}, error) {
+	if errMsg != "" && len(returns) == 0 {
+		returns = zeroValues(out[:len(out)-1])
+	}
+	if len(out) > 0 && out[len(out)-1] == errorType && len(returns) == len(out)-1 {
+		values, err := convertValues(returns, out[:len(out)-1])
+		if err != nil {
+			return nil, err
+		}
+		var e interface{}
+		if errMsg != "" {
+			e = errors.New(errMsg)
+		}
+		return append(values, e), nil
+	}
+	if errMsg != "" {
+		return nil, fmt.Errorf("length mismatch, must: %d, actual: %d", len(out)-1, len(returns))
+	}
+	return convertValues(returns, out)
+}
+
+// convertWhen 将声明的参数条件转换为指定类型的值或者参数表达式
+// 只有一个 key 的对象表示参数表达式: {any: true} 匹配任意值, {in: [1, 2]} 匹配其中任意一个值,
+// {eq: 值} 和值相等, 用于参数本身是只有一个字段的结构体或 map 的场景; 其它值和参数相等
+func convertWhen(when []interface{}, types []reflect.Type) ([]interface{}, error) {
+	if len(when) != len(types) {
+		return nil, fmt.Errorf("length mismatch, must: %d, actual: %d", len(types), len(when))
+	}
+	values := make([]interface{}, len(when))
+	for i, w := range when {
+		v, err := whenValue(w, types[i])
+		if err != nil {
+			return nil, fmt.Errorf("%d: %w", i, err)
+		}
+		values[i] = v
+	}
+	return convertValues(values, types)
+}
+
+// whenValue 将一个参数条件转换为参数表达式, 不是参数表达式时返回原值
+func whenValue(w interface{}, typ reflect.Type) (interface{}, error) {
+	expr, ok := w.(map[string]interface{})
+	if !ok || len(expr) != 1 {
+		return w, nil
+	}
+	if _, ok := expr["any"]; ok {
+		return arg.Any(), nil
+	}
+	if v, ok := expr["eq"]; ok {
+		return v, nil
+	}
+	v, ok := expr["in"]
+	if !ok {
+		return w, nil
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("in must be a list, actual: %v", v)
+	}
+	types := make([]reflect.Type, len(list))
+	for i := range types {
+		types[i] = typ
+	}
+	in, err := convertValues(list, types)
+	if err != nil {
+		return nil, fmt.Errorf("in: %w", err)
+	}
+	return arg.In(in...), nil
+}
+
+// convertValues 将从 YAML 或 JSON 中解码的值转换为指定类型的值
+// error 类型使用错误信息表示, 结构体等复合类型按照 JSON 的规则转换
+func convertValues(values []interface{}, types []reflect.Type) ([]interface{}, error) {
+	if len(values) != len(types) {
+		return nil, fmt.Errorf("length mismatch, must: %d, actual: %d", len(types), len(values))
+	}
+	converted := make([]interface{}, len(values))
+	for i, v := range values {
+		if _, ok := v.(arg.Expr); ok {
+			converted[i] = v
+			continue
+		}
+		c, err := decodeValue(JSONCodec{}, v, types[i])
+		if err != nil {
+			return nil, fmt.Errorf("%d: convert %v to %v error: %w", i, v, types[i], err)
+		}
+		converted[i] = c
+	}
+	return converted, nil
+}
+
+// decodeJSON 解码 JSON, 数字解码为 json.Number 以保留整数的精度
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// decodeFile 按照文件的扩展名解码 YAML(.yaml、.yml) 或 JSON 文件的内容
+// YAML 先转换为 JSON, 以便使用统一的规则转换结构体等类型的值
+func decodeFile(path string, data []byte, v interface{}) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var raw interface{}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		jsonData, err := json.Marshal(raw)
+		if err != nil {
+			return err
+		}
+		return decodeJSON(jsonData, v)
+	default:
+		return decodeJSON(data, v)
+	}
+}
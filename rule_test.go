@@ -0,0 +1,76 @@
+package mocker
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/tencent/goom/test"
+)
+
+func TestRuleTestSuite(t *testing.T) {
+	suite.Run(t, new(ruleTestSuite))
+}
+
+type ruleTestSuite struct {
+	suite.Suite
+}
+
+// TestRule_delay 测试用例中声明的延迟被设置到 rule 上
+func (s *ruleTestSuite) TestRule_delay() {
+	cases, err := LoadCases("testdata/ratio_cases.yaml")
+	s.Require().NoError(err)
+	m := cases.Cases()[4].Mocks[0]
+	s.Require().Equal("10ms", m.Delay)
+
+	_, target, err := cases.Target(test.Divide).lookup(m.Func)
+	s.Require().NoError(err)
+	r, err := newRule(reflect.TypeOf(target), m.When, m.Return, m.Error, m.Delay)
+	s.Require().NoError(err)
+	s.Equal(10*time.Millisecond, r.delay)
+
+	_, err = newRule(reflect.TypeOf(target), nil, nil, "", "10")
+	s.Error(err)
+}
+
+// rollbackConfig 测试回滚使用的配置, 第二个函数在应用时找不到
+func (s *ruleTestSuite) rollbackConfig() *Config {
+	config, err := ParseConfig("faults.json", []byte(`[
+  {"func": "github.com/tencent/goom/test.Join", "signature": "func([]string, string) string", "return": ["mocked"]},
+  {"func": "github.com/tencent/goom/test.Divide", "signature": "func(int, int) (int, error)", "error": "overflow"}
+]`))
+	s.Require().NoError(err)
+	config.rules[1].Func = "github.com/tencent/goom/test.notExist"
+	return config
+}
+
+// TestConfig_rollback 测试配置应用失败时返回错误, 并取消已经应用的规则
+func (s *ruleTestSuite) TestConfig_rollback() {
+	b := Create()
+	defer b.Reset()
+	err := s.rollbackConfig().Apply(b)
+	s.Require().Error(err)
+	s.Contains(err.Error(), "test.notExist")
+	s.Equal("a", test.Join([]string{"a"}, ","), "rollback check")
+}
+
+// TestAdmin_rollback 测试管理接口应用失败时取消已经应用的规则
+func (s *ruleTestSuite) TestAdmin_rollback() {
+	h, err := newAdminHandler([]interface{}{test.Join})
+	s.Require().NoError(err)
+	defer func() { _ = h.Cancel("") }()
+	config := s.rollbackConfig()
+	h.allowed[config.rules[1].Func] = config.rules[1].funcTyp
+
+	joinOnly := &Config{rules: config.rules[:1]}
+	s.Require().NoError(h.Apply(joinOnly))
+	s.Equal("mocked", test.Join([]string{"a"}, ","))
+
+	err = h.Apply(config)
+	s.True(errors.Is(err, errApplyFailed), "apply failed check")
+	s.Empty(h.status().Active, "rollback check")
+	s.Equal("a", test.Join([]string{"a"}, ","), "rollback check")
+}
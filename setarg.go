@@ -0,0 +1,131 @@
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件实现了在条件匹配成功时修改指针、slice、map 类型的参数, 支持了 When(XXX).SetArg(i, YYY)。
+package mocker
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/tencent/goom/erro"
+)
+
+// argSetter 参数修改器, 将指定的值写入指针、slice 或 map 类型的参数
+type argSetter struct {
+	// index 参数在调用参数列表中的下标(方法包含接收体)
+	index int
+	// variadic 不为负数时, 表示修改可变参数中的第 variadic 个元素
+	variadic int
+	// value 要写入的值
+	value reflect.Value
+	// name 参数的描述, 用于错误信息
+	name string
+}
+
+// newArgSetter 创建参数修改器, 并检查参数类型和值类型是否匹配
+// i 参数下标, 不包括方法的接收体; 可变参数函数的下标超出固定参数时, 表示可变参数中的元素
+func newArgSetter(funcTyp reflect.Type, isMethod bool, i int, value interface{}) (*argSetter, error) {
+	types := inTypes(isMethod, funcTyp)
+	setter := &argSetter{index: i, variadic: -1, name: "arg" + strconv.Itoa(i)}
+
+	var typ reflect.Type
+	switch {
+	case i >= 0 && funcTyp.IsVariadic() && i >= len(types)-1:
+		setter.index = len(types) - 1
+		setter.variadic = i - (len(types) - 1)
+		typ = types[len(types)-1].Elem()
+	case i >= 0 && i < len(types):
+		typ = types[i]
+	default:
+		return nil, erro.NewArgNotFoundError(funcTyp.String(), i)
+	}
+	if isMethod {
+		setter.index++
+	}
+
+	var err error
+	switch typ.Kind() {
+	case reflect.Ptr:
+		setter.value, err = assignableValue(setter.name, derefValue(value, typ), typ.Elem())
+	case reflect.Slice, reflect.Map:
+		setter.value, err = assignableValue(setter.name, value, typ)
+	case reflect.Interface:
+		// 接口类型的参数只能在调用时检查其实际的指针类型
+		setter.value = reflect.ValueOf(value)
+	default:
+		err = erro.NewIllegalParamTypeError(setter.name, typ.String(), "pointer, slice or map")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return setter, nil
+}
+
+// derefValue 值和指针参数的类型相同时, 取其指向的值, 以支持 SetArg(i, &v) 的写法
+func derefValue(value interface{}, typ reflect.Type) interface{} {
+	v := reflect.ValueOf(value)
+	if value == nil || v.Type() != typ || v.IsNil() {
+		return value
+	}
+	return v.Elem().Interface()
+}
+
+// assignableValue 检查值是否可以赋值给指定的类型
+func assignableValue(name string, value interface{}, typ reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(typ), nil
+	}
+	v := reflect.ValueOf(value)
+	if !v.Type().AssignableTo(typ) {
+		return reflect.Value{}, erro.NewIllegalParamTypeError(name, v.Type().String(), typ.String())
+	}
+	return v, nil
+}
+
+// set 修改调用参数
+func (s *argSetter) set(args []reflect.Value) {
+	target := args[s.index]
+	if s.variadic >= 0 {
+		if s.variadic >= target.Len() {
+			return
+		}
+		target = target.Index(s.variadic)
+	}
+	if target.Kind() == reflect.Interface {
+		// 接口类型的参数只支持修改其指向的指针
+		target = target.Elem()
+		if target.Kind() != reflect.Ptr {
+			panic(fmt.Sprintf("%s can not be set, it must hold a pointer, actual: %v", s.name, target))
+		}
+	}
+
+	switch target.Kind() {
+	case reflect.Ptr:
+		if target.IsNil() {
+			return
+		}
+		value := s.value
+		if !value.IsValid() {
+			value = reflect.Zero(target.Type().Elem())
+		} else if value.Type() == target.Type() && !value.IsNil() {
+			value = value.Elem()
+		}
+		if !value.Type().AssignableTo(target.Type().Elem()) {
+			panic(erro.NewIllegalParamTypeError(s.name, value.Type().String(), target.Type().Elem().String()))
+		}
+		target.Elem().Set(value)
+	case reflect.Slice:
+		reflect.Copy(target, s.value)
+	case reflect.Map:
+		if target.IsNil() {
+			return
+		}
+		iter := s.value.MapRange()
+		for iter.Next() {
+			target.SetMapIndex(iter.Key(), iter.Value())
+		}
+	default:
+		panic(fmt.Sprintf("%s can not be set, type: %s", s.name, target.Type()))
+	}
+}
@@ -0,0 +1,185 @@
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件实现了函数签名的解析: 声明式的 mock 配置中使用函数签名的字符串描述被 mock 函数的类型。
+package mocker
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strconv"
+	"time"
+	"unsafe"
+)
+
+// signatureTypes 签名中可以使用的具名类型
+var signatureTypes = map[string]reflect.Type{
+	"bool":            reflect.TypeOf(false),
+	"int":             reflect.TypeOf(int(0)),
+	"int8":            reflect.TypeOf(int8(0)),
+	"int16":           reflect.TypeOf(int16(0)),
+	"int32":           reflect.TypeOf(int32(0)),
+	"int64":           reflect.TypeOf(int64(0)),
+	"uint":            reflect.TypeOf(uint(0)),
+	"uint8":           reflect.TypeOf(uint8(0)),
+	"uint16":          reflect.TypeOf(uint16(0)),
+	"uint32":          reflect.TypeOf(uint32(0)),
+	"uint64":          reflect.TypeOf(uint64(0)),
+	"uintptr":         reflect.TypeOf(uintptr(0)),
+	"byte":            reflect.TypeOf(byte(0)),
+	"rune":            reflect.TypeOf(rune(0)),
+	"float32":         reflect.TypeOf(float32(0)),
+	"float64":         reflect.TypeOf(float64(0)),
+	"complex64":       reflect.TypeOf(complex64(0)),
+	"complex128":      reflect.TypeOf(complex128(0)),
+	"string":          reflect.TypeOf(""),
+	"error":           errorType,
+	"any":             reflect.TypeOf((*interface{})(nil)).Elem(),
+	"unsafe.Pointer":  reflect.TypeOf(unsafe.Pointer(nil)),
+	"time.Duration":   reflect.TypeOf(time.Duration(0)),
+	"time.Time":       reflect.TypeOf(time.Time{}),
+	"context.Context": reflect.TypeOf((*context.Context)(nil)).Elem(),
+}
+
+// parseSignature 解析函数签名, 比如: func(int, string) (int, error)
+// 方法的第一个参数为接收体; 只支持内置类型以及 unsafe.Pointer、time.Duration、time.Time、context.Context,
+// 其它类型需要使用内存布局一致的类型代替, 比如指针类型可以使用 unsafe.Pointer
+func parseSignature(signature string) (reflect.Type, error) {
+	expr, err := parser.ParseExpr(signature)
+	if err != nil {
+		return nil, fmt.Errorf("illegal signature %q: %w", signature, err)
+	}
+	funcType, ok := expr.(*ast.FuncType)
+	if !ok {
+		return nil, fmt.Errorf("illegal signature %q, must be a func type, such as: func(int) error", signature)
+	}
+	typ, err := signatureFunc(funcType)
+	if err != nil {
+		return nil, fmt.Errorf("illegal signature %q: %w", signature, err)
+	}
+	return typ, nil
+}
+
+// signatureFunc 将函数类型的语法树转换为函数类型
+func signatureFunc(funcType *ast.FuncType) (reflect.Type, error) {
+	in, variadic, err := signatureFields(funcType.Params)
+	if err != nil {
+		return nil, err
+	}
+	out, _, err := signatureFields(funcType.Results)
+	if err != nil {
+		return nil, err
+	}
+	return reflect.FuncOf(in, out, variadic), nil
+}
+
+// signatureFields 将参数或返回值列表的语法树转换为类型列表
+func signatureFields(fields *ast.FieldList) ([]reflect.Type, bool, error) {
+	if fields == nil {
+		return nil, false, nil
+	}
+	var (
+		types    []reflect.Type
+		variadic bool
+	)
+	for i, field := range fields.List {
+		typeExpr := field.Type
+		if ellipsis, ok := typeExpr.(*ast.Ellipsis); ok {
+			if i != len(fields.List)-1 || len(field.Names) > 1 {
+				return nil, false, fmt.Errorf("can only use ... with final parameter")
+			}
+			typeExpr, variadic = &ast.ArrayType{Elt: ellipsis.Elt}, true
+		}
+		typ, err := signatureType(typeExpr)
+		if err != nil {
+			return nil, false, err
+		}
+		// 同类型的多个参数可以写在一起, 比如: a, b int
+		n := len(field.Names)
+		if n == 0 {
+			n = 1
+		}
+		for j := 0; j < n; j++ {
+			types = append(types, typ)
+		}
+	}
+	return types, variadic, nil
+}
+
+// signatureType 将类型的语法树转换为类型
+func signatureType(expr ast.Expr) (reflect.Type, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if typ, ok := signatureTypes[t.Name]; ok {
+			return typ, nil
+		}
+	case *ast.SelectorExpr:
+		if pkg, ok := t.X.(*ast.Ident); ok {
+			if typ, ok := signatureTypes[pkg.Name+"."+t.Sel.Name]; ok {
+				return typ, nil
+			}
+		}
+	case *ast.ParenExpr:
+		return signatureType(t.X)
+	case *ast.StarExpr:
+		elem, err := signatureType(t.X)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.PtrTo(elem), nil
+	case *ast.ArrayType:
+		elem, err := signatureType(t.Elt)
+		if err != nil {
+			return nil, err
+		}
+		if t.Len == nil {
+			return reflect.SliceOf(elem), nil
+		}
+		lit, ok := t.Len.(*ast.BasicLit)
+		if !ok || lit.Kind != token.INT {
+			return nil, fmt.Errorf("array length must be an integer literal")
+		}
+		n, err := strconv.Atoi(lit.Value)
+		if err != nil {
+			return nil, fmt.Errorf("illegal array length %s: %w", lit.Value, err)
+		}
+		return reflect.ArrayOf(n, elem), nil
+	case *ast.MapType:
+		key, err := signatureType(t.Key)
+		if err != nil {
+			return nil, err
+		}
+		if !key.Comparable() {
+			return nil, fmt.Errorf("invalid map key type %v", key)
+		}
+		elem, err := signatureType(t.Value)
+		if err != nil {
+			return nil, err
+		}
+		return reflect.MapOf(key, elem), nil
+	case *ast.ChanType:
+		elem, err := signatureType(t.Value)
+		if err != nil {
+			return nil, err
+		}
+		dir := reflect.BothDir
+		if t.Dir == ast.SEND {
+			dir = reflect.SendDir
+		} else if t.Dir == ast.RECV {
+			dir = reflect.RecvDir
+		}
+		return reflect.ChanOf(dir, elem), nil
+	case *ast.FuncType:
+		return signatureFunc(t)
+	case *ast.InterfaceType:
+		if t.Methods == nil || len(t.Methods.List) == 0 {
+			return signatureTypes["any"], nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported type %s, please use a type with the same memory layout instead, "+
+		"such as unsafe.Pointer for pointers", types.ExprString(expr))
+}
@@ -0,0 +1,75 @@
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件实现了 Spy 模式: 被 mock 函数的调用总是转发到原函数, 同时记录调用的参数和返回值。
+package mocker
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/tencent/goom/arg"
+)
+
+// Call 一次调用的记录
+type Call struct {
+	// Args 调用参数, 方法的第一个参数为接收体
+	Args []interface{}
+	// Results 返回值
+	Results []interface{}
+}
+
+// Spy 调用记录器, 记录被 mock 函数的每一次调用
+// Spy 是线程安全的, 可以在被 mock 函数被并发调用时读取调用记录
+type Spy struct {
+	lock  sync.RWMutex
+	calls []*Call
+}
+
+// newSpy 创建调用记录器
+func newSpy() *Spy {
+	return &Spy{}
+}
+
+// Calls 获取所有的调用记录, 按调用的先后顺序排列
+func (s *Spy) Calls() []*Call {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	calls := make([]*Call, len(s.calls))
+	copy(calls, s.calls)
+	return calls
+}
+
+// Times 获取调用次数
+func (s *Spy) Times() int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return len(s.calls)
+}
+
+// LastCall 获取最后一次调用记录, 没有调用时返回 nil
+func (s *Spy) LastCall() *Call {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if len(s.calls) == 0 {
+		return nil
+	}
+	return s.calls[len(s.calls)-1]
+}
+
+// Reset 清空调用记录
+func (s *Spy) Reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.calls = nil
+}
+
+// record 记录一次调用
+func (s *Spy) record(funcTyp reflect.Type, args []reflect.Value, results []reflect.Value) {
+	call := &Call{
+		Args:    arg.V2I(args, inTypes(false, funcTyp)),
+		Results: arg.V2I(results, outTypes(funcTyp)),
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.calls = append(s.calls, call)
+}
@@ -0,0 +1,74 @@
+// Package mocker_test 对 mocker 包的测试
+// 当前文件实现了对 spy.go 的单测
+package mocker_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	mocker "github.com/tencent/goom"
+	"github.com/tencent/goom/test"
+)
+
+// TestUnitSpyTestSuite 测试入口
+func TestUnitSpyTestSuite(t *testing.T) {
+	suite.Run(t, new(spyTestSuite))
+}
+
+// spyTestSuite Spy 测试套件
+type spyTestSuite struct {
+	suite.Suite
+}
+
+// TestFuncSpy 测试函数的 Spy
+func (s *spyTestSuite) TestFuncSpy() {
+	s.Run("success", func() {
+		mock := mocker.Create()
+		spy := mock.Func(test.Foo).Spy()
+
+		s.Equal(1, test.Foo(1), "spy call through check")
+		s.Equal(2, test.Foo(2), "spy call through check")
+
+		s.Equal(2, spy.Times(), "spy times check")
+		s.Equal([]interface{}{1}, spy.Calls()[0].Args, "spy args check")
+		s.Equal([]interface{}{2}, spy.LastCall().Args, "spy args check")
+		s.Equal([]interface{}{2}, spy.LastCall().Results, "spy results check")
+
+		spy.Reset()
+		s.Equal(0, spy.Times(), "spy reset check")
+		s.Nil(spy.LastCall(), "spy reset check")
+
+		mock.Reset()
+		s.Equal(3, test.Foo(3), "test.Foo mock reset check")
+		s.Equal(0, spy.Times(), "spy canceled check")
+	})
+}
+
+// TestMethodSpy 测试方法的 Spy
+func (s *spyTestSuite) TestMethodSpy() {
+	s.Run("success", func() {
+		mock := mocker.Create()
+		spy := mock.Struct(&test.Fake{}).Method("Call").Spy()
+
+		f := &test.Fake{}
+		s.Equal(5, f.Call(5), "spy call through check")
+
+		s.Equal(1, spy.Times(), "spy times check")
+		s.Equal([]interface{}{f, 5}, spy.LastCall().Args, "spy args check")
+		s.Equal([]interface{}{5}, spy.LastCall().Results, "spy results check")
+
+		mock.Reset()
+		s.Equal(1, f.Call(1), "call mock reset check")
+	})
+}
+
+// TestSpyMocker 测试只有可以转发到原函数的 Mocker 支持 Spy
+func (s *spyTestSuite) TestSpyMocker() {
+	var m mocker.ExportedMocker = (*mocker.DefMocker)(nil)
+	_, ok := m.(mocker.SpyMocker)
+	s.True(ok, "func mocker spy check")
+
+	m = (*mocker.DefaultInterfaceMocker)(nil)
+	_, ok = m.(mocker.SpyMocker)
+	s.False(ok, "interface mocker spy check")
+}
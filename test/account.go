@@ -0,0 +1,45 @@
+package test
+
+import "fmt"
+
+// Account 没有接口定义的结构体, 用于测试结构体的整体 mock
+type Account struct {
+	Owner   string
+	balance int
+}
+
+// Balance 余额
+//
+//go:noinline
+func (a *Account) Balance() int {
+	return a.balance
+}
+
+// Deposit 存款, 返回存款之后的余额
+//
+//go:noinline
+func (a *Account) Deposit(amount int) int {
+	a.balance += amount
+	return a.balance
+}
+
+// Transfer 转账
+//
+//go:noinline
+func (a *Account) Transfer(to *Account, amounts ...int) error {
+	for _, amount := range amounts {
+		if amount > a.balance {
+			return fmt.Errorf("balance not enough: %d", a.balance)
+		}
+		a.balance -= amount
+		to.balance += amount
+	}
+	return nil
+}
+
+// Name 名称
+//
+//go:noinline
+func (a *Account) Name() string {
+	return a.Owner
+}
@@ -0,0 +1,15 @@
+package test
+
+import (
+	"fmt"
+
+	"github.com/tencent/goom/anchor"
+)
+
+// Commit 提交订单, 提交之前声明了锚点 order.beforeCommit, 用于测试锚点 mock
+func Commit(id int, user string) error {
+	if err := anchor.Point("order.beforeCommit", id, user); err != nil {
+		return fmt.Errorf("commit order %d: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Divide 整数除法, 除数为 0 时返回错误, 用于测试调用的录制和回放
+//
+//go:noinline
+func Divide(a, b int) (int, error) {
+	if b == 0 {
+		return 0, errors.New("divide by zero")
+	}
+	return a / b, nil
+}
+
+// Join 拼接字符串, 用于测试复合类型参数的录制和回放
+//
+//go:noinline
+func Join(parts []string, sep string) string {
+	return strings.Join(parts, sep)
+}
+
+// Ratio 计算 a/b 并格式化为 "a/b=商", 用于测试数据驱动测试
+//
+//go:noinline
+func Ratio(a, b int) (string, error) {
+	q, err := Divide(a, b)
+	if err != nil {
+		return "", fmt.Errorf("ratio: %w", err)
+	}
+	return Join([]string{strconv.Itoa(a), strconv.Itoa(b)}, "/") + "=" + strconv.Itoa(q), nil
+}
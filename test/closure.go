@@ -0,0 +1,27 @@
+package test
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NewAdder 创建捕获了 base 变量的闭包函数
+func NewAdder(base int) func(n int) int {
+	return func(n int) int {
+		return base + n
+	}
+}
+
+// NewFormatter 创建捕获了 prefix 变量的闭包函数, 参数包含整数、浮点数、字符串和切片
+func NewFormatter(prefix string) func(name string, values []int, ratio float64, n int) (string, error) {
+	return func(name string, values []int, ratio float64, n int) (string, error) {
+		if n < 0 {
+			return "", fmt.Errorf("illegal n: %d", n)
+		}
+		parts := make([]string, 0, len(values))
+		for _, v := range values {
+			parts = append(parts, fmt.Sprint(v))
+		}
+		return fmt.Sprintf("%s%s[%s]%.1f*%d", prefix, name, strings.Join(parts, ","), ratio, n), nil
+	}
+}
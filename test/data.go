@@ -1,7 +1,10 @@
 // Package test 兼容性测试、跨包结构测试工具类
 package test
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+)
 
 // GlobalVar 用于测试全局变量 mock
 var GlobalVar = 1
@@ -116,3 +119,20 @@ func Foo1() *S {
 func GetS() ([]byte, error) {
 	return []byte("hello"), nil
 }
+
+// record 未导出结构体, 包含多种类型的字段, 用于测试 fake 结构体的生成
+// nolint
+type record struct {
+	fake
+	name    string
+	next    *record
+	items   []*fake
+	index   map[string]fake
+	done    chan struct{}
+	handler func(*fake, ...int) (int, error)
+	caller  interface{ call(int) int }
+	reader  io.Reader
+	flags   [3]bool
+	ratio   float32
+	S
+}
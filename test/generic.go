@@ -0,0 +1,45 @@
+//go:build go1.18
+// +build go1.18
+
+package test
+
+import "fmt"
+
+// Map 泛型函数, 对切片中的每个元素执行 f
+//
+//go:noinline
+func Map[K comparable, V any](s []K, f func(K) V) []V {
+	r := make([]V, 0, len(s))
+	for _, k := range s {
+		r = append(r, f(k))
+	}
+	return r
+}
+
+// Cache 泛型结构体
+type Cache[K comparable, V any] struct {
+	m map[K]V
+}
+
+// NewCache 创建泛型结构体
+func NewCache[K comparable, V any]() *Cache[K, V] {
+	return &Cache[K, V]{m: make(map[K]V)}
+}
+
+// Put 泛型结构体的方法
+//
+//go:noinline
+func (c *Cache[K, V]) Put(k K, v V) {
+	c.m[k] = v
+}
+
+// Get 泛型结构体的方法
+//
+//go:noinline
+func (c *Cache[K, V]) Get(k K) (V, bool) {
+	if len(c.m) > 10000 {
+		fmt.Println("only for func size")
+	}
+	v, ok := c.m[k]
+	return v, ok
+}
@@ -0,0 +1,18 @@
+//go:build ignore
+// +build ignore
+
+// typed_mismatch.go 类型安全 API 参数和返回值类型不一致时的编译错误, 由 typed_test.go 编译
+package main
+
+import (
+	mocker "github.com/tencent/goom"
+	"github.com/tencent/goom/test"
+)
+
+func main() {
+	mock := mocker.Create()
+	defer mock.Reset()
+	m := mocker.Fn(mock, test.Foo)
+	m.WhenCall(func(foo func(int) int) { foo("1") })
+	m.ReturnWith(func(i int) string { return "1" })
+}
@@ -0,0 +1,238 @@
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件实现了 mock 调用的时间线记录: 记录每次调用的 mocker、协程、开始和结束时间、参数和匹配的条件,
+// 以 Chrome trace_event JSON 格式输出, 可以在 chrome://tracing 或 Perfetto(ui.perfetto.dev) 中打开, 方便排查并发测试的问题。
+package mocker
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tencent/goom/arg"
+)
+
+var (
+	// traceLock 保护 tracer
+	traceLock sync.RWMutex
+	// tracer 当前的调用记录器, 为 nil 时不记录
+	tracer *TraceRecorder
+)
+
+// TraceRecorder mock 调用的时间线记录器
+type TraceRecorder struct {
+	lock  sync.Mutex
+	start time.Time
+	spans []*traceSpan
+	// stacks 每个协程正在执行的调用, 用于关联调用中匹配的条件
+	stacks map[int64][]*traceSpan
+}
+
+// traceSpan 一次 mock 调用
+type traceSpan struct {
+	name       string
+	goroutine  int64
+	start, end time.Duration
+	args       string
+	results    string
+	// condition 匹配的条件, 没有使用 When 时为空
+	condition string
+}
+
+// StartTrace 开始记录 mock 调用的时间线, 覆盖之前开始的记录
+// 注意: 调用在 Apply 时决定是否拦截, 需要在 mock 之前调用; 记录保存在内存中, 请在测试结束时调用 Stop
+func StartTrace() *TraceRecorder {
+	r := &TraceRecorder{start: time.Now(), stacks: make(map[int64][]*traceSpan)}
+	traceLock.Lock()
+	defer traceLock.Unlock()
+	tracer = r
+	return r
+}
+
+// Stop 停止记录, 之后的调用不再记录
+func (r *TraceRecorder) Stop() {
+	traceLock.Lock()
+	defer traceLock.Unlock()
+	if tracer == r {
+		tracer = nil
+	}
+}
+
+// Len 已经记录的调用次数
+func (r *TraceRecorder) Len() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return len(r.spans)
+}
+
+// traceEvent Chrome trace_event 格式的事件
+// 参考: https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU
+type traceEvent struct {
+	Name string            `json:"name"`
+	Cat  string            `json:"cat,omitempty"`
+	Ph   string            `json:"ph"`
+	Ts   float64           `json:"ts"`
+	Dur  float64           `json:"dur"`
+	Pid  int               `json:"pid"`
+	Tid  int64             `json:"tid"`
+	Args map[string]string `json:"args,omitempty"`
+}
+
+// traceFile Chrome trace_event 格式的文件
+type traceFile struct {
+	TraceEvents     []*traceEvent `json:"traceEvents"`
+	DisplayTimeUnit string        `json:"displayTimeUnit"`
+}
+
+// WriteTo 以 Chrome trace_event JSON 格式输出已经完成的调用, 每个协程为一个线程
+func (r *TraceRecorder) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.Marshal(r.traceFile())
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// WriteFile 以 Chrome trace_event JSON 格式输出到文件
+func (r *TraceRecorder) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := r.WriteTo(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// traceFile 生成 Chrome trace_event 格式的文件
+func (r *TraceRecorder) traceFile() *traceFile {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	pid := os.Getpid()
+	events := []*traceEvent{{Name: "process_name", Ph: "M", Pid: pid, Args: map[string]string{"name": "goom"}}}
+	goroutines := make(map[int64]bool)
+	for _, s := range r.spans {
+		if !goroutines[s.goroutine] {
+			goroutines[s.goroutine] = true
+			events = append(events, &traceEvent{Name: "thread_name", Ph: "M", Pid: pid, Tid: s.goroutine,
+				Args: map[string]string{"name": "goroutine " + strconv.FormatInt(s.goroutine, 10)}})
+		}
+		args := map[string]string{"args": s.args, "results": s.results}
+		if s.condition != "" {
+			args["condition"] = s.condition
+		}
+		events = append(events, &traceEvent{
+			Name: s.name,
+			Cat:  "goom",
+			Ph:   "X",
+			Ts:   microseconds(s.start),
+			Dur:  microseconds(s.end - s.start),
+			Pid:  pid,
+			Tid:  s.goroutine,
+			Args: args,
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Ph == "M" && events[j].Ph != "M"
+	})
+	return &traceFile{TraceEvents: events, DisplayTimeUnit: "ms"}
+}
+
+// begin 记录调用的开始
+func (r *TraceRecorder) begin(name string, params []reflect.Value) *traceSpan {
+	s := &traceSpan{name: name, goroutine: goroutineID(), args: arg.SprintV(params), start: time.Since(r.start)}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.stacks[s.goroutine] = append(r.stacks[s.goroutine], s)
+	return s
+}
+
+// finish 记录调用的结束
+func (r *TraceRecorder) finish(s *traceSpan) {
+	end := time.Since(r.start)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	s.end = end
+	stack := r.stacks[s.goroutine]
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i] == s {
+			stack = append(stack[:i], stack[i+1:]...)
+			break
+		}
+	}
+	if len(stack) == 0 {
+		delete(r.stacks, s.goroutine)
+	} else {
+		r.stacks[s.goroutine] = stack
+	}
+	r.spans = append(r.spans, s)
+}
+
+// match 记录当前协程中正在执行的 mocker 调用匹配的条件
+func (r *TraceRecorder) match(name string, condition string) {
+	goroutine := goroutineID()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	stack := r.stacks[goroutine]
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i].name == name {
+			stack[i].condition = condition
+			return
+		}
+	}
+}
+
+// currentTrace 获取当前的调用记录器
+func currentTrace() *TraceRecorder {
+	traceLock.RLock()
+	defer traceLock.RUnlock()
+	return tracer
+}
+
+// traceCall 执行调用并记录到当前的调用记录器
+func traceCall(mocker Mocker, params []reflect.Value, call func([]reflect.Value) []reflect.Value) []reflect.Value {
+	r := currentTrace()
+	if r == nil {
+		return call(params)
+	}
+	s := r.begin(mocker.String(), params)
+	defer r.finish(s)
+	results := call(params)
+	s.results = arg.SprintV(results)
+	return results
+}
+
+// trace 记录参数匹配的条件
+func (w *When) trace(condition string) {
+	if r := currentTrace(); r != nil {
+		r.match(w.name(), condition)
+	}
+}
+
+// goroutineID 获取当前协程的 ID
+func goroutineID() int64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	s := strings.TrimPrefix(string(buf[:n]), "goroutine ")
+	if i := strings.IndexByte(s, ' '); i > 0 {
+		id, _ := strconv.ParseInt(s[:i], 10, 64)
+		return id
+	}
+	return 0
+}
+
+// microseconds 时长的微秒数, trace_event 的时间单位
+func microseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Microsecond)
+}
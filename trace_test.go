@@ -0,0 +1,112 @@
+// Package mocker_test 对 mocker 包的测试
+// 当前文件实现了对 trace.go 的单测
+package mocker_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	mocker "github.com/tencent/goom"
+	"github.com/tencent/goom/test"
+)
+
+// TestUnitTraceTestSuite 测试入口
+func TestUnitTraceTestSuite(t *testing.T) {
+	suite.Run(t, new(traceTestSuite))
+}
+
+// traceTestSuite 调用时间线测试套件
+type traceTestSuite struct {
+	suite.Suite
+}
+
+// traceEvent Chrome trace_event 格式的事件
+type traceEvent struct {
+	Name string            `json:"name"`
+	Ph   string            `json:"ph"`
+	Ts   float64           `json:"ts"`
+	Dur  float64           `json:"dur"`
+	Tid  int64             `json:"tid"`
+	Args map[string]string `json:"args"`
+}
+
+// TestTrace 测试记录多个协程中的 mock 调用和匹配的条件
+func (s *traceTestSuite) TestTrace() {
+	trace := mocker.StartTrace()
+	defer trace.Stop()
+
+	mock := mocker.Create()
+	defer mock.Reset()
+	mock.Func(test.Divide).Return(0, nil).When(1, 2).Return(10, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = test.Divide(1, 2)
+		}()
+	}
+	wg.Wait()
+	_, _ = test.Divide(3, 4)
+	trace.Stop()
+	_, _ = test.Divide(1, 2)
+	s.Equal(3, trace.Len())
+
+	var buf bytes.Buffer
+	_, err := trace.WriteTo(&buf)
+	s.Require().NoError(err)
+	var file struct {
+		TraceEvents []*traceEvent `json:"traceEvents"`
+	}
+	s.Require().NoError(json.Unmarshal(buf.Bytes(), &file))
+
+	var (
+		spans   []*traceEvent
+		threads = make(map[int64]string)
+	)
+	for _, e := range file.TraceEvents {
+		switch {
+		case e.Ph == "X":
+			spans = append(spans, e)
+		case e.Name == "thread_name":
+			threads[e.Tid] = e.Args["name"]
+		}
+	}
+	s.Require().Equal(3, len(spans))
+	s.Equal(3, len(threads))
+	conditions := make(map[string]int)
+	for _, e := range spans {
+		s.Equal("github.com/tencent/goom/test.Divide", e.Name)
+		s.NotEmpty(threads[e.Tid])
+		s.True(e.Dur >= 0)
+		conditions[e.Args["args"]+" "+e.Args["condition"]+" "+e.Args["results"]]++
+	}
+	s.Equal(map[string]int{"1,2 When(1, 2) 10,nil": 2, "3,4 default 0,nil": 1}, conditions)
+}
+
+// TestWriteFile 测试输出到文件
+func (s *traceTestSuite) TestWriteFile() {
+	trace := mocker.StartTrace()
+	mock := mocker.Create()
+	defer mock.Reset()
+	mock.Func(test.Join).Return("x")
+	_ = test.Join(nil, ",")
+	trace.Stop()
+
+	dir, err := ioutil.TempDir("", "goom-trace")
+	s.Require().NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "trace.json")
+	s.Require().NoError(trace.WriteFile(path))
+	data, err := ioutil.ReadFile(path)
+	s.Require().NoError(err)
+	s.Contains(string(data), `"name":"github.com/tencent/goom/test.Join","cat":"goom","ph":"X"`)
+}
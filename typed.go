@@ -0,0 +1,261 @@
+//go:build go1.18
+// +build go1.18
+
+// Package mocker 定义了 mock 的外层用户使用 API 定义,
+// 包括函数、方法、接口、未导出函数(或方法的)的 Mocker 的实现。
+// 当前文件实现了基于泛型的类型安全的 mock API, 回调函数和原函数的签名不一致时在编译期报错。
+package mocker
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/tencent/goom/erro"
+)
+
+// TypedMocker 类型安全的 Mocker, F 为被 mock 的函数类型(方法的第一个参数为接收体)
+type TypedMocker[F any] struct {
+	mocker ExportedMocker
+	// isMethod 是否为方法, 方法的条件参数不包括接收体
+	isMethod bool
+}
+
+// Fn 创建函数的类型安全的 Mocker
+// 比如: mocker.Fn(mock, foo).Apply(func(i int) int { return 1 })
+func Fn[F any](b *Builder, f F) *TypedMocker[F] {
+	if reflect.TypeOf(f).Kind() != reflect.Func {
+		panic(erro.NewIllegalParamTypeError("f", reflect.TypeOf(f).String(), "func"))
+	}
+	return &TypedMocker[F]{mocker: b.Func(f)}
+}
+
+// Method 创建结构体方法的类型安全的 Mocker, F 的第一个参数为接收体
+// 比如: mocker.Method[func(*Struct, int) int](mock, &Struct{}, "Div").Apply(...)
+func Method[F any](b *Builder, obj interface{}, name string) *TypedMocker[F] {
+	method, ok := reflect.TypeOf(obj).MethodByName(name)
+	if !ok {
+		panic(erro.NewFuncNotFoundError(reflect.TypeOf(obj).String() + "." + name))
+	}
+	if typ := typeOf[F](); method.Type != typ {
+		panic(erro.NewIllegalParamTypeError(name, method.Type.String(), typ.String()))
+	}
+	return &TypedMocker[F]{mocker: b.Struct(obj).Method(name), isMethod: true}
+}
+
+// typeOf 获取类型参数的 reflect.Type
+func typeOf[F any]() reflect.Type {
+	return reflect.TypeOf((*F)(nil)).Elem()
+}
+
+// Apply 指定 mock 执行的回调函数
+func (m *TypedMocker[F]) Apply(imp F) *TypedMocker[F] {
+	m.mocker.Apply(imp)
+	return m
+}
+
+// Origin 返回可调用的原函数, 需在 Apply、When 或 Return 之后使用
+// 比如: m.Apply(func(i int) int { return m.Origin()(i) + 1 })
+func (m *TypedMocker[F]) Origin() F {
+	caller, ok := m.mocker.(originCaller)
+	if !ok {
+		panic(erro.NewIllegalStatusError("Origin", m.mocker.String()+" can not call origin"))
+	}
+	if err := caller.prepareOrigin(); err != nil {
+		panic(erro.NewIllegalStatusError("Origin", "origin func is not available, "+err.Error()))
+	}
+	return reflect.MakeFunc(typeOf[F](), caller.callOrigin).Interface().(F)
+}
+
+// When 指定条件匹配, 参数可以使用 arg.Any 等表达式, 在运行时检查类型; 编译期检查类型请使用 WhenCall
+func (m *TypedMocker[F]) When(args ...interface{}) *TypedWhen[F] {
+	return &TypedWhen[F]{when: m.mocker.When(args...), isMethod: m.isMethod}
+}
+
+// WhenCall 指定条件匹配, 条件为 call 中调用 f 时传递的参数, 参数类型在编译期检查
+// 比如: m.WhenCall(func(foo func(int) int) { foo(1) }).ReturnWith(func(int) int { return 100 })
+func (m *TypedMocker[F]) WhenCall(call func(f F)) *TypedWhen[F] {
+	return m.When(argsOf(call, m.isMethod)...)
+}
+
+// Return 指定返回值, 在运行时检查类型; 编译期检查类型请使用 ReturnWith
+func (m *TypedMocker[F]) Return(results ...interface{}) *TypedWhen[F] {
+	return &TypedWhen[F]{when: m.mocker.Return(results...), isMethod: m.isMethod}
+}
+
+// ReturnWith 调用 imp 并返回其结果, 返回值类型在编译期检查
+// 跟在 When 之后时只对符合条件的调用生效, 否则作为没有匹配的条件时的默认实现
+func (m *TypedMocker[F]) ReturnWith(imp F) *TypedWhen[F] {
+	w := &TypedWhen[F]{when: m.mocker.Returns(), isMethod: m.isMethod}
+	return w.ReturnWith(imp)
+}
+
+// Returns 依次按顺序返回值, 如果是多参可使用[]interface{}; 编译期检查类型请使用 ReturnsWith
+func (m *TypedMocker[F]) Returns(results ...interface{}) *TypedWhen[F] {
+	return &TypedWhen[F]{when: m.mocker.Returns(results...), isMethod: m.isMethod}
+}
+
+// ReturnsWith 依次按顺序调用 imps 并返回其结果, 之后的调用使用最后一个 imp, 返回值类型在编译期检查
+func (m *TypedMocker[F]) ReturnsWith(imps ...F) *TypedWhen[F] {
+	w := &TypedWhen[F]{when: m.mocker.Returns(), isMethod: m.isMethod}
+	return w.ReturnsWith(imps...)
+}
+
+// Spy 监视函数的调用, 调用总是转发到原函数, 同时记录调用的参数和返回值
+func (m *TypedMocker[F]) Spy() *Spy {
+	spy, ok := m.mocker.(SpyMocker)
+	if !ok {
+		panic(erro.NewIllegalStatusError("Spy", m.mocker.String()+" does not support spy"))
+	}
+	return spy.Spy()
+}
+
+// Cancel 取消 mock
+func (m *TypedMocker[F]) Cancel() {
+	m.mocker.Cancel()
+}
+
+// Mocker 返回对应的非泛型 Mocker
+func (m *TypedMocker[F]) Mocker() ExportedMocker {
+	return m.mocker
+}
+
+// TypedWhen 类型安全的条件匹配, F 为被 mock 的函数类型
+type TypedWhen[F any] struct {
+	when *When
+	// isMethod 是否为方法, 方法的条件参数不包括接收体
+	isMethod bool
+}
+
+// When 指定条件匹配, 参数可以使用 arg.Any 等表达式, 在运行时检查类型; 编译期检查类型请使用 WhenCall
+func (w *TypedWhen[F]) When(args ...interface{}) *TypedWhen[F] {
+	w.when.When(args...)
+	return w
+}
+
+// WhenCall 指定条件匹配, 条件为 call 中调用 f 时传递的参数, 参数类型在编译期检查
+func (w *TypedWhen[F]) WhenCall(call func(f F)) *TypedWhen[F] {
+	return w.When(argsOf(call, w.isMethod)...)
+}
+
+// In 指定包含类型的条件匹配
+func (w *TypedWhen[F]) In(slices ...interface{}) *TypedWhen[F] {
+	w.when.In(slices...)
+	return w
+}
+
+// Return 指定返回值, 在运行时检查类型; 编译期检查类型请使用 ReturnWith
+func (w *TypedWhen[F]) Return(results ...interface{}) *TypedWhen[F] {
+	w.when.Return(results...)
+	return w
+}
+
+// ReturnWith 调用 imp 并返回其结果, 返回值类型在编译期检查
+// 跟在 When 之后时只对符合条件的调用生效, 否则作为没有匹配的条件时的默认实现
+func (w *TypedWhen[F]) ReturnWith(imp F) *TypedWhen[F] {
+	w.when.returnWith(reflect.ValueOf(imp))
+	return w
+}
+
+// AndReturn 指定第二次调用返回值,之后的调用以最后一个指定的值返回
+func (w *TypedWhen[F]) AndReturn(results ...interface{}) *TypedWhen[F] {
+	w.when.AndReturn(results...)
+	return w
+}
+
+// Returns 依次按顺序返回值, 如果是多参可使用[]interface{}; 编译期检查类型请使用 ReturnsWith
+func (w *TypedWhen[F]) Returns(results ...interface{}) *TypedWhen[F] {
+	w.when.Returns(results...)
+	return w
+}
+
+// ReturnsWith 依次按顺序调用 imps 并返回其结果, 之后的调用使用最后一个 imp, 返回值类型在编译期检查
+func (w *TypedWhen[F]) ReturnsWith(imps ...F) *TypedWhen[F] {
+	if len(imps) == 0 {
+		return w
+	}
+	w.when.returnWith(sequenceOf(imps))
+	return w
+}
+
+// Then 当参数符合当前 When 或 In 指定的条件时, 调用 imp 并返回其结果
+// 比如: When(42).Then(func(i int) int { return i * 2 })
+func (w *TypedWhen[F]) Then(imp F) *TypedWhen[F] {
+	w.when.then(reflect.ValueOf(imp))
+	return w
+}
+
+// CallOrigin 当参数符合当前 When 或 In 指定的条件时, 调用原函数
+func (w *TypedWhen[F]) CallOrigin() *TypedWhen[F] {
+	w.when.CallOrigin()
+	return w
+}
+
+// DefaultCallOrigin 没有匹配的条件时, 调用原函数
+func (w *TypedWhen[F]) DefaultCallOrigin() *TypedWhen[F] {
+	w.when.DefaultCallOrigin()
+	return w
+}
+
+// SetArg 当参数符合当前的条件时, 将 value 写入第 i 个参数
+func (w *TypedWhen[F]) SetArg(i int, value interface{}) *TypedWhen[F] {
+	w.when.SetArg(i, value)
+	return w
+}
+
+// Eval 执行 when 子句
+func (w *TypedWhen[F]) Eval(args ...interface{}) []interface{} {
+	return w.when.Eval(args...)
+}
+
+// Unwrap 返回对应的非泛型 When
+func (w *TypedWhen[F]) Unwrap() *When {
+	return w.when
+}
+
+// argsOf 执行 call, 返回 call 中调用 f 时传递的参数, 方法的参数不包括接收体
+func argsOf[F any](call func(f F), isMethod bool) []interface{} {
+	var (
+		typ   = typeOf[F]()
+		calls int
+		args  []reflect.Value
+	)
+	f := reflect.MakeFunc(typ, func(in []reflect.Value) []reflect.Value {
+		calls++
+		args = in
+		results := make([]reflect.Value, typ.NumOut())
+		for i := range results {
+			results[i] = reflect.Zero(typ.Out(i))
+		}
+		return results
+	}).Interface().(F)
+	call(f)
+	if calls != 1 {
+		panic(erro.NewIllegalStatusError("WhenCall",
+			fmt.Sprintf("f must be called exactly once in call, but called %d times", calls)))
+	}
+	if isMethod {
+		args = args[1:]
+	}
+	values := make([]interface{}, len(args))
+	for i, a := range args {
+		values[i] = a.Interface()
+	}
+	return values
+}
+
+// sequenceOf 生成依次调用 imps 的回调函数, 之后的调用使用最后一个 imp
+func sequenceOf[F any](imps []F) reflect.Value {
+	var n int32
+	return reflect.MakeFunc(typeOf[F](), func(args []reflect.Value) []reflect.Value {
+		i := int(atomic.AddInt32(&n, 1)) - 1
+		if i >= len(imps) {
+			i = len(imps) - 1
+		}
+		imp := reflect.ValueOf(imps[i])
+		if imp.Type().IsVariadic() {
+			return imp.CallSlice(args)
+		}
+		return imp.Call(args)
+	})
+}
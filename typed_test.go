@@ -0,0 +1,171 @@
+//go:build go1.18
+// +build go1.18
+
+// Package mocker_test 对 mocker 包的测试
+// 当前文件实现了对 typed.go 的单测
+package mocker_test
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	mocker "github.com/tencent/goom"
+	"github.com/tencent/goom/test"
+)
+
+// TestUnitTypedTestSuite 测试入口
+func TestUnitTypedTestSuite(t *testing.T) {
+	suite.Run(t, new(typedTestSuite))
+}
+
+// typedTestSuite 泛型 API 测试套件
+type typedTestSuite struct {
+	suite.Suite
+}
+
+// TestFnApply 测试函数的类型安全 Apply 和 Origin
+func (s *typedTestSuite) TestFnApply() {
+	s.Run("success", func() {
+		mock := mocker.Create()
+		m := mocker.Fn(mock, test.Foo)
+		m.Apply(func(i int) int {
+			return m.Origin()(i) + 100
+		})
+		s.Equal(101, test.Foo(1), "foo mock check")
+
+		mock.Reset()
+		s.Equal(1, test.Foo(1), "foo mock reset check")
+	})
+}
+
+// TestFnWhen 测试函数的类型安全条件匹配
+func (s *typedTestSuite) TestFnWhen() {
+	s.Run("success", func() {
+		mock := mocker.Create()
+		mocker.Fn(mock, test.Foo).
+			When(1).Return(100).
+			When(2).Then(func(i int) int { return i * 10 }).
+			Return(-1)
+
+		s.Equal(100, test.Foo(1), "foo when check")
+		s.Equal(20, test.Foo(2), "foo then check")
+		s.Equal(-1, test.Foo(3), "foo default return check")
+
+		mock.Reset()
+		s.Equal(3, test.Foo(3), "foo mock reset check")
+	})
+	s.Run("then after return", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+		s.Panics(func() {
+			mocker.Fn(mock, test.Foo).When(1).Return(1).Then(func(i int) int { return i })
+		}, "then after return check")
+	})
+}
+
+// TestMethod 测试方法的类型安全 mock
+func (s *typedTestSuite) TestMethod() {
+	s.Run("success", func() {
+		mock := mocker.Create()
+		mocker.Method[func(*test.Fake, int) int](mock, &test.Fake{}, "Call").
+			Apply(func(_ *test.Fake, i int) int { return i + 1 })
+
+		f := &test.Fake{}
+		s.Equal(3, f.Call(2), "call mock check")
+
+		mock.Reset()
+		s.Equal(2, f.Call(2), "call mock reset check")
+	})
+	s.Run("signature mismatch", func() {
+		s.Panics(func() {
+			mocker.Method[func(int) int](mocker.Create(), &test.Fake{}, "Call")
+		}, "signature mismatch check")
+	})
+}
+
+// TestWhenCall 测试编译期检查参数类型的条件匹配
+func (s *typedTestSuite) TestWhenCall() {
+	s.Run("func", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+		mocker.Fn(mock, test.Foo).
+			WhenCall(func(foo func(int) int) { foo(1) }).Return(100).
+			WhenCall(func(foo func(int) int) { foo(2) }).ReturnWith(func(i int) int { return i * 10 }).
+			Return(-1)
+
+		s.Equal(100, test.Foo(1), "foo when call check")
+		s.Equal(20, test.Foo(2), "foo return with check")
+		s.Equal(-1, test.Foo(3), "foo default return check")
+	})
+	s.Run("method", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+		mocker.Method[func(*test.Fake, int) int](mock, &test.Fake{}, "Call").
+			Return(-1).
+			WhenCall(func(call func(*test.Fake, int) int) { call(nil, 1) }).Return(100)
+
+		f := &test.Fake{}
+		s.Equal(100, f.Call(1), "call when call check")
+		s.Equal(-1, f.Call(2), "call default return check")
+	})
+	s.Run("called twice", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+		s.Panics(func() {
+			mocker.Fn(mock, test.Foo).WhenCall(func(foo func(int) int) {
+				foo(1)
+				foo(2)
+			})
+		}, "called twice check")
+	})
+}
+
+// TestReturnWith 测试编译期检查返回值类型的默认实现和按顺序返回
+func (s *typedTestSuite) TestReturnWith() {
+	s.Run("default", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+		mocker.Fn(mock, test.Divide).
+			ReturnWith(func(a, b int) (int, error) { return a * b, nil }).
+			When(1, 2).Return(100, nil)
+
+		r, err := test.Divide(3, 4)
+		s.Equal(12, r, "divide default return with check")
+		s.Nil(err)
+		r, _ = test.Divide(1, 2)
+		s.Equal(100, r, "divide when check")
+	})
+	s.Run("return after return with", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+		mocker.Fn(mock, test.Foo).ReturnWith(func(i int) int { return i }).Return(-1)
+		s.Equal(-1, test.Foo(3), "foo default return check")
+	})
+	s.Run("returns with", func() {
+		mock := mocker.Create()
+		defer mock.Reset()
+		mocker.Fn(mock, test.Foo).ReturnsWith(
+			func(i int) int { return i + 1 },
+			func(i int) int { return i + 2 })
+
+		s.Equal(2, test.Foo(1), "foo first return check")
+		s.Equal(3, test.Foo(1), "foo second return check")
+		s.Equal(3, test.Foo(1), "foo last return check")
+	})
+}
+
+// TestTypeMismatch 测试参数和返回值类型不一致时编译失败
+func (s *typedTestSuite) TestTypeMismatch() {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		s.T().Skip("go command not found")
+	}
+	out, err := exec.Command(goBin, "build", "-o", os.DevNull, "testdata/typed_mismatch.go").CombinedOutput()
+	s.Require().Error(err, string(out))
+	s.Contains(string(out), `typed_mismatch.go:16`, string(out))
+	s.Contains(string(out), `typed_mismatch.go:17`, string(out))
+	s.Equal(2, strings.Count(string(out), "cannot use"), string(out))
+}
@@ -3,8 +3,6 @@ package mocker
 import (
 	"fmt"
 	"reflect"
-
-	"github.com/tencent/goom/internal/logger"
 )
 
 // VarMock 变量 mock
@@ -54,13 +52,16 @@ func (m *defaultVarMocker) Apply(valueCallback interface{}) {
 	}
 
 	m.doSet(ret[0].Interface())
-	logger.Consolefc(logger.DebugLevel, "mocker [%s] apply.", logger.Caller(5), m.String())
+	logApply(m, 5)
 }
 
 // Cancel 取消 mock
 func (m *defaultVarMocker) Cancel() {
 	t := reflect.ValueOf(m.target)
 	t.Elem().Set(reflect.ValueOf(m.originValue))
+	if !m.canceled {
+		logCancel(m)
+	}
 	m.canceled = true
 }
 
@@ -73,7 +74,7 @@ func (m *defaultVarMocker) Canceled() bool {
 // 注意: Set 会覆盖之前设定 Apply 的值
 func (m *defaultVarMocker) Set(val interface{}) {
 	m.doSet(val)
-	logger.Consolefc(logger.DebugLevel, "mocker [%s] apply.", logger.Caller(5), m.String())
+	logApply(m, 5)
 }
 
 func (m *defaultVarMocker) doSet(val interface{}) {
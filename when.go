@@ -6,11 +6,45 @@ package mocker
 
 import (
 	"reflect"
+	"sync"
 
 	"github.com/tencent/goom/arg"
 	"github.com/tencent/goom/erro"
+	"github.com/tencent/goom/logging"
 )
 
+// originMatcher 匹配成功时可以调用原函数的参数匹配
+type originMatcher interface {
+	// setCallOrigin 设置匹配成功时调用原函数
+	setCallOrigin()
+	// shouldCallOrigin 匹配成功时是否调用原函数
+	shouldCallOrigin() bool
+}
+
+// impMatcher 匹配成功时调用回调函数的参数匹配
+type impMatcher interface {
+	// setImp 设置匹配成功时调用的回调函数
+	setImp(imp reflect.Value)
+	// matchedImp 匹配成功时调用的回调函数
+	matchedImp() reflect.Value
+}
+
+// argsMatcher 匹配成功时可以修改参数的参数匹配
+type argsMatcher interface {
+	// addArgSetter 添加参数修改器
+	addArgSetter(setter *argSetter)
+	// setArgs 修改调用参数
+	setArgs(args []reflect.Value)
+}
+
+// originCaller 可以调用原函数的 Mocker
+type originCaller interface {
+	// prepareOrigin 提前分配调用原函数需要的跳板函数, 分配失败时返回错误
+	prepareOrigin() error
+	// callOrigin 调用原函数
+	callOrigin(args []reflect.Value) []reflect.Value
+}
+
 // Matcher 参数匹配接口
 type Matcher interface {
 	// Match 匹配执行方法
@@ -23,15 +57,21 @@ type Matcher interface {
 
 // When Mock 条件匹配。
 // 当参数等于指定的值时,会 return 对应的指定值
+// 条件的添加和匹配可以在不同的协程中并发执行
 type When struct {
 	ExportedMocker
+	lock           sync.RWMutex
 	funcTyp        reflect.Type
 	funcDef        interface{}
 	isMethod       bool
 	matches        []Matcher
 	defaultReturns Matcher
+	// defaultCallOrigin 没有匹配的条件时是否调用原函数
+	defaultCallOrigin bool
 	// curMatch 当前指定的参数匹配
 	curMatch Matcher
+	// misses 没有匹配任何条件的调用, 用于覆盖率报告
+	misses *missRecorder
 }
 
 // CreateWhen 构造条件判断
@@ -53,7 +93,7 @@ func CreateWhen(m ExportedMocker, funcDef interface{}, args []interface{},
 	if defaultReturns != nil {
 		curMatch = newAlwaysMatch(defaultReturns, impTyp)
 	} else if len(outTypes(impTyp)) == 0 {
-		curMatch = newEmptyMatch()
+		curMatch = newEmptyMatch(impTyp)
 	}
 
 	defaultMatch = curMatch
@@ -68,6 +108,7 @@ func CreateWhen(m ExportedMocker, funcDef interface{}, args []interface{},
 		isMethod:       isMethod,
 		matches:        make([]Matcher, 0),
 		curMatch:       curMatch,
+		misses:         newMissRecorder(),
 	}, nil
 }
 
@@ -97,6 +138,7 @@ func NewWhen(funTyp reflect.Type) *When {
 		matches:        make([]Matcher, 0),
 		defaultReturns: nil,
 		curMatch:       nil,
+		misses:         newMissRecorder(),
 	}
 }
 
@@ -107,7 +149,10 @@ func NewWhen(funTyp reflect.Type) *When {
 //	In(3, 4), // 第一个参数是 In
 //	Any()) // 第二个参数是 Any
 func (w *When) When(args ...interface{}) *When {
-	w.curMatch = newDefaultMatch(args, nil, w.isMethod, w.funcTyp)
+	matcher := newDefaultMatch(args, nil, w.isMethod, w.funcTyp)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.curMatch = matcher
 	return w
 }
 
@@ -115,19 +160,30 @@ func (w *When) When(args ...interface{}) *When {
 // 当参数为多个时, In 的每个条件各使用一个数组表示:
 // .In([]interface{}{3, Any()}, []interface{}{4, Any()})
 func (w *When) In(slices ...interface{}) *When {
-	w.curMatch = newContainsMatch(slices, nil, w.isMethod, w.funcTyp)
+	matcher := newContainsMatch(slices, nil, w.isMethod, w.funcTyp)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.curMatch = matcher
 	return w
 }
 
 // Return 指定返回值
 func (w *When) Return(results ...interface{}) *When {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.doReturn(results...)
+}
+
+// doReturn 指定返回值, 调用方需持有写锁
+func (w *When) doReturn(results ...interface{}) *When {
 	if w.curMatch != nil {
 		w.curMatch.AddResult(results)
 		w.matches = append(w.matches, w.curMatch)
 		return w
 	}
 
-	if w.defaultReturns == nil {
+	w.defaultCallOrigin = false
+	if w.defaultReturns == nil || matchedImp(w.defaultReturns).IsValid() {
 		w.defaultReturns = newAlwaysMatch(results, w.funcTyp)
 	} else {
 		w.defaultReturns.AddResult(results)
@@ -137,13 +193,137 @@ func (w *When) Return(results ...interface{}) *When {
 
 // AndReturn 指定第二次调用返回值,之后的调用以最后一个指定的值返回
 func (w *When) AndReturn(results ...interface{}) *When {
+	w.lock.Lock()
+	defer w.lock.Unlock()
 	if w.curMatch == nil {
-		return w.Return(results...)
+		return w.doReturn(results...)
 	}
 	w.curMatch.AddResult(results)
 	return w
 }
 
+// CallOrigin 当参数符合当前 When 或 In 指定的条件时, 调用原函数
+// 比如: When(42).Return(fake).When(43).CallOrigin()
+// 没有指定条件时, 和 DefaultCallOrigin 相同
+func (w *When) CallOrigin() *When {
+	w.prepareOrigin("CallOrigin")
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.curMatch == nil {
+		w.defaultCallOrigin = true
+		return w
+	}
+
+	m, ok := w.curMatch.(originMatcher)
+	if !ok || !w.isPending(w.curMatch) {
+		panic(erro.NewIllegalStatusError("CallOrigin",
+			"CallOrigin must follow When(...) or In(...), use DefaultCallOrigin() for the unmatched args"))
+	}
+	m.setCallOrigin()
+	w.matches = append(w.matches, w.curMatch)
+	// 之后没有指定条件的 Return 作为默认返回值
+	w.curMatch = nil
+	return w
+}
+
+// then 当参数符合当前 When 或 In 指定的条件时, 调用 imp 并返回其结果
+// imp 的签名必须和被 mock 的函数一致
+func (w *When) then(imp reflect.Value) *When {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	m, ok := w.curMatch.(impMatcher)
+	if !ok || !w.isPending(w.curMatch) {
+		panic(erro.NewIllegalStatusError("Then", "Then must follow When(...) or In(...)"))
+	}
+	m.setImp(imp)
+	w.matches = append(w.matches, w.curMatch)
+	// 之后没有指定条件的 Return 作为默认返回值
+	w.curMatch = nil
+	return w
+}
+
+// returnWith 当参数符合当前 When 或 In 指定的条件时调用 imp 并返回其结果;
+// 没有指定条件时, 作为没有匹配的条件时的默认实现
+func (w *When) returnWith(imp reflect.Value) *When {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.curMatch != nil {
+		m, ok := w.curMatch.(impMatcher)
+		if !ok || !w.isPending(w.curMatch) {
+			panic(erro.NewIllegalStatusError("ReturnWith", "ReturnWith must follow When(...) or In(...)"))
+		}
+		m.setImp(imp)
+		w.matches = append(w.matches, w.curMatch)
+		w.curMatch = nil
+		return w
+	}
+
+	w.defaultCallOrigin = false
+	defaultReturns := &AlwaysMatcher{BaseMatcher: newBaseMatcher(nil, w.funcTyp)}
+	defaultReturns.setImp(imp)
+	w.defaultReturns = defaultReturns
+	return w
+}
+
+// isPending 条件是否还没有指定返回值, 调用方需持有锁
+func (w *When) isPending(matcher Matcher) bool {
+	if matcher == w.defaultReturns {
+		return false
+	}
+	for _, m := range w.matches {
+		if m == matcher {
+			return false
+		}
+	}
+	return true
+}
+
+// SetArg 当参数符合当前的条件时, 将 value 写入第 i 个参数
+// 第 i 个参数必须为指针、slice 或 map 类型(接口类型的参数在调用时必须持有指针), 下标不包括方法的接收体;
+// 可变参数函数的下标超出固定参数时, 表示可变参数中的元素.
+// 比如: mock.Func(json.Unmarshal).When(data, arg.Any()).SetArg(1, user).Return(nil)
+// 没有指定条件时, 作用于默认返回值
+func (w *When) SetArg(i int, value interface{}) *When {
+	setter, err := newArgSetter(w.funcTyp, w.isMethod, i, value)
+	if err != nil {
+		panic(err)
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	target := w.curMatch
+	if target == nil {
+		target = w.defaultReturns
+	}
+	m, ok := target.(argsMatcher)
+	if !ok {
+		panic(erro.NewIllegalStatusError("SetArg", "SetArg must follow When(...), In(...) or Return(...)"))
+	}
+	m.addArgSetter(setter)
+	return w
+}
+
+// SetArgs 当参数符合当前的条件时, 按下标依次修改参数, 值为 nil 表示不修改对应下标的参数
+// 比如: mock.Func(scan).When(arg.Any(), arg.Any()).SetArgs("name", 18).Return(nil)
+func (w *When) SetArgs(values ...interface{}) *When {
+	for i, v := range values {
+		if v != nil {
+			w.SetArg(i, v)
+		}
+	}
+	return w
+}
+
+// DefaultCallOrigin 没有匹配的条件时, 调用原函数
+// 比如: When(42).Return(fake).DefaultCallOrigin(), 只有参数为42时返回 fake, 其它情况都调用原函数
+func (w *When) DefaultCallOrigin() *When {
+	w.prepareOrigin("DefaultCallOrigin")
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.defaultCallOrigin = true
+	return w
+}
+
 // Matches 多个条件匹配
 func (w *When) Matches(matches ...arg.Pair) *When {
 	if len(matches) == 0 {
@@ -162,7 +342,9 @@ func (w *When) Matches(matches ...arg.Pair) *When {
 
 		w.Return(results...)
 		matcher := newDefaultMatch(args, results, w.isMethod, w.funcTyp)
+		w.lock.Lock()
 		w.matches = append(w.matches, matcher)
+		w.lock.Unlock()
 	}
 	return w
 }
@@ -189,14 +371,110 @@ func (w *When) Returns(rets ...interface{}) *When {
 
 // invoke 执行 When 参数匹配并返回值
 func (w *When) invoke(args1 []reflect.Value) (results []reflect.Value) {
-	if len(w.matches) != 0 {
-		for _, c := range w.matches {
-			if c.Match(args1) {
-				return c.Result()
+	w.lock.RLock()
+	matches, defaultReturns, defaultCallOrigin := w.matches, w.defaultReturns, w.defaultCallOrigin
+	w.lock.RUnlock()
+
+	for _, c := range matches {
+		if c.Match(args1) {
+			hit(c)
+			// 条件的描述需要格式化参数, 只在输出事件或者记录时间线时构造
+			if logging.Enabled(logging.LevelDebug) {
+				w.emit(logging.Match, "condition matched", args1, logging.Any(logging.KeyCondition, describe(c)))
 			}
+			if currentTrace() != nil {
+				w.trace(describe(c))
+			}
+			return w.resultOf(c, args1)
 		}
 	}
-	return w.returnDefaults()
+	hit(defaultReturns)
+	if len(matches) > 0 {
+		w.misses.record(args1)
+		w.emit(logging.Miss, "no condition matched", args1)
+	}
+	if defaultCallOrigin {
+		w.trace("origin")
+		results = w.callOrigin(args1)
+		setArgs(defaultReturns, args1)
+		return results
+	}
+	w.trace("default")
+	results = w.returnDefaults(defaultReturns, args1, matches)
+	setArgs(defaultReturns, args1)
+	return results
+}
+
+// emit 输出参数匹配的结构化事件
+func (w *When) emit(typ logging.Type, msg string, args []reflect.Value, attrs ...logging.Attr) {
+	if !logging.Enabled(logging.LevelDebug) {
+		return
+	}
+	name := w.name()
+	// 输出事件用到了 time.Now, 避免递归死循环
+	if name == excludeFunc {
+		return
+	}
+	attrs = append([]logging.Attr{logging.Any(logging.KeyMocker, name), logging.Any(logging.KeyArgs, arg.SprintV(args))},
+		attrs...)
+	logging.Emit(logging.LevelDebug, typ, msg, attrs...)
+}
+
+// resultOf 获取匹配成功的条件的结果: 调用原函数或者返回指定的值, 并修改参数
+func (w *When) resultOf(c Matcher, args []reflect.Value) []reflect.Value {
+	var results []reflect.Value
+	if m, ok := c.(originMatcher); ok && m.shouldCallOrigin() {
+		results = w.callOrigin(args)
+	} else if imp := matchedImp(c); imp.IsValid() {
+		if imp.Type().IsVariadic() {
+			results = imp.CallSlice(args)
+		} else {
+			results = imp.Call(args)
+		}
+	} else {
+		results = c.Result()
+	}
+	setArgs(c, args)
+	return results
+}
+
+// matchedImp 条件中指定的回调函数, 没有指定时返回零值
+func matchedImp(c Matcher) reflect.Value {
+	if m, ok := c.(impMatcher); ok {
+		return m.matchedImp()
+	}
+	return reflect.Value{}
+}
+
+// setArgs 按照条件中指定的参数修改器修改参数
+func setArgs(c Matcher, args []reflect.Value) {
+	if m, ok := c.(argsMatcher); ok {
+		m.setArgs(args)
+	}
+}
+
+// prepareOrigin 提前分配调用原函数需要的跳板函数, 分配失败时 panic
+func (w *When) prepareOrigin(funcName string) {
+	caller, ok := w.ExportedMocker.(originCaller)
+	if !ok {
+		return
+	}
+	if err := caller.prepareOrigin(); err != nil {
+		panic(erro.NewIllegalStatusError(funcName, "origin func is not available, "+err.Error()))
+	}
+}
+
+// callOrigin 调用原函数
+func (w *When) callOrigin(args []reflect.Value) []reflect.Value {
+	caller, ok := w.ExportedMocker.(originCaller)
+	if !ok {
+		panic(erro.NewIllegalStatusError("CallOrigin", "when is not bound to a mocker"))
+	}
+	results := caller.callOrigin(args)
+	if results == nil {
+		return []reflect.Value{}
+	}
+	return results
 }
 
 // Eval 执行 when 子句
@@ -206,10 +484,19 @@ func (w *When) Eval(params ...interface{}) []interface{} {
 	return arg.V2I(resultVs, outTypes(w.funcTyp))
 }
 
-// returnDefaults 返回默认值
-func (w *When) returnDefaults() []reflect.Value {
-	if w.defaultReturns == nil && w.funcTyp.NumOut() != 0 {
-		panic("there is no suitable condition matched, or set default return with: mocker.Return(...)")
+// returnDefaults 返回默认值, 没有默认值时 panic, 并输出参数和每个条件匹配失败的原因
+func (w *When) returnDefaults(defaultReturns Matcher, args []reflect.Value, matches []Matcher) []reflect.Value {
+	if defaultReturns == nil {
+		if w.funcTyp.NumOut() != 0 {
+			panic(w.mismatchMessage(args, matches))
+		}
+		return []reflect.Value{}
+	}
+	if imp := matchedImp(defaultReturns); imp.IsValid() {
+		if imp.Type().IsVariadic() {
+			return imp.CallSlice(args)
+		}
+		return imp.Call(args)
 	}
-	return w.defaultReturns.Result()
+	return defaultReturns.Result()
 }
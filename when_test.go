@@ -268,3 +268,69 @@ func (s *WhenTestSuite) TestMethodMultiIn() {
 		s.Equal(102, structOuter.Compute(7, -1), "method when check")
 	})
 }
+
+// TestCallOriginIllegal 测试 CallOrigin 的非法使用
+func (s *WhenTestSuite) TestCallOriginIllegal() {
+	s.Run("call origin after return", func() {
+		when := mocker.NewWhen(reflect.TypeOf(simple))
+		s.Panics(func() {
+			when.When(1).Return(2).CallOrigin()
+		}, "call origin after return check")
+	})
+	s.Run("call origin without mocker", func() {
+		when := mocker.NewWhen(reflect.TypeOf(simple))
+		when.When(1).Return(2).DefaultCallOrigin()
+
+		s.Equal(2, when.Eval(1)[0], "when result check")
+		s.Panics(func() {
+			when.Eval(3)
+		}, "call origin without mocker check")
+	})
+}
+
+// unmarshal 通过指针参数返回结果的函数
+func unmarshal(data []byte, v *Result) error {
+	return nil
+}
+
+// fill 通过 slice 和 map 参数返回结果的函数
+func fill(buf []int, m map[string]int) int {
+	return 0
+}
+
+// TestSetArg 测试修改指针、slice 和 map 类型的参数
+func (s *WhenTestSuite) TestSetArg() {
+	s.Run("pointer", func() {
+		when := mocker.NewWhen(reflect.TypeOf(unmarshal))
+		when.When([]byte("1"), arg.Any()).SetArg(1, Result{1}).Return(nil).
+			When([]byte("2"), arg.Any()).SetArg(1, &Result{2}).Return(nil)
+
+		r := &Result{}
+		s.Equal(nil, when.Eval([]byte("1"), r)[0], "when result check")
+		s.Equal(1, r.field1, "set arg check")
+		s.Equal(nil, when.Eval([]byte("2"), r)[0], "when result check")
+		s.Equal(2, r.field1, "set arg pointer value check")
+	})
+	s.Run("slice and map", func() {
+		when := mocker.NewWhen(reflect.TypeOf(fill))
+		when.Return(2).SetArgs([]int{1, 2}, map[string]int{"a": 1})
+
+		buf := make([]int, 3)
+		m := map[string]int{}
+		s.Equal(2, when.Eval(buf, m)[0], "when result check")
+		s.Equal([]int{1, 2, 0}, buf, "set slice arg check")
+		s.Equal(map[string]int{"a": 1}, m, "set map arg check")
+	})
+	s.Run("illegal", func() {
+		when := mocker.NewWhen(reflect.TypeOf(unmarshal))
+		s.Panics(func() {
+			when.When(arg.Any(), arg.Any()).SetArg(1, "string")
+		}, "set arg type mismatch check")
+		s.Panics(func() {
+			when.When(arg.Any(), arg.Any()).SetArg(2, Result{})
+		}, "set arg out of range check")
+		s.Panics(func() {
+			mocker.NewWhen(reflect.TypeOf(simple)).SetArg(0, 1)
+		}, "set arg not pointer check")
+	})
+}